@@ -0,0 +1,70 @@
+package barcode
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// jenis barcode yang didukung
+const (
+	EAN8  = "EAN-8"
+	UPCA  = "UPC-A"
+	EAN13 = "EAN-13"
+)
+
+// CheckDigit menghitung digit pemeriksa GS1 untuk digits tanpa digit terakhirnya.
+// Dari kanan, digit di posisi ganjil dikali 3 dan posisi genap dikali 1.
+func CheckDigit(digits string) (int, error) {
+	sum := 0
+	for i := len(digits) - 1; i >= 0; i-- {
+		d := digits[i]
+		if d < '0' || d > '9' {
+			return 0, errors.New("barcode must contain digits only")
+		}
+		n := int(d - '0')
+		if (len(digits)-1-i)%2 == 0 {
+			n *= 3
+		}
+		sum += n
+	}
+	return (10 - sum%10) % 10, nil
+}
+
+// Type mengembalikan jenis barcode berdasarkan panjangnya setelah memvalidasi digit pemeriksa
+func Type(code string) (string, error) {
+	var kind string
+	switch len(code) {
+	case 8:
+		kind = EAN8
+	case 12:
+		kind = UPCA
+	case 13:
+		kind = EAN13
+	default:
+		return "", fmt.Errorf("barcode %q must be EAN-8, UPC-A or EAN-13", code)
+	}
+
+	check, err := CheckDigit(code[:len(code)-1])
+	if err != nil {
+		return "", fmt.Errorf("barcode %q must contain digits only", code)
+	}
+	if int(code[len(code)-1]-'0') != check {
+		return "", fmt.Errorf("barcode %q has invalid check digit, expected %d", code, check)
+	}
+	return kind, nil
+}
+
+// Normalize memvalidasi barcode dan mengubah UPC-A menjadi EAN-13 (diawali 0), karena scanner
+// bisa mengirim kode yang sama dalam kedua bentuk
+func Normalize(code string) (string, error) {
+	code = strings.TrimSpace(code)
+	kind, err := Type(code)
+	if err != nil {
+		return "", err
+	}
+	if kind == UPCA {
+		return "0" + code, nil
+	}
+	return code, nil
+}
@@ -0,0 +1,166 @@
+package barcode
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Modules adalah hasil encode barcode: true untuk bar hitam, false untuk spasi, masing-masing selebar satu modul.
+// Quiet zone di kiri dan kanan tidak termasuk.
+type Modules []bool
+
+// pola digit EAN set L (paritas ganjil); set R adalah kebalikan L dan set G adalah R yang dibalik urutannya
+var eanL = [10]string{"0001101", "0011001", "0010011", "0111101", "0100011", "0110001", "0101111", "0111011", "0110111", "0001011"}
+
+// eanParity menentukan set L/G untuk 6 digit kiri EAN-13 berdasarkan digit pertama
+var eanParity = [10]string{"LLLLLL", "LLGLGG", "LLGGLG", "LLGGGL", "LGLLGG", "LGGLLG", "LGGGLG", "LGLGLG", "LGLGGL", "LGGLGL"}
+
+func eanPattern(digit byte, set byte) string {
+	l := eanL[digit-'0']
+	if set == 'L' {
+		return l
+	}
+
+	r := make([]byte, len(l))
+	for i := range l {
+		if l[i] == '0' {
+			r[i] = '1'
+		} else {
+			r[i] = '0'
+		}
+	}
+	if set == 'G' {
+		for i, j := 0, len(r)-1; i < j; i, j = i+1, j-1 {
+			r[i], r[j] = r[j], r[i]
+		}
+	}
+	return string(r)
+}
+
+// EncodeEAN mengubah EAN-13 atau EAN-8 (UPC-A diubah ke EAN-13 dulu) menjadi modul bar
+func EncodeEAN(code string) (Modules, error) {
+	code, err := Normalize(code)
+	if err != nil {
+		return nil, err
+	}
+
+	var b strings.Builder
+	b.WriteString("101")
+	switch len(code) {
+	case 13:
+		parity := eanParity[code[0]-'0']
+		for i := 1; i <= 6; i++ {
+			b.WriteString(eanPattern(code[i], parity[i-1]))
+		}
+		b.WriteString("01010")
+		for i := 7; i <= 12; i++ {
+			b.WriteString(eanPattern(code[i], 'R'))
+		}
+	case 8:
+		for i := 0; i < 4; i++ {
+			b.WriteString(eanPattern(code[i], 'L'))
+		}
+		b.WriteString("01010")
+		for i := 4; i < 8; i++ {
+			b.WriteString(eanPattern(code[i], 'R'))
+		}
+	}
+	b.WriteString("101")
+
+	return modulesFromString(b.String()), nil
+}
+
+// code128Patterns adalah lebar bar/spasi bergantian untuk setiap simbol Code 128, 103-105 adalah start A/B/C
+// dan 106 adalah stop
+var code128Patterns = [107]string{
+	"212222", "222122", "222221", "121223", "121322", "131222", "122213", "122312", "132212", "221213",
+	"221312", "231212", "112232", "122132", "122231", "113222", "123122", "123221", "223211", "221132",
+	"221231", "213212", "223112", "312131", "311222", "321122", "321221", "312212", "322112", "322211",
+	"212123", "212321", "232121", "111323", "131123", "131321", "112313", "132113", "132311", "211313",
+	"231113", "231311", "112133", "112331", "132131", "113123", "113321", "133121", "313121", "211331",
+	"231131", "213113", "213311", "213131", "311123", "311321", "331121", "312113", "312311", "332111",
+	"314111", "221411", "431111", "111224", "111422", "121124", "121421", "141122", "141221", "112214",
+	"112412", "122114", "122411", "142112", "142211", "241211", "221114", "413111", "241112", "134111",
+	"111242", "121142", "121241", "114212", "124112", "124211", "411212", "421112", "421211", "212141",
+	"214121", "412121", "111143", "111341", "131141", "114113", "114311", "411113", "411311", "113141",
+	"114131", "311141", "411131", "211412", "211214", "211232", "2331112",
+}
+
+// Code128 adalah simbologi untuk kode alfanumerik seperti SKU
+const Code128 = "Code-128"
+
+// Encode mengubah code menjadi modul bar sesuai simbologinya (EAN-8, UPC-A, EAN-13 atau Code-128)
+func Encode(symbology, code string) (Modules, error) {
+	switch symbology {
+	case EAN8, UPCA, EAN13:
+		return EncodeEAN(code)
+	case Code128:
+		return EncodeCode128(code)
+	}
+	return nil, fmt.Errorf("unsupported symbology %q", symbology)
+}
+
+const (
+	code128StartB = 104
+	code128StartC = 105
+	code128Stop   = 106
+)
+
+// EncodeCode128 mengubah teks ASCII (spasi sampai ~) menjadi Code 128. Teks angka dengan panjang genap
+// memakai set C supaya barcode lebih pendek, selain itu set B.
+func EncodeCode128(text string) (Modules, error) {
+	if text == "" {
+		return nil, fmt.Errorf("code 128 text must not be empty")
+	}
+
+	var symbols []int
+	if len(text)%2 == 0 && isDigits(text) {
+		symbols = append(symbols, code128StartC)
+		for i := 0; i < len(text); i += 2 {
+			symbols = append(symbols, int(text[i]-'0')*10+int(text[i+1]-'0'))
+		}
+	} else {
+		symbols = append(symbols, code128StartB)
+		for i := 0; i < len(text); i++ {
+			c := text[i]
+			if c < 32 || c > 126 {
+				return nil, fmt.Errorf("code 128 cannot encode character %q", c)
+			}
+			symbols = append(symbols, int(c)-32)
+		}
+	}
+
+	// checksum: start + jumlah simbol x posisinya, modulo 103
+	sum := symbols[0]
+	for i, s := range symbols[1:] {
+		sum += s * (i + 1)
+	}
+	symbols = append(symbols, sum%103, code128Stop)
+
+	var modules Modules
+	for _, s := range symbols {
+		for i, w := range code128Patterns[s] {
+			for n := 0; n < int(w-'0'); n++ {
+				modules = append(modules, i%2 == 0)
+			}
+		}
+	}
+	return modules, nil
+}
+
+func isDigits(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+func modulesFromString(s string) Modules {
+	modules := make(Modules, len(s))
+	for i := range s {
+		modules[i] = s[i] == '1'
+	}
+	return modules
+}
@@ -1,42 +1,524 @@
 create table public.categories (
   id bigint generated by default as identity not null,
+  parent_id bigint null,
   name character varying null,
   description text null,
+  tax_rate numeric(5,2) null,
+  tax_exempt boolean not null default false,
+  archived_at timestamp with time zone null,
   created_at timestamp with time zone not null default now(),
-  constraint categories_pkey primary key (id)
+  constraint categories_pkey primary key (id),
+  constraint fk_categories_parent_id foreign KEY (parent_id) references categories (id) on delete RESTRICT,
+  constraint categories_parent_check check (parent_id <> id)
 ) TABLESPACE pg_default;
 
+create index IF not exists idx_categories_parent_id on public.categories using btree (parent_id) TABLESPACE pg_default;
+
 insert into "public"."categories" ("name", "description", "created_at") values ('Food', 'Semua jenis makanan', '2026-01-28 08:00:00+00'), ('Snacks', 'Camilan ringan', '2026-01-28 08:00:00+00'), ('Beverages', 'Minuman', '2026-01-28 08:00:00+00'), ('Seasoning', 'Bumbu dan saus', '2026-01-28 08:00:00+00'), ('Dairy & Eggs', 'Susu, Keju, Yogurt, Telur', '2026-01-28 09:57:46.426932+00');
 
 create table public.products (
   id bigint generated by default as identity not null,
   created_at timestamp with time zone not null default now(),
   name character varying null,
+  sku character varying null,
   price integer null,
   stock integer null,
+  cost_price integer not null default 0,
+  reorder_point integer null,
+  reorder_quantity integer not null default 0,
   category_id bigint not null,
+  parent_id bigint null,
+  options jsonb null,
+  unit character varying not null default 'pcs',
+  track_expiry boolean not null default false,
   constraint product_pkey primary key (id),
-  constraint fk_products_category_id foreign KEY (category_id) references categories (id) on delete CASCADE
+  constraint products_sku_key unique (sku),
+  constraint fk_products_category_id foreign KEY (category_id) references categories (id) on delete RESTRICT,
+  constraint products_parent_id_fkey foreign KEY (parent_id) references products (id),
+  constraint products_variant_options_check check (parent_id is null or options is not null)
 ) TABLESPACE pg_default;
 
 insert into "public"."products" ("created_at", "name", "price", "stock", "category_id") values ('2026-01-27 13:18:27.558298+00', 'Indomie Rebus', '3500', '10', '1'), ('2026-01-27 13:18:50.660861+00', 'Vit 1000ml', '3000', '40', '3'), ('2026-01-27 13:19:23.428699+00', 'Kecap', '12000', '20', '4'), ('2026-01-28 09:52:48.015086+00', 'Fanta', '4500', '15', '3');
 
 create index IF not exists idx_products_category_id on public.products using btree (category_id) TABLESPACE pg_default;
+create index IF not exists idx_products_parent_id on public.products using btree (parent_id) TABLESPACE pg_default;
+
+create table public.product_units (
+  id bigint generated by default as identity not null,
+  product_id bigint not null,
+  name character varying not null,
+  factor integer not null,
+  price integer not null,
+  constraint product_units_pkey primary key (id),
+  constraint product_units_product_id_name_key unique (product_id, name),
+  constraint product_units_product_id_fkey foreign KEY (product_id) references products (id) on delete CASCADE,
+  constraint product_units_factor_check check (factor > 1)
+) TABLESPACE pg_default;
+
+create table public.product_components (
+  product_id bigint not null,
+  component_id bigint not null,
+  quantity integer not null,
+  constraint product_components_pkey primary key (product_id, component_id),
+  constraint product_components_product_id_fkey foreign KEY (product_id) references products (id) on delete CASCADE,
+  constraint product_components_component_id_fkey foreign KEY (component_id) references products (id) on delete RESTRICT,
+  constraint product_components_quantity_check check (quantity > 0),
+  constraint product_components_self_check check (product_id <> component_id)
+) TABLESPACE pg_default;
+
+create index IF not exists idx_product_components_component_id on public.product_components using btree (component_id) TABLESPACE pg_default;
+
+create table public.product_barcodes (
+  code character varying not null,
+  product_id bigint not null,
+  unit_id bigint null,
+  created_at timestamp with time zone not null default now(),
+  constraint product_barcodes_pkey primary key (code),
+  constraint product_barcodes_product_id_fkey foreign KEY (product_id) references products (id) on delete CASCADE,
+  constraint product_barcodes_unit_id_fkey foreign KEY (unit_id) references product_units (id) on delete CASCADE
+) TABLESPACE pg_default;
+
+create index IF not exists idx_product_barcodes_product_id on public.product_barcodes using btree (product_id) TABLESPACE pg_default;
+
+create table public.outlets (
+  id bigint generated by default as identity not null,
+  name character varying not null,
+  address text null,
+  api_key character varying null,
+  is_default boolean not null default false,
+  service_charge_rate numeric(5, 2) not null default 0,
+  service_charge_taxable boolean not null default true,
+  created_at timestamp with time zone not null default now(),
+  constraint outlets_pkey primary key (id),
+  constraint outlets_api_key_key unique (api_key)
+) TABLESPACE pg_default;
+
+create unique index IF not exists idx_outlets_default on public.outlets using btree (is_default) TABLESPACE pg_default where is_default;
+
+insert into "public"."outlets" ("name", "is_default") values ('Toko Utama', true);
+
+create table public.outlet_stocks (
+  outlet_id bigint not null,
+  product_id bigint not null,
+  stock integer not null default 0,
+  low_stock_alerted_at timestamp with time zone null,
+  constraint outlet_stocks_pkey primary key (outlet_id, product_id),
+  constraint outlet_stocks_outlet_id_fkey foreign KEY (outlet_id) references outlets (id) on delete RESTRICT,
+  constraint outlet_stocks_product_id_fkey foreign KEY (product_id) references products (id) on delete CASCADE
+) TABLESPACE pg_default;
+
+insert into "public"."outlet_stocks" ("outlet_id", "product_id", "stock") select 1, id, coalesce(stock, 0) from products;
 
 create table public.transactions (
   id bigint generated by default as identity not null,
+  outlet_id bigint null,
+  gross_amount integer not null default 0,
+  promotion_discount_amount integer not null default 0,
+  line_discount_amount integer not null default 0,
+  discount_amount integer not null default 0,
+  voucher_code character varying null,
+  voucher_discount_amount integer not null default 0,
+  customer character varying null,
+  subtotal_amount integer not null default 0,
+  tax_mode character varying not null default 'exclusive',
+  taxable_amount integer not null default 0,
+  tax_amount integer not null default 0,
+  service_charge_amount integer not null default 0,
   total_amount integer not null,
+  status character varying not null default 'completed',
+  refunded_amount integer not null default 0,
+  paid_amount integer not null default 0,
+  change_amount integer not null default 0,
+  void_reason text null,
+  voided_by character varying null,
+  voided_at timestamp without time zone null,
   created_at timestamp without time zone null default CURRENT_TIMESTAMP,
-  constraint transactions_pkey primary key (id)
+  constraint transactions_pkey primary key (id),
+  constraint transactions_outlet_id_fkey foreign KEY (outlet_id) references outlets (id) on delete RESTRICT
 ) TABLESPACE pg_default;
 
 create table public.transaction_details (
   id bigint generated by default as identity not null,
   transaction_id bigint null,
   product_id bigint null,
+  product_name character varying null,
+  category_name character varying null,
+  unit_price integer null,
+  unit_cost integer not null default 0,
   quantity integer not null,
+  unit character varying null,
+  unit_factor integer not null default 1,
+  promotion_discount integer not null default 0,
+  discount_amount integer not null default 0,
   subtotal integer not null,
+  allocated_discount integer not null default 0,
+  tax_rate numeric(5,2) not null default 0,
+  taxable_amount integer not null default 0,
+  tax_amount integer not null default 0,
+  service_charge_amount integer not null default 0,
+  line_total integer not null default 0,
+  refunded_quantity integer not null default 0,
   constraint transactions_details_pkey primary key (id),
-  constraint transactions_details_product_id_fkey foreign KEY (product_id) references products (id),
+  constraint transactions_details_product_id_fkey foreign KEY (product_id) references products (id) on delete SET NULL,
   constraint transactions_details_transaction_id_fkey foreign KEY (transaction_id) references transactions (id) on delete CASCADE
-) TABLESPACE pg_default;
\ No newline at end of file
+) TABLESPACE pg_default;
+
+create table public.transaction_detail_components (
+  id bigint generated by default as identity not null,
+  transaction_detail_id bigint not null,
+  product_id bigint null,
+  product_name character varying null,
+  quantity integer not null,
+  unit_cost integer not null default 0,
+  constraint transaction_detail_components_pkey primary key (id),
+  constraint transaction_detail_components_transaction_detail_id_fkey foreign KEY (transaction_detail_id) references transaction_details (id) on delete CASCADE,
+  constraint transaction_detail_components_product_id_fkey foreign KEY (product_id) references products (id) on delete SET NULL
+) TABLESPACE pg_default;
+
+create index IF not exists idx_transaction_detail_components_detail_id on public.transaction_detail_components using btree (transaction_detail_id) TABLESPACE pg_default;
+
+create table public.transaction_refunds (
+  id bigint generated by default as identity not null,
+  transaction_id bigint not null,
+  type character varying not null,
+  reason text not null,
+  refunded_by character varying not null,
+  amount integer not null,
+  created_at timestamp without time zone null default CURRENT_TIMESTAMP,
+  constraint transaction_refunds_pkey primary key (id),
+  constraint transaction_refunds_transaction_id_fkey foreign KEY (transaction_id) references transactions (id) on delete CASCADE
+) TABLESPACE pg_default;
+
+create table public.transaction_refund_items (
+  id bigint generated by default as identity not null,
+  refund_id bigint not null,
+  transaction_detail_id bigint not null,
+  quantity integer not null,
+  amount integer not null,
+  constraint transaction_refund_items_pkey primary key (id),
+  constraint transaction_refund_items_refund_id_fkey foreign KEY (refund_id) references transaction_refunds (id) on delete CASCADE,
+  constraint transaction_refund_items_detail_id_fkey foreign KEY (transaction_detail_id) references transaction_details (id) on delete CASCADE
+) TABLESPACE pg_default;
+
+create table public.transaction_payments (
+  id bigint generated by default as identity not null,
+  transaction_id bigint not null,
+  method character varying not null,
+  amount integer not null,
+  change_amount integer not null default 0,
+  created_at timestamp without time zone null default CURRENT_TIMESTAMP,
+  constraint transaction_payments_pkey primary key (id),
+  constraint transaction_payments_transaction_id_fkey foreign KEY (transaction_id) references transactions (id) on delete CASCADE
+) TABLESPACE pg_default;
+
+create index IF not exists idx_transaction_payments_transaction_id on public.transaction_payments using btree (transaction_id) TABLESPACE pg_default;
+
+create table public.idempotency_keys (
+  key character varying not null,
+  request_hash character varying not null,
+  transaction_id bigint null,
+  created_at timestamp with time zone not null default now(),
+  expires_at timestamp with time zone not null,
+  constraint idempotency_keys_pkey primary key (key),
+  constraint idempotency_keys_transaction_id_fkey foreign KEY (transaction_id) references transactions (id) on delete CASCADE
+) TABLESPACE pg_default;
+
+create index IF not exists idx_idempotency_keys_expires_at on public.idempotency_keys using btree (expires_at) TABLESPACE pg_default;
+
+create table public.promotions (
+  id bigint generated by default as identity not null,
+  name character varying not null,
+  type character varying not null,
+  priority integer not null default 0,
+  stackable boolean not null default false,
+  active boolean not null default true,
+  product_id bigint null,
+  category_id bigint null,
+  buy_quantity integer not null default 0,
+  get_quantity integer not null default 0,
+  bundle_price integer not null default 0,
+  percent numeric(5,2) not null default 0,
+  start_date timestamp with time zone null,
+  end_date timestamp with time zone null,
+  start_time time without time zone null,
+  end_time time without time zone null,
+  created_at timestamp with time zone not null default now(),
+  constraint promotions_pkey primary key (id),
+  constraint promotions_product_id_fkey foreign KEY (product_id) references products (id) on delete CASCADE,
+  constraint promotions_category_id_fkey foreign KEY (category_id) references categories (id) on delete CASCADE
+) TABLESPACE pg_default;
+
+create table public.promotion_bundle_items (
+  promotion_id bigint not null,
+  product_id bigint not null,
+  quantity integer not null,
+  constraint promotion_bundle_items_pkey primary key (promotion_id, product_id),
+  constraint promotion_bundle_items_promotion_id_fkey foreign KEY (promotion_id) references promotions (id) on delete CASCADE,
+  constraint promotion_bundle_items_product_id_fkey foreign KEY (product_id) references products (id) on delete CASCADE
+) TABLESPACE pg_default;
+
+create table public.transaction_promotions (
+  id bigint generated by default as identity not null,
+  transaction_id bigint not null,
+  promotion_id bigint null,
+  promotion_name character varying not null,
+  discount_amount integer not null,
+  constraint transaction_promotions_pkey primary key (id),
+  constraint transaction_promotions_transaction_id_fkey foreign KEY (transaction_id) references transactions (id) on delete CASCADE,
+  constraint transaction_promotions_promotion_id_fkey foreign KEY (promotion_id) references promotions (id) on delete SET NULL
+) TABLESPACE pg_default;
+
+create table public.voucher_batches (
+  id bigint generated by default as identity not null,
+  name character varying not null,
+  type character varying not null,
+  value numeric(12,2) not null,
+  max_discount integer not null default 0,
+  min_spend integer not null default 0,
+  expires_at timestamp with time zone null,
+  usage_limit integer not null default 1,
+  per_customer_limit integer not null default 0,
+  active boolean not null default true,
+  created_at timestamp with time zone not null default now(),
+  constraint voucher_batches_pkey primary key (id)
+) TABLESPACE pg_default;
+
+create table public.vouchers (
+  id bigint generated by default as identity not null,
+  batch_id bigint not null,
+  code character varying not null,
+  used_count integer not null default 0,
+  created_at timestamp with time zone not null default now(),
+  constraint vouchers_pkey primary key (id),
+  constraint vouchers_code_key unique (code),
+  constraint vouchers_batch_id_fkey foreign KEY (batch_id) references voucher_batches (id) on delete CASCADE
+) TABLESPACE pg_default;
+
+create table public.voucher_redemptions (
+  id bigint generated by default as identity not null,
+  voucher_id bigint not null,
+  transaction_id bigint not null,
+  customer character varying null,
+  discount_amount integer not null,
+  created_at timestamp with time zone not null default now(),
+  constraint voucher_redemptions_pkey primary key (id),
+  constraint voucher_redemptions_voucher_id_fkey foreign KEY (voucher_id) references vouchers (id) on delete CASCADE,
+  constraint voucher_redemptions_transaction_id_fkey foreign KEY (transaction_id) references transactions (id) on delete CASCADE
+) TABLESPACE pg_default;
+
+create index IF not exists idx_voucher_redemptions_customer on public.voucher_redemptions using btree (customer) TABLESPACE pg_default;
+
+create table public.carts (
+  id bigint generated by default as identity not null,
+  outlet_id bigint null,
+  name character varying null,
+  customer character varying null,
+  status character varying not null default 'open',
+  reserve_stock boolean not null default false,
+  transaction_id bigint null,
+  created_at timestamp with time zone not null default now(),
+  updated_at timestamp with time zone not null default now(),
+  constraint carts_pkey primary key (id),
+  constraint carts_transaction_id_fkey foreign KEY (transaction_id) references transactions (id) on delete SET NULL,
+  constraint carts_outlet_id_fkey foreign KEY (outlet_id) references outlets (id) on delete RESTRICT
+) TABLESPACE pg_default;
+
+create table public.cart_items (
+  cart_id bigint not null,
+  product_id bigint not null,
+  unit character varying not null default '',
+  unit_factor integer not null default 1,
+  quantity integer not null,
+  created_at timestamp with time zone not null default now(),
+  constraint cart_items_pkey primary key (cart_id, product_id, unit),
+  constraint cart_items_cart_id_fkey foreign KEY (cart_id) references carts (id) on delete CASCADE,
+  constraint cart_items_product_id_fkey foreign KEY (product_id) references products (id) on delete CASCADE
+) TABLESPACE pg_default;
+
+create index IF not exists idx_carts_status on public.carts using btree (status) TABLESPACE pg_default;
+
+create table public.stock_movements (
+  id bigint generated by default as identity not null,
+  product_id bigint not null,
+  outlet_id bigint null,
+  type character varying not null,
+  quantity integer not null,
+  balance integer not null,
+  reason character varying null,
+  reference_type character varying null,
+  reference_id bigint null,
+  "user" character varying null,
+  created_at timestamp with time zone not null default now(),
+  constraint stock_movements_pkey primary key (id),
+  constraint stock_movements_product_id_fkey foreign KEY (product_id) references products (id) on delete RESTRICT,
+  constraint stock_movements_outlet_id_fkey foreign KEY (outlet_id) references outlets (id) on delete RESTRICT
+) TABLESPACE pg_default;
+
+create index IF not exists idx_stock_movements_product_id on public.stock_movements using btree (product_id, created_at) TABLESPACE pg_default;
+create index IF not exists idx_stock_movements_reference on public.stock_movements using btree (reference_type, reference_id) TABLESPACE pg_default;
+
+create table public.stock_lots (
+  id bigint generated by default as identity not null,
+  product_id bigint not null,
+  outlet_id bigint not null,
+  lot_number character varying not null default '',
+  expiry_date date null,
+  quantity integer not null default 0,
+  created_at timestamp with time zone not null default now(),
+  constraint stock_lots_pkey primary key (id),
+  constraint stock_lots_product_id_fkey foreign KEY (product_id) references products (id) on delete CASCADE,
+  constraint stock_lots_outlet_id_fkey foreign KEY (outlet_id) references outlets (id) on delete RESTRICT,
+  constraint stock_lots_quantity_check check (quantity >= 0)
+) TABLESPACE pg_default;
+
+create unique index IF not exists idx_stock_lots_key on public.stock_lots using btree (outlet_id, product_id, lot_number, (coalesce(expiry_date, 'infinity'::date))) TABLESPACE pg_default;
+create index IF not exists idx_stock_lots_expiry_date on public.stock_lots using btree (expiry_date) TABLESPACE pg_default where quantity > 0;
+
+create table public.stock_movement_lots (
+  stock_movement_id bigint not null,
+  lot_id bigint not null,
+  quantity integer not null,
+  constraint stock_movement_lots_pkey primary key (stock_movement_id, lot_id),
+  constraint stock_movement_lots_stock_movement_id_fkey foreign KEY (stock_movement_id) references stock_movements (id) on delete CASCADE,
+  constraint stock_movement_lots_lot_id_fkey foreign KEY (lot_id) references stock_lots (id) on delete CASCADE
+) TABLESPACE pg_default;
+
+create table public.stock_adjustments (
+  id bigint generated by default as identity not null,
+  outlet_id bigint null,
+  reason character varying not null,
+  note character varying null,
+  adjusted_by character varying null,
+  created_at timestamp with time zone not null default now(),
+  constraint stock_adjustments_pkey primary key (id),
+  constraint stock_adjustments_outlet_id_fkey foreign KEY (outlet_id) references outlets (id) on delete RESTRICT
+) TABLESPACE pg_default;
+
+create table public.stock_adjustment_items (
+  adjustment_id bigint not null,
+  product_id bigint not null,
+  quantity integer not null,
+  balance integer not null,
+  lot_id bigint null,
+  constraint stock_adjustment_items_pkey primary key (adjustment_id, product_id),
+  constraint stock_adjustment_items_adjustment_id_fkey foreign KEY (adjustment_id) references stock_adjustments (id) on delete CASCADE,
+  constraint stock_adjustment_items_product_id_fkey foreign KEY (product_id) references products (id) on delete CASCADE,
+  constraint stock_adjustment_items_lot_id_fkey foreign KEY (lot_id) references stock_lots (id) on delete set null
+) TABLESPACE pg_default;
+
+create table public.stocktakes (
+  id bigint generated by default as identity not null,
+  outlet_id bigint null,
+  name character varying null,
+  status character varying not null default 'open',
+  opened_by character varying null,
+  posted_by character varying null,
+  created_at timestamp with time zone not null default now(),
+  posted_at timestamp with time zone null,
+  constraint stocktakes_pkey primary key (id),
+  constraint stocktakes_outlet_id_fkey foreign KEY (outlet_id) references outlets (id) on delete RESTRICT
+) TABLESPACE pg_default;
+
+create table public.stocktake_counts (
+  stocktake_id bigint not null,
+  product_id bigint not null,
+  counted_quantity integer not null,
+  system_stock integer null,
+  counted_by character varying null,
+  updated_at timestamp with time zone not null default now(),
+  constraint stocktake_counts_pkey primary key (stocktake_id, product_id),
+  constraint stocktake_counts_stocktake_id_fkey foreign KEY (stocktake_id) references stocktakes (id) on delete CASCADE,
+  constraint stocktake_counts_product_id_fkey foreign KEY (product_id) references products (id) on delete CASCADE
+) TABLESPACE pg_default;
+
+create table public.suppliers (
+  id bigint generated by default as identity not null,
+  name character varying not null,
+  contact character varying null,
+  phone character varying null,
+  email character varying null,
+  address text null,
+  created_at timestamp with time zone not null default now(),
+  constraint suppliers_pkey primary key (id)
+) TABLESPACE pg_default;
+
+create table public.purchase_orders (
+  id bigint generated by default as identity not null,
+  outlet_id bigint null,
+  supplier_id bigint not null,
+  status character varying not null default 'draft',
+  note text null,
+  created_by character varying null,
+  created_at timestamp with time zone not null default now(),
+  ordered_at timestamp with time zone null,
+  closed_at timestamp with time zone null,
+  constraint purchase_orders_pkey primary key (id),
+  constraint purchase_orders_supplier_id_fkey foreign KEY (supplier_id) references suppliers (id) on delete RESTRICT,
+  constraint purchase_orders_outlet_id_fkey foreign KEY (outlet_id) references outlets (id) on delete RESTRICT
+) TABLESPACE pg_default;
+
+create index IF not exists idx_purchase_orders_supplier_id on public.purchase_orders using btree (supplier_id) TABLESPACE pg_default;
+
+create table public.purchase_order_lines (
+  id bigint generated by default as identity not null,
+  purchase_order_id bigint not null,
+  product_id bigint not null,
+  quantity integer not null,
+  unit character varying null,
+  unit_factor integer not null default 1,
+  unit_cost integer not null default 0,
+  received_quantity integer not null default 0,
+  constraint purchase_order_lines_pkey primary key (id),
+  constraint purchase_order_lines_purchase_order_id_fkey foreign KEY (purchase_order_id) references purchase_orders (id) on delete CASCADE,
+  constraint purchase_order_lines_product_id_fkey foreign KEY (product_id) references products (id) on delete RESTRICT
+) TABLESPACE pg_default;
+
+create table public.goods_receipts (
+  id bigint generated by default as identity not null,
+  purchase_order_id bigint not null,
+  received_by character varying null,
+  note text null,
+  created_at timestamp with time zone not null default now(),
+  constraint goods_receipts_pkey primary key (id),
+  constraint goods_receipts_purchase_order_id_fkey foreign KEY (purchase_order_id) references purchase_orders (id) on delete CASCADE
+) TABLESPACE pg_default;
+
+create table public.goods_receipt_items (
+  id bigint generated by default as identity not null,
+  goods_receipt_id bigint not null,
+  purchase_order_line_id bigint not null,
+  product_id bigint not null,
+  quantity integer not null,
+  unit_cost integer not null default 0,
+  lot_number character varying null,
+  expiry_date date null,
+  constraint goods_receipt_items_pkey primary key (id),
+  constraint goods_receipt_items_goods_receipt_id_fkey foreign KEY (goods_receipt_id) references goods_receipts (id) on delete CASCADE,
+  constraint goods_receipt_items_purchase_order_line_id_fkey foreign KEY (purchase_order_line_id) references purchase_order_lines (id) on delete CASCADE
+) TABLESPACE pg_default;
+
+create table public.stock_transfers (
+  id bigint generated by default as identity not null,
+  from_outlet_id bigint not null,
+  to_outlet_id bigint not null,
+  status character varying not null default 'draft',
+  note text null,
+  created_by character varying null,
+  sent_by character varying null,
+  received_by character varying null,
+  created_at timestamp with time zone not null default now(),
+  sent_at timestamp with time zone null,
+  received_at timestamp with time zone null,
+  constraint stock_transfers_pkey primary key (id),
+  constraint stock_transfers_from_outlet_id_fkey foreign KEY (from_outlet_id) references outlets (id) on delete RESTRICT,
+  constraint stock_transfers_to_outlet_id_fkey foreign KEY (to_outlet_id) references outlets (id) on delete RESTRICT
+) TABLESPACE pg_default;
+
+create table public.stock_transfer_items (
+  transfer_id bigint not null,
+  product_id bigint not null,
+  quantity integer not null,
+  constraint stock_transfer_items_pkey primary key (transfer_id, product_id),
+  constraint stock_transfer_items_transfer_id_fkey foreign KEY (transfer_id) references stock_transfers (id) on delete CASCADE,
+  constraint stock_transfer_items_product_id_fkey foreign KEY (product_id) references products (id) on delete RESTRICT
+) TABLESPACE pg_default;
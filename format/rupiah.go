@@ -0,0 +1,34 @@
+package format
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Number memformat angka dengan pemisah ribuan titik, misal 1250000 -> "1.250.000"
+func Number(n int) string {
+	sign := ""
+	if n < 0 {
+		sign = "-"
+		n = -n
+	}
+
+	digits := strconv.Itoa(n)
+	var b strings.Builder
+	for i, d := range digits {
+		if i > 0 && (len(digits)-i)%3 == 0 {
+			b.WriteByte('.')
+		}
+		b.WriteRune(d)
+	}
+
+	return sign + b.String()
+}
+
+// Rupiah memformat nominal rupiah, misal 12500 -> "Rp 12.500"
+func Rupiah(amount int) string {
+	if amount < 0 {
+		return "-Rp " + Number(-amount)
+	}
+	return "Rp " + Number(amount)
+}
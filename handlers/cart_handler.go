@@ -0,0 +1,237 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"kasir-api/middlewares"
+	"kasir-api/models"
+	"kasir-api/repositories"
+	"kasir-api/services"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+type CartHandler struct {
+	service *services.CartService
+}
+
+func NewCartHandler(service *services.CartService) *CartHandler {
+	return &CartHandler{service: service}
+}
+
+// HandleCarts - GET /api/carts?status=open&outlet_id=, POST /api/carts
+func (h *CartHandler) HandleCarts(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		h.GetAll(w, r)
+	case http.MethodPost:
+		h.Create(w, r)
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (h *CartHandler) GetAll(w http.ResponseWriter, r *http.Request) {
+	outletID, err := outletFilter(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	carts, err := h.service.GetAll(r.URL.Query().Get("status"), outletID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(carts)
+}
+
+func (h *CartHandler) Create(w http.ResponseWriter, r *http.Request) {
+	var input models.CartInput
+	err := json.NewDecoder(r.Body).Decode(&input)
+	if err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	input.OutletID = middlewares.Outlet(r.Context()).ID
+
+	cart, err := h.service.Create(&input)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(cart)
+}
+
+// HandleCartByID - GET, DELETE /api/carts/{id}, POST /api/carts/{id}/items,
+// PUT, DELETE /api/carts/{id}/items/{product_id}, POST /api/carts/{id}/checkout
+func (h *CartHandler) HandleCartByID(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/api/carts/"), "/")
+	id, err := strconv.Atoi(parts[0])
+	if err != nil {
+		http.Error(w, "invalid cart ID", http.StatusBadRequest)
+		return
+	}
+
+	// API key outlet hanya boleh mengakses cart outletnya sendiri
+	if outlet := middlewares.Outlet(r.Context()); outlet.Fixed {
+		cart, err := h.service.GetByID(id)
+		if err != nil || cart.OutletID != outlet.ID {
+			http.Error(w, "cart not found", http.StatusNotFound)
+			return
+		}
+	}
+
+	switch {
+	case len(parts) == 1:
+		switch r.Method {
+		case http.MethodGet:
+			h.GetByID(w, r, id)
+		case http.MethodDelete:
+			h.Cancel(w, r, id)
+		default:
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		}
+	case len(parts) == 2 && parts[1] == "items":
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		h.AddItem(w, r, id)
+	case len(parts) == 3 && parts[1] == "items":
+		productID, err := strconv.Atoi(parts[2])
+		if err != nil {
+			http.Error(w, "invalid product ID", http.StatusBadRequest)
+			return
+		}
+		switch r.Method {
+		case http.MethodPut:
+			h.SetItem(w, r, id, productID)
+		case http.MethodDelete:
+			h.RemoveItem(w, r, id, productID)
+		default:
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		}
+	case len(parts) == 2 && parts[1] == "checkout":
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		h.Checkout(w, r, id)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (h *CartHandler) GetByID(w http.ResponseWriter, r *http.Request, id int) {
+	cart, err := h.service.GetByID(id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(cart)
+}
+
+func (h *CartHandler) Cancel(w http.ResponseWriter, r *http.Request, id int) {
+	err := h.service.Cancel(id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{
+		"message": "cart cancelled successfully",
+	})
+}
+
+func (h *CartHandler) AddItem(w http.ResponseWriter, r *http.Request, id int) {
+	var input models.CartItemInput
+	err := json.NewDecoder(r.Body).Decode(&input)
+	if err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	cart, err := h.service.AddItem(id, &input)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(cart)
+}
+
+func (h *CartHandler) SetItem(w http.ResponseWriter, r *http.Request, id, productID int) {
+	var input models.CartItemInput
+	err := json.NewDecoder(r.Body).Decode(&input)
+	if err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	input.ProductID = productID
+	cart, err := h.service.SetItem(id, &input)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(cart)
+}
+
+func (h *CartHandler) RemoveItem(w http.ResponseWriter, r *http.Request, id, productID int) {
+	// satuan item lewat query ?unit=, kosong berarti satuan dasar
+	cart, err := h.service.RemoveItem(id, productID, r.URL.Query().Get("unit"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(cart)
+}
+
+func (h *CartHandler) Checkout(w http.ResponseWriter, r *http.Request, id int) {
+	// body opsional: diskon, voucher dan pembayaran
+	var req models.CheckoutRequest
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil && err != io.EOF {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	key := strings.TrimSpace(r.Header.Get("Idempotency-Key"))
+	if len(key) > 255 {
+		http.Error(w, "Idempotency-Key must be at most 255 characters", http.StatusBadRequest)
+		return
+	}
+
+	transaction, replayed, err := h.service.Checkout(id, req, key)
+	if errors.Is(err, repositories.ErrIdempotencyKeyConflict) {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if replayed {
+		w.Header().Set("Idempotent-Replayed", "true")
+	}
+	json.NewEncoder(w).Encode(transaction)
+}
@@ -2,7 +2,7 @@ package handlers
 
 import (
 	"encoding/json"
-	"fmt"
+	"errors"
 	"kasir-api/models"
 	"kasir-api/services"
 	"net/http"
@@ -18,7 +18,7 @@ func NewCategoryHandler(service *services.CategoryService) *CategoryHandler {
 	return &CategoryHandler{service: service}
 }
 
-// HandleCategories - GET, POST /api/categories
+// HandleCategories - GET /api/categories?include_archived=true, POST /api/categories
 func (h *CategoryHandler) HandleCategories(w http.ResponseWriter, r *http.Request) {
 	switch r.Method {
 	case http.MethodGet:
@@ -31,7 +31,7 @@ func (h *CategoryHandler) HandleCategories(w http.ResponseWriter, r *http.Reques
 }
 
 func (h *CategoryHandler) GetAll(w http.ResponseWriter, r *http.Request) {
-	categories, err := h.service.GetAll()
+	categories, err := h.service.GetAll(r.URL.Query().Get("include_archived") == "true")
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -60,28 +60,56 @@ func (h *CategoryHandler) Create(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(category)
 }
 
-// HandleCategoryByID - GET, PUT, DELETE /api/categories/{id}
-func (h *CategoryHandler) HandleCategoryByID(w http.ResponseWriter, r *http.Request) {
+// HandleCategoryTree - GET /api/categories/tree?include_archived=true
+func (h *CategoryHandler) HandleCategoryTree(w http.ResponseWriter, r *http.Request) {
 	switch r.Method {
 	case http.MethodGet:
-		h.GetByID(w, r)
-	case http.MethodPut:
-		h.Update(w, r)
-	case http.MethodDelete:
-		h.Delete(w, r)
+		h.GetTree(w, r)
 	default:
 		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
 	}
 }
 
-func (h *CategoryHandler) GetByID(w http.ResponseWriter, r *http.Request) {
-	idStr := strings.TrimPrefix(r.URL.Path, "/api/categories/")
+func (h *CategoryHandler) GetTree(w http.ResponseWriter, r *http.Request) {
+	tree, err := h.service.GetTree(r.URL.Query().Get("include_archived") == "true")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(tree)
+}
+
+// HandleCategoryByID - GET, PUT, DELETE /api/categories/{id}, POST /api/categories/{id}/archive,
+// POST /api/categories/{id}/unarchive
+func (h *CategoryHandler) HandleCategoryByID(w http.ResponseWriter, r *http.Request) {
+	idStr, action, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/api/categories/"), "/")
 	id, err := strconv.Atoi(idStr)
 	if err != nil {
 		http.Error(w, "invalid category ID", http.StatusBadRequest)
 		return
 	}
 
+	switch {
+	case action == "" && r.Method == http.MethodGet:
+		h.GetByID(w, r, id)
+	case action == "" && r.Method == http.MethodPut:
+		h.Update(w, r, id)
+	case action == "" && r.Method == http.MethodDelete:
+		h.Delete(w, r, id)
+	case action == "archive" && r.Method == http.MethodPost:
+		h.Archive(w, r, id)
+	case action == "unarchive" && r.Method == http.MethodPost:
+		h.Unarchive(w, r, id)
+	case action != "" && action != "archive" && action != "unarchive":
+		http.NotFound(w, r)
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (h *CategoryHandler) GetByID(w http.ResponseWriter, r *http.Request, id int) {
 	category, err := h.service.GetByID(id)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusNotFound)
@@ -92,17 +120,9 @@ func (h *CategoryHandler) GetByID(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(category)
 }
 
-func (h *CategoryHandler) Update(w http.ResponseWriter, r *http.Request) {
-	idStr := strings.TrimPrefix(r.URL.Path, "/api/categories/")
-	id, err := strconv.Atoi(idStr)
-	if err != nil {
-		fmt.Print("error here")
-		http.Error(w, "invalid category ID", http.StatusBadRequest)
-		return
-	}
-
+func (h *CategoryHandler) Update(w http.ResponseWriter, r *http.Request, id int) {
 	var category models.Category
-	err = json.NewDecoder(r.Body).Decode(&category)
+	err := json.NewDecoder(r.Body).Decode(&category)
 	if err != nil {
 		http.Error(w, "invalid request body", http.StatusBadRequest)
 		return
@@ -111,6 +131,10 @@ func (h *CategoryHandler) Update(w http.ResponseWriter, r *http.Request) {
 	category.ID = id
 	err = h.service.Update(&category)
 	if err != nil {
+		if err.Error() == "category not found" {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
@@ -119,22 +143,78 @@ func (h *CategoryHandler) Update(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(category)
 }
 
-func (h *CategoryHandler) Delete(w http.ResponseWriter, r *http.Request) {
-	idStr := strings.TrimPrefix(r.URL.Path, "/api/categories/")
-	id, err := strconv.Atoi(idStr)
+// Delete - DELETE /api/categories/{id}?reassign_to={id}. Tanpa reassign_to, kategori yang masih punya
+// produk atau sub-kategori ditolak dengan 409.
+func (h *CategoryHandler) Delete(w http.ResponseWriter, r *http.Request, id int) {
+	reassignTo := 0
+	if v := r.URL.Query().Get("reassign_to"); v != "" {
+		target, err := strconv.Atoi(v)
+		if err != nil || target <= 0 {
+			http.Error(w, "invalid reassign_to", http.StatusBadRequest)
+			return
+		}
+		reassignTo = target
+	}
+
+	moved, err := h.service.Delete(id, reassignTo)
 	if err != nil {
-		http.Error(w, "invalid category ID", http.StatusBadRequest)
+		switch {
+		case err.Error() == "category not found":
+			http.Error(w, err.Error(), http.StatusNotFound)
+		case reassignTo == 0:
+			http.Error(w, err.Error(), http.StatusConflict)
+		default:
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		}
 		return
 	}
 
-	err = h.service.Delete(id)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"message":             "category deleted successfully",
+		"products_reassigned": moved,
+	})
+}
+
+func (h *CategoryHandler) Archive(w http.ResponseWriter, r *http.Request, id int) {
+	category, err := h.service.Archive(id)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		if err.Error() == "category not found" {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]string{
-		"message": "category deleted successfully",
-	})
+	json.NewEncoder(w).Encode(category)
+}
+
+func (h *CategoryHandler) Unarchive(w http.ResponseWriter, r *http.Request, id int) {
+	category, err := h.service.Unarchive(id)
+	if err != nil {
+		if err.Error() == "category not found" {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(category)
+}
+
+// categoryFilter membaca query category_id, 0 berarti semua kategori
+func categoryFilter(r *http.Request) (int, error) {
+	v := r.URL.Query().Get("category_id")
+	if v == "" {
+		return 0, nil
+	}
+	id, err := strconv.Atoi(v)
+	if err != nil || id < 0 {
+		return 0, errors.New("invalid category_id")
+	}
+	return id, nil
 }
@@ -0,0 +1,81 @@
+package handlers
+
+import (
+	"encoding/json"
+	"kasir-api/models"
+	"kasir-api/services"
+	"net/http"
+	"strconv"
+)
+
+type InventoryHandler struct {
+	service *services.InventoryService
+}
+
+func NewInventoryHandler(service *services.InventoryService) *InventoryHandler {
+	return &InventoryHandler{service: service}
+}
+
+// HandleLowStock - GET /api/inventory/low-stock?outlet_id=
+func (h *InventoryHandler) HandleLowStock(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		h.GetLowStock(w, r)
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (h *InventoryHandler) GetLowStock(w http.ResponseWriter, r *http.Request) {
+	outletID, err := outletFilter(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	items, err := h.service.GetLowStock(outletID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(items)
+}
+
+// HandleExpiring - GET /api/inventory/expiring?days=7&outlet_id=
+func (h *InventoryHandler) HandleExpiring(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		h.GetExpiring(w, r)
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (h *InventoryHandler) GetExpiring(w http.ResponseWriter, r *http.Request) {
+	outletID, err := outletFilter(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	filter := models.ExpiringLotFilter{Days: 7, OutletID: outletID}
+	if v := r.URL.Query().Get("days"); v != "" {
+		days, err := strconv.Atoi(v)
+		if err != nil || days < 0 || days > 3650 {
+			http.Error(w, "invalid days", http.StatusBadRequest)
+			return
+		}
+		filter.Days = days
+	}
+
+	lots, err := h.service.GetExpiringLots(filter)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(lots)
+}
@@ -0,0 +1,92 @@
+package handlers
+
+import (
+	"fmt"
+	"kasir-api/label"
+	"kasir-api/models"
+	"kasir-api/services"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+type LabelHandler struct {
+	service *services.LabelService
+}
+
+func NewLabelHandler(service *services.LabelService) *LabelHandler {
+	return &LabelHandler{service: service}
+}
+
+// HandleLabels - GET /api/labels?product_ids=1,2,3&category_id=&format=svg|png|pdf&size=50x30&copies=1
+func (h *LabelHandler) HandleLabels(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		h.Render(w, r)
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (h *LabelHandler) Render(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	req := models.LabelRequest{
+		Format: strings.ToLower(query.Get("format")),
+		Width:  label.DefaultSize.Width,
+		Height: label.DefaultSize.Height,
+		Copies: 1,
+	}
+	if req.Format == "" {
+		req.Format = services.LabelFormatSVG
+	}
+
+	if v := query.Get("product_ids"); v != "" {
+		for _, s := range strings.Split(v, ",") {
+			id, err := strconv.Atoi(strings.TrimSpace(s))
+			if err != nil {
+				http.Error(w, "invalid product_ids", http.StatusBadRequest)
+				return
+			}
+			req.ProductIDs = append(req.ProductIDs, id)
+		}
+	}
+	if v := query.Get("category_id"); v != "" {
+		id, err := strconv.Atoi(v)
+		if err != nil {
+			http.Error(w, "invalid category_id", http.StatusBadRequest)
+			return
+		}
+		req.CategoryID = id
+	}
+	if v := query.Get("size"); v != "" {
+		size, err := label.ParseSize(v)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		req.Width, req.Height = size.Width, size.Height
+	}
+	if v := query.Get("copies"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			http.Error(w, "invalid copies", http.StatusBadRequest)
+			return
+		}
+		req.Copies = n
+	}
+
+	body, contentType, err := h.service.Render(req)
+	if err != nil {
+		if strings.HasSuffix(err.Error(), "not found") {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf("inline; filename=\"label.%s\"", req.Format))
+	w.Write(body)
+}
@@ -0,0 +1,179 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"kasir-api/middlewares"
+	"kasir-api/models"
+	"kasir-api/services"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+type OutletHandler struct {
+	service *services.OutletService
+}
+
+func NewOutletHandler(service *services.OutletService) *OutletHandler {
+	return &OutletHandler{service: service}
+}
+
+// HandleOutlets - GET/POST /api/outlets
+func (h *OutletHandler) HandleOutlets(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		h.GetAll(w, r)
+	case http.MethodPost:
+		h.Create(w, r)
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (h *OutletHandler) GetAll(w http.ResponseWriter, r *http.Request) {
+	outlets, err := h.service.GetAll()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	// API key outlet lain hanya terlihat dengan API key utama
+	if middlewares.Outlet(r.Context()).Fixed {
+		for i := range outlets {
+			outlets[i].APIKey = ""
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(outlets)
+}
+
+func (h *OutletHandler) Create(w http.ResponseWriter, r *http.Request) {
+	if !requireMasterKey(w, r) {
+		return
+	}
+
+	var input models.OutletInput
+	err := json.NewDecoder(r.Body).Decode(&input)
+	if err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	outlet, err := h.service.Create(&input)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(outlet)
+}
+
+// HandleOutletByID - GET/PUT /api/outlets/{id}, GET /api/outlets/{id}/stock
+func (h *OutletHandler) HandleOutletByID(w http.ResponseWriter, r *http.Request) {
+	idStr, action, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/api/outlets/"), "/")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		http.Error(w, "invalid outlet ID", http.StatusBadRequest)
+		return
+	}
+
+	switch {
+	case action == "" && r.Method == http.MethodGet:
+		h.GetByID(w, r, id)
+	case action == "" && r.Method == http.MethodPut:
+		h.Update(w, r, id)
+	case action == "stock" && r.Method == http.MethodGet:
+		h.GetStock(w, r, id)
+	case action != "" && action != "stock":
+		http.NotFound(w, r)
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (h *OutletHandler) GetByID(w http.ResponseWriter, r *http.Request, id int) {
+	outlet, err := h.service.GetByID(id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	if middlewares.Outlet(r.Context()).Fixed {
+		outlet.APIKey = ""
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(outlet)
+}
+
+func (h *OutletHandler) Update(w http.ResponseWriter, r *http.Request, id int) {
+	if !requireMasterKey(w, r) {
+		return
+	}
+
+	var input models.OutletInput
+	err := json.NewDecoder(r.Body).Decode(&input)
+	if err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	outlet, err := h.service.Update(id, &input)
+	if err != nil {
+		if err.Error() == "outlet not found" {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(outlet)
+}
+
+func (h *OutletHandler) GetStock(w http.ResponseWriter, r *http.Request, id int) {
+	stocks, err := h.service.GetStock(id)
+	if err != nil {
+		if err.Error() == "outlet not found" {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(stocks)
+}
+
+// requireMasterKey menolak request dengan API key outlet, pengelolaan outlet hanya dengan API key utama
+func requireMasterKey(w http.ResponseWriter, r *http.Request) bool {
+	if middlewares.Outlet(r.Context()).Fixed {
+		http.Error(w, "outlet management requires the main API Key", http.StatusForbidden)
+		return false
+	}
+	return true
+}
+
+// outletFilter membaca query outlet_id untuk laporan dan daftar (0 = semua outlet).
+// Request dengan API key outlet selalu dibatasi ke outletnya sendiri.
+func outletFilter(r *http.Request) (int, error) {
+	if outlet := middlewares.Outlet(r.Context()); outlet.Fixed {
+		return outlet.ID, nil
+	}
+
+	v := r.URL.Query().Get("outlet_id")
+	if v == "" {
+		return 0, nil
+	}
+	id, err := strconv.Atoi(v)
+	if err != nil || id < 0 {
+		return 0, errors.New("invalid outlet_id")
+	}
+	return id, nil
+}
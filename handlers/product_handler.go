@@ -2,11 +2,15 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
+	"kasir-api/middlewares"
 	"kasir-api/models"
 	"kasir-api/services"
 	"net/http"
 	"strconv"
 	"strings"
+	"time"
 )
 
 type ProductHandler struct {
@@ -31,7 +35,13 @@ func (h *ProductHandler) HandleProducts(w http.ResponseWriter, r *http.Request)
 
 func (h *ProductHandler) GetAll(w http.ResponseWriter, r *http.Request) {
 	name := r.URL.Query().Get("name")
-	products, err := h.service.GetAll(name)
+	categoryID, err := categoryFilter(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	products, err := h.service.GetAll(name, categoryID, middlewares.Outlet(r.Context()).ID)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -49,6 +59,8 @@ func (h *ProductHandler) Create(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	input.OutletID = middlewares.Outlet(r.Context()).ID
+
 	product, err := h.service.Create(&input)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusBadRequest)
@@ -60,8 +72,34 @@ func (h *ProductHandler) Create(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(product)
 }
 
-// HandleProductByID - GET/PUT/DELETE /api/product/{id}
+// HandleProductByID - GET/PUT/DELETE /api/product/{id}, GET /api/product/{id}/stock-movements,
+// GET /api/product/{id}/lots, GET /api/product/barcode/{code}
 func (h *ProductHandler) HandleProductByID(w http.ResponseWriter, r *http.Request) {
+	if strings.HasPrefix(r.URL.Path, "/api/product/barcode/") {
+		if r.Method != http.MethodGet {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		h.GetByBarcode(w, r)
+		return
+	}
+	if strings.HasSuffix(r.URL.Path, "/stock-movements") {
+		if r.Method != http.MethodGet {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		h.GetStockMovements(w, r)
+		return
+	}
+	if strings.HasSuffix(r.URL.Path, "/lots") {
+		if r.Method != http.MethodGet {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		h.GetLots(w, r)
+		return
+	}
+
 	switch r.Method {
 	case http.MethodGet:
 		h.GetByID(w, r)
@@ -82,7 +120,7 @@ func (h *ProductHandler) GetByID(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	product, err := h.service.GetByID(id)
+	product, err := h.service.GetByID(id, middlewares.Outlet(r.Context()).ID)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusNotFound)
 		return
@@ -92,6 +130,24 @@ func (h *ProductHandler) GetByID(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(product)
 }
 
+// GetByBarcode - lookup produk untuk scanner
+func (h *ProductHandler) GetByBarcode(w http.ResponseWriter, r *http.Request) {
+	code := strings.TrimPrefix(r.URL.Path, "/api/product/barcode/")
+
+	product, err := h.service.GetByBarcode(code, middlewares.Outlet(r.Context()).ID)
+	if err != nil {
+		if strings.HasSuffix(err.Error(), "not found") {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(product)
+}
+
 func (h *ProductHandler) Update(w http.ResponseWriter, r *http.Request) {
 	idStr := strings.TrimPrefix(r.URL.Path, "/api/product/")
 	id, err := strconv.Atoi(idStr)
@@ -107,6 +163,8 @@ func (h *ProductHandler) Update(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	input.OutletID = middlewares.Outlet(r.Context()).ID
+
 	product, err := h.service.Update(id, &input)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusBadRequest)
@@ -136,3 +194,103 @@ func (h *ProductHandler) Delete(w http.ResponseWriter, r *http.Request) {
 		"message": "product deleted successfully",
 	})
 }
+
+// GetStockMovements - GET /api/product/{id}/stock-movements?start_date=&end_date=&type=&outlet_id=&page=1&limit=20
+func (h *ProductHandler) GetStockMovements(w http.ResponseWriter, r *http.Request) {
+	idStr := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/api/product/"), "/stock-movements")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		http.Error(w, "invalid product ID", http.StatusBadRequest)
+		return
+	}
+
+	filter, err := parseStockMovementFilter(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	movements, err := h.service.GetStockMovements(id, filter)
+	if err != nil {
+		if err.Error() == "product not found" {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(movements)
+}
+
+// GetLots - GET /api/product/{id}/lots?outlet_id=
+func (h *ProductHandler) GetLots(w http.ResponseWriter, r *http.Request) {
+	idStr := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/api/product/"), "/lots")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		http.Error(w, "invalid product ID", http.StatusBadRequest)
+		return
+	}
+
+	outletID, err := outletFilter(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	lots, err := h.service.GetLots(id, outletID)
+	if err != nil {
+		if err.Error() == "product not found" {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(lots)
+}
+
+func parseStockMovementFilter(r *http.Request) (models.StockMovementFilter, error) {
+	q := r.URL.Query()
+	filter := models.StockMovementFilter{
+		StartDate: q.Get("start_date"),
+		EndDate:   q.Get("end_date"),
+		Type:      q.Get("type"),
+		Page:      1,
+		Limit:     20,
+	}
+
+	for _, date := range []string{filter.StartDate, filter.EndDate} {
+		if date == "" {
+			continue
+		}
+		if _, err := time.Parse("2006-01-02", date); err != nil {
+			return filter, fmt.Errorf("invalid date %q, expected YYYY-MM-DD", date)
+		}
+	}
+
+	outletID, err := outletFilter(r)
+	if err != nil {
+		return filter, err
+	}
+	filter.OutletID = outletID
+	if v := q.Get("page"); v != "" {
+		page, err := strconv.Atoi(v)
+		if err != nil || page < 1 {
+			return filter, errors.New("invalid page")
+		}
+		filter.Page = page
+	}
+	if v := q.Get("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit < 1 || limit > 100 {
+			return filter, errors.New("limit must be between 1 and 100")
+		}
+		filter.Limit = limit
+	}
+
+	return filter, nil
+}
@@ -0,0 +1,200 @@
+package handlers
+
+import (
+	"encoding/json"
+	"kasir-api/middlewares"
+	"kasir-api/models"
+	"kasir-api/services"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+type PurchaseOrderHandler struct {
+	service *services.PurchaseOrderService
+}
+
+func NewPurchaseOrderHandler(service *services.PurchaseOrderService) *PurchaseOrderHandler {
+	return &PurchaseOrderHandler{service: service}
+}
+
+// HandlePurchaseOrders - GET /api/purchase-orders?status=&supplier_id=, POST /api/purchase-orders
+func (h *PurchaseOrderHandler) HandlePurchaseOrders(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		h.GetAll(w, r)
+	case http.MethodPost:
+		h.Create(w, r)
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (h *PurchaseOrderHandler) GetAll(w http.ResponseWriter, r *http.Request) {
+	filter := models.PurchaseOrderFilter{Status: r.URL.Query().Get("status")}
+	if v := r.URL.Query().Get("supplier_id"); v != "" {
+		id, err := strconv.Atoi(v)
+		if err != nil {
+			http.Error(w, "invalid supplier_id", http.StatusBadRequest)
+			return
+		}
+		filter.SupplierID = id
+	}
+	outletID, err := outletFilter(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	filter.OutletID = outletID
+
+	orders, err := h.service.GetAll(filter)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(orders)
+}
+
+func (h *PurchaseOrderHandler) Create(w http.ResponseWriter, r *http.Request) {
+	var input models.PurchaseOrderInput
+	err := json.NewDecoder(r.Body).Decode(&input)
+	if err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	input.OutletID = middlewares.Outlet(r.Context()).ID
+
+	order, err := h.service.Create(&input)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(order)
+}
+
+// HandlePurchaseOrderByID - GET, PUT, DELETE /api/purchase-orders/{id}, POST /api/purchase-orders/{id}/order,
+// POST /api/purchase-orders/{id}/receive, POST /api/purchase-orders/{id}/close
+func (h *PurchaseOrderHandler) HandlePurchaseOrderByID(w http.ResponseWriter, r *http.Request) {
+	idStr, action, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/api/purchase-orders/"), "/")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		http.Error(w, "invalid purchase order ID", http.StatusBadRequest)
+		return
+	}
+
+	// API key outlet hanya boleh mengakses purchase order outletnya sendiri
+	if outlet := middlewares.Outlet(r.Context()); outlet.Fixed {
+		order, err := h.service.GetByID(id)
+		if err != nil || order.OutletID != outlet.ID {
+			http.Error(w, "purchase order not found", http.StatusNotFound)
+			return
+		}
+	}
+
+	switch {
+	case action == "" && r.Method == http.MethodGet:
+		h.GetByID(w, r, id)
+	case action == "" && r.Method == http.MethodPut:
+		h.Update(w, r, id)
+	case action == "" && r.Method == http.MethodDelete:
+		h.Delete(w, r, id)
+	case action == "order" && r.Method == http.MethodPost:
+		h.Order(w, r, id)
+	case action == "receive" && r.Method == http.MethodPost:
+		h.Receive(w, r, id)
+	case action == "close" && r.Method == http.MethodPost:
+		h.Close(w, r, id)
+	case action != "" && action != "order" && action != "receive" && action != "close":
+		http.NotFound(w, r)
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (h *PurchaseOrderHandler) GetByID(w http.ResponseWriter, r *http.Request, id int) {
+	order, err := h.service.GetByID(id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(order)
+}
+
+func (h *PurchaseOrderHandler) Update(w http.ResponseWriter, r *http.Request, id int) {
+	var input models.PurchaseOrderInput
+	err := json.NewDecoder(r.Body).Decode(&input)
+	if err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	order, err := h.service.Update(id, &input)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(order)
+}
+
+func (h *PurchaseOrderHandler) Delete(w http.ResponseWriter, r *http.Request, id int) {
+	err := h.service.Delete(id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{
+		"message": "purchase order deleted successfully",
+	})
+}
+
+func (h *PurchaseOrderHandler) Order(w http.ResponseWriter, r *http.Request, id int) {
+	order, err := h.service.Order(id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(order)
+}
+
+func (h *PurchaseOrderHandler) Receive(w http.ResponseWriter, r *http.Request, id int) {
+	var req models.GoodsReceiptRequest
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	order, err := h.service.Receive(id, &req)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(order)
+}
+
+func (h *PurchaseOrderHandler) Close(w http.ResponseWriter, r *http.Request, id int) {
+	order, err := h.service.Close(id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(order)
+}
@@ -14,7 +14,7 @@ func NewReportHandler(service *services.ReportService) *ReportHandler {
 	return &ReportHandler{service: service}
 }
 
-// HandleReportToday - GET/POST /api/report/hari-ini
+// HandleReportToday - GET/POST /api/report/hari-ini?outlet_id=
 func (h *ReportHandler) HandleReportToday(w http.ResponseWriter, r *http.Request) {
 	switch r.Method {
 	case http.MethodGet:
@@ -25,7 +25,13 @@ func (h *ReportHandler) HandleReportToday(w http.ResponseWriter, r *http.Request
 }
 
 func (h *ReportHandler) GetReportToday(w http.ResponseWriter, r *http.Request) {
-	report, err := h.service.GetReportToday()
+	outletID, err := outletFilter(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	report, err := h.service.GetReportToday(outletID)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -35,7 +41,7 @@ func (h *ReportHandler) GetReportToday(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(report)
 }
 
-// HandleReport - GET/POST /api/report?start_date=2026-01-02&end_date=2026-02-03
+// HandleReport - GET/POST /api/report?start_date=2026-01-02&end_date=2026-02-03&outlet_id=
 func (h *ReportHandler) HandleReport(w http.ResponseWriter, r *http.Request) {
 	switch r.Method {
 	case http.MethodGet:
@@ -54,7 +60,124 @@ func (h *ReportHandler) GetReportByDate(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	report, err := h.service.GetReportByDate(startDate, endDate)
+	outletID, err := outletFilter(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	report, err := h.service.GetReportByDate(startDate, endDate, outletID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}
+
+// HandleTaxReport - GET /api/report/pajak?start_date=2026-01-02&end_date=2026-02-03&outlet_id=
+func (h *ReportHandler) HandleTaxReport(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		h.GetTaxReport(w, r)
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (h *ReportHandler) GetTaxReport(w http.ResponseWriter, r *http.Request) {
+	startDate := r.URL.Query().Get("start_date")
+	endDate := r.URL.Query().Get("end_date")
+
+	if startDate == "" || endDate == "" {
+		http.Error(w, "start_date and end_date are required", http.StatusBadRequest)
+		return
+	}
+
+	outletID, err := outletFilter(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	report, err := h.service.GetTaxReport(startDate, endDate, outletID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}
+
+// HandleProfitReport - GET /api/report/laba?start_date=2026-01-02&end_date=2026-02-03&group_by=product|category|day&outlet_id=&category_id=
+func (h *ReportHandler) HandleProfitReport(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		h.GetProfitReport(w, r)
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (h *ReportHandler) GetProfitReport(w http.ResponseWriter, r *http.Request) {
+	startDate := r.URL.Query().Get("start_date")
+	endDate := r.URL.Query().Get("end_date")
+
+	if startDate == "" || endDate == "" {
+		http.Error(w, "start_date and end_date are required", http.StatusBadRequest)
+		return
+	}
+
+	outletID, err := outletFilter(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	categoryID, err := categoryFilter(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	report, err := h.service.GetProfitReport(startDate, endDate, r.URL.Query().Get("group_by"), outletID, categoryID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}
+
+// HandleIngredientReport - GET /api/report/bahan?start_date=2026-01-02&end_date=2026-02-03&outlet_id=
+func (h *ReportHandler) HandleIngredientReport(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		h.GetIngredientReport(w, r)
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (h *ReportHandler) GetIngredientReport(w http.ResponseWriter, r *http.Request) {
+	startDate := r.URL.Query().Get("start_date")
+	endDate := r.URL.Query().Get("end_date")
+
+	if startDate == "" || endDate == "" {
+		http.Error(w, "start_date and end_date are required", http.StatusBadRequest)
+		return
+	}
+
+	outletID, err := outletFilter(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	report, err := h.service.GetIngredientReport(startDate, endDate, outletID)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
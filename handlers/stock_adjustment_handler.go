@@ -0,0 +1,84 @@
+package handlers
+
+import (
+	"encoding/json"
+	"kasir-api/middlewares"
+	"kasir-api/models"
+	"kasir-api/services"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+type StockAdjustmentHandler struct {
+	service *services.StockAdjustmentService
+}
+
+func NewStockAdjustmentHandler(service *services.StockAdjustmentService) *StockAdjustmentHandler {
+	return &StockAdjustmentHandler{service: service}
+}
+
+// HandleStockAdjustments - POST /api/stock-adjustments
+func (h *StockAdjustmentHandler) HandleStockAdjustments(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodPost:
+		h.Create(w, r)
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (h *StockAdjustmentHandler) Create(w http.ResponseWriter, r *http.Request) {
+	var req models.StockAdjustmentRequest
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	req.OutletID = middlewares.Outlet(r.Context()).ID
+
+	adjustment, err := h.service.Create(&req)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(adjustment)
+}
+
+// HandleStockAdjustmentByID - GET /api/stock-adjustments/{id}
+func (h *StockAdjustmentHandler) HandleStockAdjustmentByID(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		h.GetByID(w, r)
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (h *StockAdjustmentHandler) GetByID(w http.ResponseWriter, r *http.Request) {
+	idStr := strings.TrimPrefix(r.URL.Path, "/api/stock-adjustments/")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		http.Error(w, "invalid stock adjustment ID", http.StatusBadRequest)
+		return
+	}
+
+	adjustment, err := h.service.GetByID(id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	// API key outlet hanya boleh melihat penyesuaian stok outletnya sendiri
+	if outlet := middlewares.Outlet(r.Context()); outlet.Fixed && adjustment.OutletID != outlet.ID {
+		http.Error(w, "stock adjustment not found", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(adjustment)
+}
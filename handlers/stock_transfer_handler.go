@@ -0,0 +1,166 @@
+package handlers
+
+import (
+	"encoding/json"
+	"io"
+	"kasir-api/middlewares"
+	"kasir-api/models"
+	"kasir-api/services"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+type StockTransferHandler struct {
+	service *services.StockTransferService
+}
+
+func NewStockTransferHandler(service *services.StockTransferService) *StockTransferHandler {
+	return &StockTransferHandler{service: service}
+}
+
+// HandleStockTransfers - GET /api/stock-transfers?status=&outlet_id=, POST /api/stock-transfers
+func (h *StockTransferHandler) HandleStockTransfers(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		h.GetAll(w, r)
+	case http.MethodPost:
+		h.Create(w, r)
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (h *StockTransferHandler) GetAll(w http.ResponseWriter, r *http.Request) {
+	outletID, err := outletFilter(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	transfers, err := h.service.GetAll(models.StockTransferFilter{Status: r.URL.Query().Get("status"), OutletID: outletID})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(transfers)
+}
+
+func (h *StockTransferHandler) Create(w http.ResponseWriter, r *http.Request) {
+	var input models.StockTransferInput
+	err := json.NewDecoder(r.Body).Decode(&input)
+	if err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	// outlet asal default-nya outlet request; API key outlet hanya boleh mengirim dari outletnya sendiri
+	outlet := middlewares.Outlet(r.Context())
+	if input.FromOutletID == 0 {
+		input.FromOutletID = outlet.ID
+	}
+	if outlet.Fixed && input.FromOutletID != outlet.ID {
+		http.Error(w, "API Key is not valid for this outlet", http.StatusForbidden)
+		return
+	}
+
+	transfer, err := h.service.Create(&input)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(transfer)
+}
+
+// HandleStockTransferByID - GET /api/stock-transfers/{id}, POST /api/stock-transfers/{id}/send,
+// POST /api/stock-transfers/{id}/receive, POST /api/stock-transfers/{id}/cancel
+func (h *StockTransferHandler) HandleStockTransferByID(w http.ResponseWriter, r *http.Request) {
+	idStr, action, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/api/stock-transfers/"), "/")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		http.Error(w, "invalid stock transfer ID", http.StatusBadRequest)
+		return
+	}
+
+	if !h.allowedForOutlet(w, r, id, action) {
+		return
+	}
+
+	switch {
+	case action == "" && r.Method == http.MethodGet:
+		h.GetByID(w, r, id)
+	case action == "send" && r.Method == http.MethodPost:
+		h.Action(w, r, id, h.service.Send)
+	case action == "receive" && r.Method == http.MethodPost:
+		h.Action(w, r, id, h.service.Receive)
+	case action == "cancel" && r.Method == http.MethodPost:
+		h.Action(w, r, id, h.service.Cancel)
+	case action != "" && action != "send" && action != "receive" && action != "cancel":
+		http.NotFound(w, r)
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// allowedForOutlet membatasi API key outlet: transfer hanya terlihat oleh outlet asal dan tujuan,
+// send dan cancel hanya oleh outlet asal, dan receive hanya oleh outlet tujuan
+func (h *StockTransferHandler) allowedForOutlet(w http.ResponseWriter, r *http.Request, id int, action string) bool {
+	outlet := middlewares.Outlet(r.Context())
+	if !outlet.Fixed {
+		return true
+	}
+
+	transfer, err := h.service.GetByID(id)
+	if err != nil || (transfer.FromOutletID != outlet.ID && transfer.ToOutletID != outlet.ID) {
+		http.Error(w, "stock transfer not found", http.StatusNotFound)
+		return false
+	}
+
+	allowed := true
+	switch action {
+	case "send", "cancel":
+		allowed = transfer.FromOutletID == outlet.ID
+	case "receive":
+		allowed = transfer.ToOutletID == outlet.ID
+	}
+	if !allowed {
+		http.Error(w, "API Key is not valid for this outlet", http.StatusForbidden)
+		return false
+	}
+	return true
+}
+
+func (h *StockTransferHandler) GetByID(w http.ResponseWriter, r *http.Request, id int) {
+	transfer, err := h.service.GetByID(id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(transfer)
+}
+
+// Action menjalankan send, receive atau cancel. Body {"user": "..."} opsional.
+func (h *StockTransferHandler) Action(w http.ResponseWriter, r *http.Request, id int, run func(int, *models.StockTransferAction) (*models.StockTransfer, error)) {
+	var action models.StockTransferAction
+	err := json.NewDecoder(r.Body).Decode(&action)
+	if err != nil && err != io.EOF {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	transfer, err := run(id, &action)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(transfer)
+}
@@ -0,0 +1,164 @@
+package handlers
+
+import (
+	"encoding/json"
+	"io"
+	"kasir-api/middlewares"
+	"kasir-api/models"
+	"kasir-api/services"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+type StocktakeHandler struct {
+	service *services.StocktakeService
+}
+
+func NewStocktakeHandler(service *services.StocktakeService) *StocktakeHandler {
+	return &StocktakeHandler{service: service}
+}
+
+// HandleStocktakes - GET /api/stocktakes?status=open&outlet_id=, POST /api/stocktakes
+func (h *StocktakeHandler) HandleStocktakes(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		h.GetAll(w, r)
+	case http.MethodPost:
+		h.Create(w, r)
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (h *StocktakeHandler) GetAll(w http.ResponseWriter, r *http.Request) {
+	outletID, err := outletFilter(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	stocktakes, err := h.service.GetAll(r.URL.Query().Get("status"), outletID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(stocktakes)
+}
+
+func (h *StocktakeHandler) Create(w http.ResponseWriter, r *http.Request) {
+	var input models.StocktakeInput
+	err := json.NewDecoder(r.Body).Decode(&input)
+	if err != nil && err != io.EOF {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	input.OutletID = middlewares.Outlet(r.Context()).ID
+
+	stocktake, err := h.service.Create(&input)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(stocktake)
+}
+
+// HandleStocktakeByID - GET, DELETE /api/stocktakes/{id}, POST /api/stocktakes/{id}/counts, POST /api/stocktakes/{id}/post
+func (h *StocktakeHandler) HandleStocktakeByID(w http.ResponseWriter, r *http.Request) {
+	idStr, action, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/api/stocktakes/"), "/")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		http.Error(w, "invalid stocktake ID", http.StatusBadRequest)
+		return
+	}
+
+	// API key outlet hanya boleh mengakses stock opname outletnya sendiri
+	if outlet := middlewares.Outlet(r.Context()); outlet.Fixed {
+		stocktake, err := h.service.GetByID(id)
+		if err != nil || stocktake.OutletID != outlet.ID {
+			http.Error(w, "stocktake not found", http.StatusNotFound)
+			return
+		}
+	}
+
+	switch {
+	case action == "" && r.Method == http.MethodGet:
+		h.GetByID(w, r, id)
+	case action == "" && r.Method == http.MethodDelete:
+		h.Cancel(w, r, id)
+	case action == "counts" && r.Method == http.MethodPost:
+		h.SubmitCounts(w, r, id)
+	case action == "post" && r.Method == http.MethodPost:
+		h.Post(w, r, id)
+	case action != "" && action != "counts" && action != "post":
+		http.NotFound(w, r)
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (h *StocktakeHandler) GetByID(w http.ResponseWriter, r *http.Request, id int) {
+	stocktake, err := h.service.GetByID(id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(stocktake)
+}
+
+func (h *StocktakeHandler) SubmitCounts(w http.ResponseWriter, r *http.Request, id int) {
+	var req models.StocktakeCountRequest
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	stocktake, err := h.service.SubmitCounts(id, &req)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(stocktake)
+}
+
+func (h *StocktakeHandler) Post(w http.ResponseWriter, r *http.Request, id int) {
+	var req models.StocktakePostRequest
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil && err != io.EOF {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	stocktake, err := h.service.Post(id, &req)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(stocktake)
+}
+
+func (h *StocktakeHandler) Cancel(w http.ResponseWriter, r *http.Request, id int) {
+	err := h.service.Cancel(id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{
+		"message": "stocktake cancelled successfully",
+	})
+}
@@ -2,19 +2,29 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
+	"kasir-api/middlewares"
 	"kasir-api/models"
+	"kasir-api/receipt"
+	"kasir-api/repositories"
 	"kasir-api/services"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 )
 
 type TransactionHandler struct {
-	service *services.TransactionService
+	service        *services.TransactionService
+	receiptService *services.ReceiptService
 }
 
-func NewTransactionHandler(service *services.TransactionService) *TransactionHandler {
-	return &TransactionHandler{service: service}
+func NewTransactionHandler(service *services.TransactionService, receiptService *services.ReceiptService) *TransactionHandler {
+	return &TransactionHandler{service: service, receiptService: receiptService}
 }
 
+// HandleCheckout - POST /api/checkout
 func (h *TransactionHandler) HandleCheckout(w http.ResponseWriter, r *http.Request) {
 	switch r.Method {
 	case http.MethodPost:
@@ -31,13 +41,254 @@ func (h *TransactionHandler) Checkout(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "invalid request body", http.StatusBadRequest)
 		return
 	}
+	req.OutletID = middlewares.Outlet(r.Context()).ID
 
-	transaction, err := h.service.Checkout(req.Items)
+	key := strings.TrimSpace(r.Header.Get("Idempotency-Key"))
+	if key == "" {
+		transaction, err := h.service.Checkout(req)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(transaction)
+		return
+	}
+
+	if len(key) > 255 {
+		http.Error(w, "Idempotency-Key must be at most 255 characters", http.StatusBadRequest)
+		return
+	}
+
+	transaction, replayed, err := h.service.CheckoutIdempotent(key, req)
+	if errors.Is(err, repositories.ErrIdempotencyKeyConflict) {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if replayed {
+		w.Header().Set("Idempotent-Replayed", "true")
+	}
+	json.NewEncoder(w).Encode(transaction)
+}
+
+// HandleTransactions - GET /api/transactions?start_date=2026-01-02&end_date=2026-02-03&min_amount=&max_amount=&product_id=&status=&outlet_id=&page=1&limit=20
+func (h *TransactionHandler) HandleTransactions(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		h.GetAll(w, r)
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (h *TransactionHandler) GetAll(w http.ResponseWriter, r *http.Request) {
+	filter, err := parseTransactionFilter(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	transactions, err := h.service.GetAll(filter)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(transactions)
+}
+
+// HandleTransactionByID - GET /api/transactions/{id}, POST /api/transactions/{id}/void, POST /api/transactions/{id}/refund,
+// GET /api/transactions/{id}/receipt?format=text|escpos|pdf&width=58|80&size=a4|a6
+func (h *TransactionHandler) HandleTransactionByID(w http.ResponseWriter, r *http.Request) {
+	idStr, action, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/api/transactions/"), "/")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		http.Error(w, "invalid transaction ID", http.StatusBadRequest)
+		return
+	}
+
+	// API key outlet hanya boleh mengakses transaksi outletnya sendiri
+	if outlet := middlewares.Outlet(r.Context()); outlet.Fixed {
+		transaction, err := h.service.GetByID(id)
+		if err != nil || transaction.OutletID != outlet.ID {
+			http.Error(w, "transaction not found", http.StatusNotFound)
+			return
+		}
+	}
+
+	switch {
+	case action == "" && r.Method == http.MethodGet:
+		h.GetByID(w, r, id)
+	case action == "void" && r.Method == http.MethodPost:
+		h.Void(w, r, id)
+	case action == "refund" && r.Method == http.MethodPost:
+		h.Refund(w, r, id)
+	case action == "receipt" && r.Method == http.MethodGet:
+		h.Receipt(w, r, id)
+	case action != "" && action != "void" && action != "refund" && action != "receipt":
+		http.NotFound(w, r)
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (h *TransactionHandler) GetByID(w http.ResponseWriter, r *http.Request, id int) {
+	transaction, err := h.service.GetByID(id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(transaction)
 }
+
+func (h *TransactionHandler) Receipt(w http.ResponseWriter, r *http.Request, id int) {
+	query := r.URL.Query()
+
+	format := query.Get("format")
+	if format == "" {
+		format = services.ReceiptFormatText
+	}
+
+	width := receipt.Width58
+	if v := query.Get("width"); v != "" {
+		n, err := strconv.Atoi(strings.TrimSuffix(v, "mm"))
+		if err != nil {
+			http.Error(w, "invalid width", http.StatusBadRequest)
+			return
+		}
+		width = n
+	}
+
+	size := strings.ToLower(query.Get("size"))
+	if size == "" {
+		size = receipt.SizeA6
+	}
+
+	body, contentType, err := h.receiptService.Render(id, format, width, size)
+	if err != nil {
+		if err.Error() == "transaction not found" {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	switch format {
+	case services.ReceiptFormatPDF:
+		w.Header().Set("Content-Disposition", fmt.Sprintf("inline; filename=\"struk-%d.pdf\"", id))
+	case services.ReceiptFormatESCPOS:
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"struk-%d.bin\"", id))
+	}
+	w.Write(body)
+}
+
+func (h *TransactionHandler) Void(w http.ResponseWriter, r *http.Request, id int) {
+	var req models.VoidRequest
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	transaction, err := h.service.Void(id, req)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(transaction)
+}
+
+func (h *TransactionHandler) Refund(w http.ResponseWriter, r *http.Request, id int) {
+	var req models.RefundRequest
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	transaction, err := h.service.Refund(id, req)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(transaction)
+}
+
+func parseTransactionFilter(r *http.Request) (models.TransactionFilter, error) {
+	q := r.URL.Query()
+	filter := models.TransactionFilter{
+		StartDate: q.Get("start_date"),
+		EndDate:   q.Get("end_date"),
+		Status:    q.Get("status"),
+		Page:      1,
+		Limit:     20,
+	}
+
+	for _, date := range []string{filter.StartDate, filter.EndDate} {
+		if date == "" {
+			continue
+		}
+		if _, err := time.Parse("2006-01-02", date); err != nil {
+			return filter, fmt.Errorf("invalid date %q, expected YYYY-MM-DD", date)
+		}
+	}
+
+	if v := q.Get("min_amount"); v != "" {
+		amount, err := strconv.Atoi(v)
+		if err != nil {
+			return filter, errors.New("invalid min_amount")
+		}
+		filter.MinAmount = &amount
+	}
+	if v := q.Get("max_amount"); v != "" {
+		amount, err := strconv.Atoi(v)
+		if err != nil {
+			return filter, errors.New("invalid max_amount")
+		}
+		filter.MaxAmount = &amount
+	}
+	if v := q.Get("product_id"); v != "" {
+		id, err := strconv.Atoi(v)
+		if err != nil {
+			return filter, errors.New("invalid product_id")
+		}
+		filter.ProductID = id
+	}
+	outletID, err := outletFilter(r)
+	if err != nil {
+		return filter, err
+	}
+	filter.OutletID = outletID
+	if v := q.Get("page"); v != "" {
+		page, err := strconv.Atoi(v)
+		if err != nil || page < 1 {
+			return filter, errors.New("invalid page")
+		}
+		filter.Page = page
+	}
+	if v := q.Get("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit < 1 || limit > 100 {
+			return filter, errors.New("limit must be between 1 and 100")
+		}
+		filter.Limit = limit
+	}
+
+	return filter, nil
+}
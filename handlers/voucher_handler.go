@@ -0,0 +1,130 @@
+package handlers
+
+import (
+	"encoding/json"
+	"kasir-api/models"
+	"kasir-api/services"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+type VoucherHandler struct {
+	service *services.VoucherService
+}
+
+func NewVoucherHandler(service *services.VoucherService) *VoucherHandler {
+	return &VoucherHandler{service: service}
+}
+
+// HandleVouchers - GET, POST /api/vouchers
+func (h *VoucherHandler) HandleVouchers(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		h.GetAll(w, r)
+	case http.MethodPost:
+		h.Create(w, r)
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (h *VoucherHandler) GetAll(w http.ResponseWriter, r *http.Request) {
+	batches, err := h.service.GetAll()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(batches)
+}
+
+func (h *VoucherHandler) Create(w http.ResponseWriter, r *http.Request) {
+	// default: aktif dan setiap kode hanya bisa dipakai sekali
+	input := models.VoucherBatchInput{VoucherBatch: models.VoucherBatch{Active: true, UsageLimit: 1}}
+	err := json.NewDecoder(r.Body).Decode(&input)
+	if err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	batch, err := h.service.Create(&input)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(batch)
+}
+
+// HandleVoucherByID - GET, PUT /api/vouchers/{id}, GET /api/vouchers/code/{code}?customer=
+func (h *VoucherHandler) HandleVoucherByID(w http.ResponseWriter, r *http.Request) {
+	path := strings.TrimPrefix(r.URL.Path, "/api/vouchers/")
+	if code, ok := strings.CutPrefix(path, "code/"); ok {
+		if r.Method != http.MethodGet {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		h.GetStatus(w, r, code)
+		return
+	}
+
+	id, err := strconv.Atoi(path)
+	if err != nil {
+		http.Error(w, "invalid voucher batch ID", http.StatusBadRequest)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		h.GetByID(w, r, id)
+	case http.MethodPut:
+		h.Update(w, r, id)
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (h *VoucherHandler) GetByID(w http.ResponseWriter, r *http.Request, id int) {
+	batch, err := h.service.GetByID(id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(batch)
+}
+
+func (h *VoucherHandler) Update(w http.ResponseWriter, r *http.Request, id int) {
+	var batch models.VoucherBatch
+	err := json.NewDecoder(r.Body).Decode(&batch)
+	if err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	batch.ID = id
+	updated, err := h.service.Update(&batch)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(updated)
+}
+
+func (h *VoucherHandler) GetStatus(w http.ResponseWriter, r *http.Request, code string) {
+	status, err := h.service.GetStatus(code, r.URL.Query().Get("customer"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(status)
+}
@@ -0,0 +1,182 @@
+// Package label menyusun label barcode dan label harga produk lalu merendernya ke SVG, PNG
+// dan lembar PDF berisi banyak label. Ketiga format memakai layout yang sama.
+package label
+
+import (
+	"fmt"
+	"kasir-api/barcode"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// batas ukuran label (mm)
+const (
+	MinWidth  = 25
+	MaxWidth  = 120
+	MinHeight = 20
+	MaxHeight = 100
+)
+
+// Label adalah isi satu label. Code kosong berarti label hanya berisi nama dan harga.
+type Label struct {
+	Name      string
+	Price     string
+	Code      string
+	Symbology string
+}
+
+// Size adalah ukuran label dalam mm
+type Size struct {
+	Width  float64
+	Height float64
+}
+
+// DefaultSize adalah ukuran label rak yang umum dipakai
+var DefaultSize = Size{Width: 50, Height: 30}
+
+// ParseSize membaca ukuran dalam format "{lebar}x{tinggi}" dalam mm, misal "50x30"
+func ParseSize(s string) (Size, error) {
+	w, h, ok := strings.Cut(strings.ToLower(strings.TrimSpace(s)), "x")
+	if !ok {
+		return Size{}, fmt.Errorf("size must be in {width}x{height} format, e.g. 50x30")
+	}
+	width, err := strconv.ParseFloat(strings.TrimSuffix(w, "mm"), 64)
+	if err != nil {
+		return Size{}, fmt.Errorf("invalid label width %q", w)
+	}
+	height, err := strconv.ParseFloat(strings.TrimSuffix(h, "mm"), 64)
+	if err != nil {
+		return Size{}, fmt.Errorf("invalid label height %q", h)
+	}
+
+	size := Size{Width: width, Height: height}
+	return size, size.Validate()
+}
+
+func (s Size) Validate() error {
+	if s.Width < MinWidth || s.Width > MaxWidth {
+		return fmt.Errorf("label width must be between %d and %d mm", MinWidth, MaxWidth)
+	}
+	if s.Height < MinHeight || s.Height > MaxHeight {
+		return fmt.Errorf("label height must be between %d and %d mm", MinHeight, MaxHeight)
+	}
+	return nil
+}
+
+// semua teks label memakai font monospace, lebar karakternya 0.6 x ukuran font
+// dan tinggi huruf kapitalnya sekitar 0.7 x ukuran font
+const (
+	charWidth = 0.6
+	capHeight = 0.7
+
+	// modul bar tersempit yang masih terbaca scanner
+	minModule = 0.19
+	maxModule = 0.4
+	// quiet zone di kiri dan kanan barcode, dalam jumlah modul
+	quietZone = 10
+)
+
+type text struct {
+	x, y float64 // titik tengah baseline
+	size float64
+	bold bool
+	text string
+}
+
+type bar struct {
+	x, width float64
+}
+
+// layout adalah posisi elemen label dalam mm dengan titik (0,0) di kiri atas label
+type layout struct {
+	texts          []text
+	bars           []bar
+	barTop, barEnd float64
+}
+
+// arrange menyusun elemen label. unit adalah resolusi perangkat dalam mm (0 untuk vektor);
+// lebar modul barcode dibulatkan ke kelipatan unit supaya semua bar di PNG sama lebar.
+func arrange(l Label, size Size, unit float64) layout {
+	w, h := size.Width, size.Height
+	pad := 1.5
+	nameSize := math.Min(h*0.12, 4)
+	priceSize := math.Min(h*0.2, 7)
+	codeSize := math.Min(h*0.08, 2.6)
+
+	var lo layout
+	y := pad + nameSize*capHeight
+	lo.texts = append(lo.texts, text{x: w / 2, y: y, size: nameSize, text: fit(l.Name, w-2*pad, nameSize)})
+	y += 1 + priceSize*capHeight
+	lo.texts = append(lo.texts, text{x: w / 2, y: y, size: priceSize, bold: true, text: fit(l.Price, w-2*pad, priceSize)})
+
+	if l.Code == "" {
+		// tanpa barcode, nama dan harga ditaruh di tengah label
+		offset := (h - pad - y) / 2
+		for i := range lo.texts {
+			lo.texts[i].y += offset
+		}
+		return lo
+	}
+
+	codeY := h - pad
+	lo.texts = append(lo.texts, text{x: w / 2, y: codeY, size: codeSize, text: fit(l.Code, w-2*pad, codeSize)})
+
+	modules, err := barcode.Encode(l.Symbology, l.Code)
+	if err != nil {
+		return lo
+	}
+
+	// lebar modul disesuaikan dengan lebar label, kalau terlalu sempit barcode tidak dicetak
+	// dan label hanya memuat kodenya
+	module := math.Min((w-2*pad)/float64(len(modules)+2*quietZone), maxModule)
+	if unit > 0 {
+		module = math.Floor(module/unit) * unit
+	}
+	if module < minModule {
+		return lo
+	}
+
+	lo.barTop = y + 1.5
+	lo.barEnd = codeY - codeSize*capHeight - 0.8
+	left := (w - module*float64(len(modules))) / 2
+	if unit > 0 {
+		left = math.Round(left/unit) * unit
+	}
+	for i := 0; i < len(modules); {
+		if !modules[i] {
+			i++
+			continue
+		}
+		start := i
+		for i < len(modules) && modules[i] {
+			i++
+		}
+		lo.bars = append(lo.bars, bar{x: left + float64(start)*module, width: float64(i-start) * module})
+	}
+
+	return lo
+}
+
+// fit memotong teks supaya muat di lebar width (mm) dengan ukuran font size
+func fit(s string, width, size float64) string {
+	limit := int(width / (charWidth * size))
+	r := []rune(strings.TrimSpace(s))
+	if len(r) <= limit {
+		return string(r)
+	}
+	if limit <= 3 {
+		return string(r[:limit])
+	}
+	return string(r[:limit-3]) + "..."
+}
+
+// jarak antar label di strip SVG/PNG dan di lembar PDF (mm)
+const gap = 2
+
+// grid menghitung jumlah kolom dan baris label yang muat di area width x height
+func grid(size Size, width, height float64) (int, int) {
+	cols := int((width + gap) / (size.Width + gap))
+	rows := int((height + gap) / (size.Height + gap))
+	return max(cols, 1), max(rows, 1)
+}
@@ -0,0 +1,52 @@
+package label
+
+import (
+	"kasir-api/pdf"
+)
+
+// margin lembar PDF (mm)
+const sheetMargin = 8
+
+// PDF merender label-label ke lembar A4 dalam grid, halaman baru ditambahkan jika label tidak muat.
+// Setiap label diberi garis tepi tipis sebagai panduan potong.
+func PDF(labels []Label, size Size) []byte {
+	cols, rows := grid(size, pdf.A4Width/pdf.MM-2*sheetMargin, pdf.A4Height/pdf.MM-2*sheetMargin)
+	perPage := cols * rows
+
+	doc := pdf.New()
+	var page *pdf.Page
+	for i, l := range labels {
+		if i%perPage == 0 {
+			page = doc.AddPage(pdf.A4Width, pdf.A4Height)
+		}
+
+		// posisi kiri atas label dalam mm dari kiri atas halaman
+		n := i % perPage
+		left := sheetMargin + float64(n%cols)*(size.Width+gap)
+		top := sheetMargin + float64(n/cols)*(size.Height+gap)
+
+		// koordinat PDF dalam point dengan titik (0,0) di kiri bawah
+		x := func(mm float64) float64 { return (left + mm) * pdf.MM }
+		y := func(mm float64) float64 { return pdf.A4Height - (top+mm)*pdf.MM }
+
+		page.StrokeRect(x(0), y(size.Height), size.Width*pdf.MM, size.Height*pdf.MM, 0.3)
+
+		lo := arrange(l, size, 0)
+		for _, b := range lo.bars {
+			page.Rect(x(b.x), y(lo.barEnd), b.width*pdf.MM, (lo.barEnd-lo.barTop)*pdf.MM)
+		}
+		for _, t := range lo.texts {
+			font := pdf.Courier
+			if t.bold {
+				font = pdf.CourierBold
+			}
+			width := float64(len([]rune(t.text))) * charWidth * t.size
+			page.Text(x(t.x-width/2), y(t.y), font, t.size*pdf.MM, t.text)
+		}
+	}
+
+	if page == nil {
+		doc.AddPage(pdf.A4Width, pdf.A4Height)
+	}
+	return doc.Bytes()
+}
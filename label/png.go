@@ -0,0 +1,148 @@
+package label
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"math"
+	"unicode"
+)
+
+// DotsPerMM adalah resolusi PNG, sama dengan printer label thermal 203 dpi
+const DotsPerMM = 8
+
+// PNG merender label-label sebagai strip satu kolom grayscale. Teks digambar dengan font bitmap
+// 5x7 bawaan sehingga huruf kecil dicetak kapital dan karakter yang tidak dikenal menjadi "?".
+func PNG(labels []Label, size Size) ([]byte, error) {
+	step := size.Height + gap
+	width := px(size.Width)
+	height := px(float64(max(len(labels), 1))*step - gap)
+
+	img := image.NewGray(image.Rect(0, 0, width, height))
+	fill(img, img.Bounds(), color.Gray{Y: 255})
+
+	border := color.Gray{Y: 153}
+	for i, l := range labels {
+		top := float64(i) * step
+		r := image.Rect(0, px(top), width, px(top+size.Height))
+		fill(img, image.Rect(r.Min.X, r.Min.Y, r.Max.X, r.Min.Y+1), border)
+		fill(img, image.Rect(r.Min.X, r.Max.Y-1, r.Max.X, r.Max.Y), border)
+		fill(img, image.Rect(r.Min.X, r.Min.Y, r.Min.X+1, r.Max.Y), border)
+		fill(img, image.Rect(r.Max.X-1, r.Min.Y, r.Max.X, r.Max.Y), border)
+
+		lo := arrange(l, size, 1.0/DotsPerMM)
+		for _, b := range lo.bars {
+			fill(img, image.Rect(px(b.x), px(top+lo.barTop), px(b.x+b.width), px(top+lo.barEnd)), color.Gray{})
+		}
+		for _, t := range lo.texts {
+			drawText(img, t, top)
+		}
+	}
+
+	var out bytes.Buffer
+	if err := png.Encode(&out, img); err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
+
+func px(mm float64) int {
+	return int(math.Round(mm * DotsPerMM))
+}
+
+func fill(img *image.Gray, r image.Rectangle, c color.Gray) {
+	r = r.Intersect(img.Bounds())
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			img.SetGray(x, y, c)
+		}
+	}
+}
+
+// drawText menggambar teks rata tengah di t.x dengan baseline t.y, top adalah posisi atas label (mm)
+func drawText(img *image.Gray, t text, top float64) {
+	// satu titik font mengikuti tinggi huruf kapital, setiap karakter selebar 6 titik termasuk spasi antar huruf
+	dot := max(int(math.Round(t.size*capHeight*DotsPerMM/7)), 1)
+	runes := []rune(t.text)
+	x := px(t.x) - len(runes)*6*dot/2
+	baseline := px(top + t.y)
+
+	for _, r := range runes {
+		g, ok := glyphs[unicode.ToUpper(r)]
+		if !ok {
+			g = glyphs['?']
+		}
+		for row, bits := range g {
+			for col := 0; col < 5; col++ {
+				if bits&(1<<(4-col)) == 0 {
+					continue
+				}
+				x0 := x + col*dot
+				y0 := baseline - (7-row)*dot
+				// huruf tebal digambar dengan titik yang lebih lebar
+				x1 := x0 + dot
+				if t.bold {
+					x1 += max(dot/2, 1)
+				}
+				fill(img, image.Rect(x0, y0, x1, y0+dot), color.Gray{})
+			}
+		}
+		x += 6 * dot
+	}
+}
+
+// glyphs adalah font bitmap 5x7, setiap baris 5 bit dengan bit tertinggi di kiri
+var glyphs = map[rune][7]uint8{
+	' ':  {},
+	'0':  {0b01110, 0b10001, 0b10011, 0b10101, 0b11001, 0b10001, 0b01110},
+	'1':  {0b00100, 0b01100, 0b00100, 0b00100, 0b00100, 0b00100, 0b01110},
+	'2':  {0b01110, 0b10001, 0b00001, 0b00010, 0b00100, 0b01000, 0b11111},
+	'3':  {0b11111, 0b00010, 0b00100, 0b00010, 0b00001, 0b10001, 0b01110},
+	'4':  {0b00010, 0b00110, 0b01010, 0b10010, 0b11111, 0b00010, 0b00010},
+	'5':  {0b11111, 0b10000, 0b11110, 0b00001, 0b00001, 0b10001, 0b01110},
+	'6':  {0b00110, 0b01000, 0b10000, 0b11110, 0b10001, 0b10001, 0b01110},
+	'7':  {0b11111, 0b00001, 0b00010, 0b00100, 0b01000, 0b01000, 0b01000},
+	'8':  {0b01110, 0b10001, 0b10001, 0b01110, 0b10001, 0b10001, 0b01110},
+	'9':  {0b01110, 0b10001, 0b10001, 0b01111, 0b00001, 0b00010, 0b01100},
+	'A':  {0b01110, 0b10001, 0b10001, 0b11111, 0b10001, 0b10001, 0b10001},
+	'B':  {0b11110, 0b10001, 0b10001, 0b11110, 0b10001, 0b10001, 0b11110},
+	'C':  {0b01110, 0b10001, 0b10000, 0b10000, 0b10000, 0b10001, 0b01110},
+	'D':  {0b11100, 0b10010, 0b10001, 0b10001, 0b10001, 0b10010, 0b11100},
+	'E':  {0b11111, 0b10000, 0b10000, 0b11110, 0b10000, 0b10000, 0b11111},
+	'F':  {0b11111, 0b10000, 0b10000, 0b11110, 0b10000, 0b10000, 0b10000},
+	'G':  {0b01110, 0b10001, 0b10000, 0b10111, 0b10001, 0b10001, 0b01111},
+	'H':  {0b10001, 0b10001, 0b10001, 0b11111, 0b10001, 0b10001, 0b10001},
+	'I':  {0b01110, 0b00100, 0b00100, 0b00100, 0b00100, 0b00100, 0b01110},
+	'J':  {0b00111, 0b00010, 0b00010, 0b00010, 0b00010, 0b10010, 0b01100},
+	'K':  {0b10001, 0b10010, 0b10100, 0b11000, 0b10100, 0b10010, 0b10001},
+	'L':  {0b10000, 0b10000, 0b10000, 0b10000, 0b10000, 0b10000, 0b11111},
+	'M':  {0b10001, 0b11011, 0b10101, 0b10101, 0b10001, 0b10001, 0b10001},
+	'N':  {0b10001, 0b10001, 0b11001, 0b10101, 0b10011, 0b10001, 0b10001},
+	'O':  {0b01110, 0b10001, 0b10001, 0b10001, 0b10001, 0b10001, 0b01110},
+	'P':  {0b11110, 0b10001, 0b10001, 0b11110, 0b10000, 0b10000, 0b10000},
+	'Q':  {0b01110, 0b10001, 0b10001, 0b10001, 0b10101, 0b10010, 0b01101},
+	'R':  {0b11110, 0b10001, 0b10001, 0b11110, 0b10100, 0b10010, 0b10001},
+	'S':  {0b01111, 0b10000, 0b10000, 0b01110, 0b00001, 0b00001, 0b11110},
+	'T':  {0b11111, 0b00100, 0b00100, 0b00100, 0b00100, 0b00100, 0b00100},
+	'U':  {0b10001, 0b10001, 0b10001, 0b10001, 0b10001, 0b10001, 0b01110},
+	'V':  {0b10001, 0b10001, 0b10001, 0b10001, 0b10001, 0b01010, 0b00100},
+	'W':  {0b10001, 0b10001, 0b10001, 0b10101, 0b10101, 0b10101, 0b01010},
+	'X':  {0b10001, 0b10001, 0b01010, 0b00100, 0b01010, 0b10001, 0b10001},
+	'Y':  {0b10001, 0b10001, 0b10001, 0b01010, 0b00100, 0b00100, 0b00100},
+	'Z':  {0b11111, 0b00001, 0b00010, 0b00100, 0b01000, 0b10000, 0b11111},
+	'.':  {0, 0, 0, 0, 0, 0b01100, 0b01100},
+	',':  {0, 0, 0, 0, 0b01100, 0b00100, 0b01000},
+	'-':  {0, 0, 0, 0b11111, 0, 0, 0},
+	'/':  {0, 0b00001, 0b00010, 0b00100, 0b01000, 0b10000, 0},
+	'(':  {0b00010, 0b00100, 0b01000, 0b01000, 0b01000, 0b00100, 0b00010},
+	')':  {0b01000, 0b00100, 0b00010, 0b00010, 0b00010, 0b00100, 0b01000},
+	'&':  {0b01100, 0b10010, 0b10100, 0b01000, 0b10101, 0b10010, 0b01101},
+	'\'': {0b01100, 0b00100, 0b01000, 0, 0, 0, 0},
+	':':  {0, 0b01100, 0b01100, 0, 0b01100, 0b01100, 0},
+	'%':  {0b11000, 0b11001, 0b00010, 0b00100, 0b01000, 0b10011, 0b00011},
+	'+':  {0, 0b00100, 0b00100, 0b11111, 0b00100, 0b00100, 0},
+	'#':  {0b01010, 0b01010, 0b11111, 0b01010, 0b11111, 0b01010, 0b01010},
+	'*':  {0, 0b00100, 0b10101, 0b01110, 0b10101, 0b00100, 0},
+	'?':  {0b01110, 0b10001, 0b00001, 0b00010, 0b00100, 0, 0b00100},
+}
@@ -0,0 +1,48 @@
+package label
+
+import (
+	"fmt"
+	"html"
+	"strings"
+)
+
+// SVG merender label-label sebagai strip satu kolom, satuan dokumen dalam mm
+func SVG(labels []Label, size Size) []byte {
+	height := float64(len(labels))*(size.Height+gap) - gap
+	if len(labels) == 0 {
+		height = size.Height
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" width="%smm" height="%smm" viewBox="0 0 %s %s">`+"\n",
+		num(size.Width), num(height), num(size.Width), num(height))
+	b.WriteString(`<rect width="100%" height="100%" fill="#fff"/>` + "\n")
+
+	for i, l := range labels {
+		lo := arrange(l, size, 0)
+		fmt.Fprintf(&b, `<g transform="translate(0 %s)">`+"\n", num(float64(i)*(size.Height+gap)))
+		fmt.Fprintf(&b, `<rect x="0.1" y="0.1" width="%s" height="%s" fill="none" stroke="#999" stroke-width="0.2"/>`+"\n",
+			num(size.Width-0.2), num(size.Height-0.2))
+
+		for _, bar := range lo.bars {
+			fmt.Fprintf(&b, `<rect x="%s" y="%s" width="%s" height="%s"/>`+"\n",
+				num(bar.x), num(lo.barTop), num(bar.width), num(lo.barEnd-lo.barTop))
+		}
+		for _, t := range lo.texts {
+			weight := "normal"
+			if t.bold {
+				weight = "bold"
+			}
+			fmt.Fprintf(&b, `<text x="%s" y="%s" font-family="Courier New, Courier, monospace" font-size="%s" font-weight="%s" text-anchor="middle">%s</text>`+"\n",
+				num(t.x), num(t.y), num(t.size), weight, html.EscapeString(t.text))
+		}
+		b.WriteString("</g>\n")
+	}
+
+	b.WriteString("</svg>\n")
+	return []byte(b.String())
+}
+
+func num(f float64) string {
+	return strings.TrimRight(strings.TrimRight(fmt.Sprintf("%.3f", f), "0"), ".")
+}
@@ -6,35 +6,85 @@ import (
 	"kasir-api/database"
 	"kasir-api/handlers"
 	"kasir-api/middlewares"
+	"kasir-api/models"
+	"kasir-api/receipt"
 	"kasir-api/repositories"
 	"kasir-api/services"
 	"log"
 	"net/http"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/spf13/viper"
 )
 
 type Config struct {
-	Port   string `mapstructure:"PORT"`
-	DBConn string `mapstructure:"DB_CONN"`
-	APIKey string `mapstructure:"API_KEY"`
+	Port           string        `mapstructure:"PORT"`
+	DBConn         string        `mapstructure:"DB_CONN"`
+	APIKey         string        `mapstructure:"API_KEY"`
+	IdempotencyTTL time.Duration `mapstructure:"IDEMPOTENCY_TTL"`
+
+	TaxMode string  `mapstructure:"TAX_MODE"`
+	TaxRate float64 `mapstructure:"TAX_RATE"`
+
+	ReceiptHeader   string `mapstructure:"RECEIPT_HEADER"`
+	ReceiptFooter   string `mapstructure:"RECEIPT_FOOTER"`
+	ReceiptTimezone string `mapstructure:"RECEIPT_TIMEZONE"`
+
+	LowStockCheckInterval time.Duration `mapstructure:"LOW_STOCK_CHECK_INTERVAL"`
+	LowStockAlertLog      bool          `mapstructure:"LOW_STOCK_ALERT_LOG"`
+	LowStockWebhookURL    string        `mapstructure:"LOW_STOCK_WEBHOOK_URL"`
+
+	ExpiredWriteOffInterval time.Duration `mapstructure:"EXPIRED_WRITE_OFF_INTERVAL"`
 }
 
 func main() {
 	viper.AutomaticEnv()
 	viper.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
 
+	viper.SetDefault("IDEMPOTENCY_TTL", "24h")
+	viper.SetDefault("TAX_MODE", models.TaxModeExclusive)
+	viper.SetDefault("TAX_RATE", 11)
+	viper.SetDefault("RECEIPT_HEADER", "Kasir API")
+	viper.SetDefault("RECEIPT_FOOTER", "Terima kasih atas kunjungan Anda")
+	viper.SetDefault("RECEIPT_TIMEZONE", "Asia/Jakarta")
+	viper.SetDefault("LOW_STOCK_CHECK_INTERVAL", "5m")
+	viper.SetDefault("LOW_STOCK_ALERT_LOG", true)
+	viper.SetDefault("EXPIRED_WRITE_OFF_INTERVAL", "1h")
+
 	if _, err := os.Stat(".env"); err == nil {
 		viper.SetConfigFile(".env")
 		_ = viper.ReadInConfig()
 	}
 
 	config := Config{
-		Port:   viper.GetString("PORT"),
-		DBConn: viper.GetString("DB_CONN"),
-		APIKey: viper.GetString("API_KEY"),
+		Port:           viper.GetString("PORT"),
+		DBConn:         viper.GetString("DB_CONN"),
+		APIKey:         viper.GetString("API_KEY"),
+		IdempotencyTTL: viper.GetDuration("IDEMPOTENCY_TTL"),
+
+		TaxMode: viper.GetString("TAX_MODE"),
+		TaxRate: viper.GetFloat64("TAX_RATE"),
+
+		ReceiptHeader:   viper.GetString("RECEIPT_HEADER"),
+		ReceiptFooter:   viper.GetString("RECEIPT_FOOTER"),
+		ReceiptTimezone: viper.GetString("RECEIPT_TIMEZONE"),
+
+		LowStockCheckInterval: viper.GetDuration("LOW_STOCK_CHECK_INTERVAL"),
+		LowStockAlertLog:      viper.GetBool("LOW_STOCK_ALERT_LOG"),
+		LowStockWebhookURL:    viper.GetString("LOW_STOCK_WEBHOOK_URL"),
+
+		ExpiredWriteOffInterval: viper.GetDuration("EXPIRED_WRITE_OFF_INTERVAL"),
+	}
+
+	if config.TaxMode != models.TaxModeExclusive && config.TaxMode != models.TaxModeInclusive {
+		log.Fatalf("invalid TAX_MODE %q, expected %q or %q", config.TaxMode, models.TaxModeExclusive, models.TaxModeInclusive)
+	}
+
+	receiptLocation, err := time.LoadLocation(config.ReceiptTimezone)
+	if err != nil {
+		log.Fatalf("invalid RECEIPT_TIMEZONE %q: %v", config.ReceiptTimezone, err)
 	}
 
 	// Setup database
@@ -44,19 +94,86 @@ func main() {
 	}
 	defer db.Close()
 
-	apiKeyMiddleware := middlewares.APIKeyMiddleware(config.APIKey)
+	outletRepo := repositories.NewOutletRepository(db)
+	outletService := services.NewOutletService(outletRepo)
+	outletHandler := handlers.NewOutletHandler(outletService)
+
+	// API key utama atau API key milik salah satu outlet
+	apiKeyMiddleware := middlewares.APIKeyMiddleware(config.APIKey, outletService.FindByAPIKey)
 
 	productRepo := repositories.NewProductRepository(db)
 	productService := services.NewProductService(productRepo)
 	productHandler := handlers.NewProductHandler(productService)
 
+	labelService := services.NewLabelService(productRepo)
+	labelHandler := handlers.NewLabelHandler(labelService)
+
 	categoryRepo := repositories.NewCategoryRepository(db)
 	categoryService := services.NewCategoryService(categoryRepo)
 	categoryHandler := handlers.NewCategoryHandler(categoryService)
 
-	transactionRepo := repositories.NewTransactionRepository(db)
-	transactionService := services.NewTransactionService(transactionRepo)
-	transactionHandler := handlers.NewTransactionHandler(transactionService)
+	taxConfig := models.TaxConfig{
+		Mode: config.TaxMode,
+		Rate: config.TaxRate,
+	}
+
+	// peringatan stok menipis dikirim ke log dan/atau webhook
+	var alertSinks []services.AlertSink
+	if config.LowStockAlertLog {
+		alertSinks = append(alertSinks, services.LogAlertSink{})
+	}
+	if config.LowStockWebhookURL != "" {
+		alertSinks = append(alertSinks, services.NewWebhookAlertSink(config.LowStockWebhookURL))
+	}
+	inventoryRepo := repositories.NewInventoryRepository(db)
+	inventoryService := services.NewInventoryService(inventoryRepo)
+	inventoryHandler := handlers.NewInventoryHandler(inventoryService)
+	lowStockNotifier := services.NewLowStockNotifier(inventoryRepo, config.LowStockCheckInterval, alertSinks...)
+	lowStockNotifier.Start()
+
+	transactionRepo := repositories.NewTransactionRepository(db, taxConfig, receiptLocation)
+	transactionService := services.NewTransactionService(transactionRepo, config.IdempotencyTTL, lowStockNotifier)
+	receiptConfig := receipt.Config{
+		Header:   splitLines(config.ReceiptHeader),
+		Footer:   splitLines(config.ReceiptFooter),
+		Location: receiptLocation,
+	}
+	receiptService := services.NewReceiptService(transactionRepo, receiptConfig)
+	transactionHandler := handlers.NewTransactionHandler(transactionService, receiptService)
+
+	cartRepo := repositories.NewCartRepository(db, taxConfig, receiptLocation)
+	cartService := services.NewCartService(cartRepo, transactionService)
+	cartHandler := handlers.NewCartHandler(cartService)
+
+	promotionRepo := repositories.NewPromotionRepository(db)
+	promotionService := services.NewPromotionService(promotionRepo)
+	promotionHandler := handlers.NewPromotionHandler(promotionService)
+
+	voucherRepo := repositories.NewVoucherRepository(db)
+	voucherService := services.NewVoucherService(voucherRepo)
+	voucherHandler := handlers.NewVoucherHandler(voucherService)
+
+	stockAdjustmentRepo := repositories.NewStockAdjustmentRepository(db)
+	stockAdjustmentService := services.NewStockAdjustmentService(stockAdjustmentRepo)
+	stockAdjustmentHandler := handlers.NewStockAdjustmentHandler(stockAdjustmentService)
+	expiredLotWriter := services.NewExpiredLotWriter(stockAdjustmentRepo, config.ExpiredWriteOffInterval, lowStockNotifier)
+	expiredLotWriter.Start()
+
+	stocktakeRepo := repositories.NewStocktakeRepository(db)
+	stocktakeService := services.NewStocktakeService(stocktakeRepo)
+	stocktakeHandler := handlers.NewStocktakeHandler(stocktakeService)
+
+	supplierRepo := repositories.NewSupplierRepository(db)
+	supplierService := services.NewSupplierService(supplierRepo)
+	supplierHandler := handlers.NewSupplierHandler(supplierService)
+
+	purchaseOrderRepo := repositories.NewPurchaseOrderRepository(db)
+	purchaseOrderService := services.NewPurchaseOrderService(purchaseOrderRepo, supplierRepo)
+	purchaseOrderHandler := handlers.NewPurchaseOrderHandler(purchaseOrderService)
+
+	stockTransferRepo := repositories.NewStockTransferRepository(db)
+	stockTransferService := services.NewStockTransferService(stockTransferRepo)
+	stockTransferHandler := handlers.NewStockTransferHandler(stockTransferService)
 
 	reportRepo := repositories.NewReportRepository(db)
 	reportService := services.NewReportService(reportRepo)
@@ -67,12 +184,46 @@ func main() {
 
 	// bungkus protected route dengan middleware
 	http.HandleFunc("/api/product/", middlewares.CORS(middlewares.Logger(apiKeyMiddleware(productHandler.HandleProductByID))))
+	http.HandleFunc("/api/labels", middlewares.CORS(middlewares.Logger(apiKeyMiddleware(labelHandler.HandleLabels))))
 	http.HandleFunc("/api/categories", middlewares.CORS(middlewares.Logger(categoryHandler.HandleCategories)))
+	http.HandleFunc("/api/categories/tree", middlewares.CORS(middlewares.Logger(categoryHandler.HandleCategoryTree)))
 	http.HandleFunc("/api/categories/", middlewares.CORS(middlewares.Logger(categoryHandler.HandleCategoryByID)))
 
 	http.HandleFunc("/api/checkout", middlewares.CORS(middlewares.Logger(apiKeyMiddleware(transactionHandler.HandleCheckout))))
+	http.HandleFunc("/api/transactions", middlewares.CORS(middlewares.Logger(apiKeyMiddleware(transactionHandler.HandleTransactions))))
+	http.HandleFunc("/api/transactions/", middlewares.CORS(middlewares.Logger(apiKeyMiddleware(transactionHandler.HandleTransactionByID))))
+
+	http.HandleFunc("/api/carts", middlewares.CORS(middlewares.Logger(apiKeyMiddleware(cartHandler.HandleCarts))))
+	http.HandleFunc("/api/carts/", middlewares.CORS(middlewares.Logger(apiKeyMiddleware(cartHandler.HandleCartByID))))
+
+	http.HandleFunc("/api/promotions", middlewares.CORS(middlewares.Logger(apiKeyMiddleware(promotionHandler.HandlePromotions))))
+	http.HandleFunc("/api/promotions/", middlewares.CORS(middlewares.Logger(apiKeyMiddleware(promotionHandler.HandlePromotionByID))))
+
+	http.HandleFunc("/api/vouchers", middlewares.CORS(middlewares.Logger(apiKeyMiddleware(voucherHandler.HandleVouchers))))
+	http.HandleFunc("/api/vouchers/", middlewares.CORS(middlewares.Logger(apiKeyMiddleware(voucherHandler.HandleVoucherByID))))
+
+	http.HandleFunc("/api/stock-adjustments", middlewares.CORS(middlewares.Logger(apiKeyMiddleware(stockAdjustmentHandler.HandleStockAdjustments))))
+	http.HandleFunc("/api/stock-adjustments/", middlewares.CORS(middlewares.Logger(apiKeyMiddleware(stockAdjustmentHandler.HandleStockAdjustmentByID))))
+	http.HandleFunc("/api/stocktakes", middlewares.CORS(middlewares.Logger(apiKeyMiddleware(stocktakeHandler.HandleStocktakes))))
+	http.HandleFunc("/api/stocktakes/", middlewares.CORS(middlewares.Logger(apiKeyMiddleware(stocktakeHandler.HandleStocktakeByID))))
+
+	http.HandleFunc("/api/suppliers", middlewares.CORS(middlewares.Logger(apiKeyMiddleware(supplierHandler.HandleSuppliers))))
+	http.HandleFunc("/api/suppliers/", middlewares.CORS(middlewares.Logger(apiKeyMiddleware(supplierHandler.HandleSupplierByID))))
+	http.HandleFunc("/api/purchase-orders", middlewares.CORS(middlewares.Logger(apiKeyMiddleware(purchaseOrderHandler.HandlePurchaseOrders))))
+	http.HandleFunc("/api/purchase-orders/", middlewares.CORS(middlewares.Logger(apiKeyMiddleware(purchaseOrderHandler.HandlePurchaseOrderByID))))
+
+	http.HandleFunc("/api/outlets", middlewares.CORS(middlewares.Logger(apiKeyMiddleware(outletHandler.HandleOutlets))))
+	http.HandleFunc("/api/outlets/", middlewares.CORS(middlewares.Logger(apiKeyMiddleware(outletHandler.HandleOutletByID))))
+	http.HandleFunc("/api/stock-transfers", middlewares.CORS(middlewares.Logger(apiKeyMiddleware(stockTransferHandler.HandleStockTransfers))))
+	http.HandleFunc("/api/stock-transfers/", middlewares.CORS(middlewares.Logger(apiKeyMiddleware(stockTransferHandler.HandleStockTransferByID))))
+
+	http.HandleFunc("/api/inventory/low-stock", middlewares.CORS(middlewares.Logger(apiKeyMiddleware(inventoryHandler.HandleLowStock))))
+	http.HandleFunc("/api/inventory/expiring", middlewares.CORS(middlewares.Logger(apiKeyMiddleware(inventoryHandler.HandleExpiring))))
 
 	http.HandleFunc("/api/report/hari-ini", middlewares.CORS(middlewares.Logger(reportHandler.HandleReportToday)))
+	http.HandleFunc("/api/report/pajak", middlewares.CORS(middlewares.Logger(reportHandler.HandleTaxReport)))
+	http.HandleFunc("/api/report/bahan", middlewares.CORS(middlewares.Logger(reportHandler.HandleIngredientReport)))
+	http.HandleFunc("/api/report/laba", middlewares.CORS(middlewares.Logger(apiKeyMiddleware(reportHandler.HandleProfitReport))))
 	http.HandleFunc("/api/report", middlewares.CORS(middlewares.Logger(reportHandler.HandleReport)))
 
 	// Health check
@@ -91,3 +242,14 @@ func main() {
 		fmt.Println("failed running server")
 	}
 }
+
+// splitLines memecah nilai config multi-baris yang dipisah "|"
+func splitLines(value string) []string {
+	var lines []string
+	for _, line := range strings.Split(value, "|") {
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
@@ -1,10 +1,19 @@
 package middlewares
 
-import "net/http"
+import (
+	"net/http"
+	"strconv"
+)
+
+// OutletKeyLookup mencari outlet pemilik API key, ok false jika key bukan milik outlet manapun
+type OutletKeyLookup func(apiKey string) (outletID int, ok bool, err error)
 
 // func (api key) func handler http.handler
 // fungsi middleware ini me-return fungsi handler. Fungsi handler tersebut juga me-return fungsi
-func APIKeyMiddleware(validApiKey string) func(http.HandlerFunc) http.HandlerFunc {
+//
+// API key utama boleh memilih outlet lewat header X-Outlet-ID (kosong = outlet default),
+// sedangkan API key outlet selalu terikat ke outletnya sendiri.
+func APIKeyMiddleware(validApiKey string, lookupOutlet OutletKeyLookup) func(http.HandlerFunc) http.HandlerFunc {
 	return func(next http.HandlerFunc) http.HandlerFunc {
 		return func(w http.ResponseWriter, r *http.Request) {
 			apiKey := r.Header.Get("X-Api-Key")
@@ -14,13 +23,35 @@ func APIKeyMiddleware(validApiKey string) func(http.HandlerFunc) http.HandlerFun
 				return
 			}
 
-			if apiKey != validApiKey {
-				http.Error(w, "Invalid API Key", http.StatusUnauthorized)
-				return
+			var outlet OutletContext
+			if apiKey == validApiKey {
+				if v := r.Header.Get("X-Outlet-ID"); v != "" {
+					id, err := strconv.Atoi(v)
+					if err != nil || id <= 0 {
+						http.Error(w, "invalid X-Outlet-ID", http.StatusBadRequest)
+						return
+					}
+					outlet.ID = id
+				}
+			} else {
+				id, ok, err := lookupOutlet(apiKey)
+				if err != nil {
+					http.Error(w, err.Error(), http.StatusInternalServerError)
+					return
+				}
+				if !ok {
+					http.Error(w, "Invalid API Key", http.StatusUnauthorized)
+					return
+				}
+				if v := r.Header.Get("X-Outlet-ID"); v != "" && v != strconv.Itoa(id) {
+					http.Error(w, "API Key is not valid for this outlet", http.StatusForbidden)
+					return
+				}
+				outlet = OutletContext{ID: id, Fixed: true}
 			}
 
 			// jalankan fungsi selanjutnya jika API Key valid
-			next(w, r)
+			next(w, r.WithContext(WithOutlet(r.Context(), outlet)))
 		}
 	}
 }
@@ -0,0 +1,22 @@
+package middlewares
+
+import "context"
+
+type outletKey struct{}
+
+// OutletContext adalah outlet yang sedang dipakai request. ID 0 berarti outlet default.
+// Fixed bernilai true jika outlet ditentukan oleh API key outlet sehingga tidak bisa diganti lewat header.
+type OutletContext struct {
+	ID    int
+	Fixed bool
+}
+
+func WithOutlet(ctx context.Context, outlet OutletContext) context.Context {
+	return context.WithValue(ctx, outletKey{}, outlet)
+}
+
+// Outlet mengambil outlet request, kosong (outlet default) jika tidak ada
+func Outlet(ctx context.Context) OutletContext {
+	outlet, _ := ctx.Value(outletKey{}).(OutletContext)
+	return outlet
+}
@@ -0,0 +1,58 @@
+package models
+
+import "time"
+
+// status cart
+const (
+	CartStatusOpen       = "open"
+	CartStatusCheckedOut = "checked_out"
+	CartStatusCancelled  = "cancelled"
+)
+
+// Cart adalah keranjang yang diparkir sebelum checkout. Total dihitung ulang dari harga produk saat ini
+// termasuk promo otomatis dan pajak; diskon manual dan voucher baru diterapkan saat checkout.
+// Jika ReserveStock aktif, quantity item di cart tidak bisa dibeli oleh transaksi lain selama cart masih open.
+// Cart terikat ke outlet tempat dibuat; stok dan reservasi dihitung per outlet.
+type Cart struct {
+	ID                      int        `json:"id"`
+	OutletID                int        `json:"outlet_id"`
+	Name                    string     `json:"name"`
+	Customer                string     `json:"customer,omitempty"`
+	Status                  string     `json:"status"`
+	ReserveStock            bool       `json:"reserve_stock"`
+	TransactionID           *int       `json:"transaction_id,omitempty"`
+	CreatedAt               time.Time  `json:"created_at"`
+	UpdatedAt               time.Time  `json:"updated_at"`
+	Items                   []CartItem `json:"items"`
+	GrossAmount             int        `json:"gross_amount"`
+	PromotionDiscountAmount int        `json:"promotion_discount_amount"`
+	TaxAmount               int        `json:"tax_amount"`
+	ServiceChargeAmount     int        `json:"service_charge_amount"`
+	TotalAmount             int        `json:"total_amount"`
+}
+
+// CartItem dalam satuan Unit (kosong berarti satuan dasar), Stock tetap dalam satuan dasar
+type CartItem struct {
+	ProductID         int    `json:"product_id"`
+	ProductName       string `json:"product_name"`
+	Unit              string `json:"unit,omitempty"`
+	UnitPrice         int    `json:"unit_price"`
+	Quantity          int    `json:"quantity"`
+	PromotionDiscount int    `json:"promotion_discount"`
+	Subtotal          int    `json:"subtotal"`
+	Stock             int    `json:"stock"`
+}
+
+type CartInput struct {
+	Name         string         `json:"name"`
+	Customer     string         `json:"customer"`
+	ReserveStock bool           `json:"reserve_stock"`
+	Items        []CheckoutItem `json:"items"`
+	OutletID     int            `json:"-"`
+}
+
+type CartItemInput struct {
+	ProductID int    `json:"product_id"`
+	Unit      string `json:"unit"`
+	Quantity  int    `json:"quantity"`
+}
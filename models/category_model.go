@@ -1,7 +1,20 @@
 package models
 
+import "time"
+
+// Category bisa bersarang di bawah kategori lain lewat ParentID, misal Beverages > Soft Drinks > Soda.
+// Path adalah nama kategori dari akar sampai kategori ini, dan Children hanya diisi di endpoint tree.
+// Kategori yang diarsipkan (ArchivedAt terisi) tetap menyimpan produknya tetapi tidak bisa dipakai untuk
+// produk atau sub-kategori baru, dan tidak ditampilkan di daftar kecuali diminta.
 type Category struct {
 	ID          int    `json:"id"`
+	ParentID    *int   `json:"parent_id"`
 	Name        string `json:"name"`
 	Description string `json:"description"`
+	// TaxRate kosong berarti memakai tarif pajak default
+	TaxRate    *float64   `json:"tax_rate"`
+	TaxExempt  bool       `json:"tax_exempt"`
+	ArchivedAt *time.Time `json:"archived_at"`
+	Path       []string   `json:"path,omitempty"`
+	Children   []Category `json:"children,omitempty"`
 }
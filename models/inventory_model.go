@@ -0,0 +1,24 @@
+package models
+
+import "time"
+
+// LowStockItem adalah produk dengan stok di bawah atau sama dengan reorder point. OnOrder adalah
+// quantity yang masih ditunggu dari purchase order yang sudah dipesan. OutletID diisi pada peringatan,
+// yang selalu untuk stok dan PO satu outlet.
+type LowStockItem struct {
+	OutletID        int    `json:"outlet_id,omitempty"`
+	ProductID       int    `json:"product_id"`
+	Name            string `json:"name"`
+	Category        string `json:"category"`
+	Stock           int    `json:"stock"`
+	ReorderPoint    int    `json:"reorder_point"`
+	ReorderQuantity int    `json:"reorder_quantity"`
+	OnOrder         int    `json:"on_order"`
+}
+
+// LowStockAlert adalah event yang dikirim ke log dan/atau webhook saat stok produk menipis
+type LowStockAlert struct {
+	Event      string       `json:"event"`
+	Product    LowStockItem `json:"product"`
+	OccurredAt time.Time    `json:"occurred_at"`
+}
@@ -0,0 +1,12 @@
+package models
+
+// LabelRequest memilih produk yang dicetak labelnya: daftar ProductIDs, semua produk di CategoryID,
+// atau keduanya. Setiap produk dicetak Copies kali dengan ukuran label Width x Height mm.
+type LabelRequest struct {
+	ProductIDs []int
+	CategoryID int
+	Format     string
+	Width      float64
+	Height     float64
+	Copies     int
+}
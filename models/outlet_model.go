@@ -0,0 +1,87 @@
+package models
+
+import "time"
+
+// Outlet adalah satu toko. APIKey opsional; request dengan API key outlet otomatis terikat ke outlet tersebut.
+// Outlet default dipakai jika request tidak menyebut outlet. ServiceChargeRate dalam persen, 0 berarti tanpa service charge.
+type Outlet struct {
+	ID                   int       `json:"id"`
+	Name                 string    `json:"name"`
+	Address              string    `json:"address,omitempty"`
+	APIKey               string    `json:"api_key,omitempty"`
+	IsDefault            bool      `json:"is_default"`
+	ServiceChargeRate    float64   `json:"service_charge_rate"`
+	ServiceChargeTaxable bool      `json:"service_charge_taxable"`
+	CreatedAt            time.Time `json:"created_at"`
+}
+
+// OutletInput adalah body create/update outlet. GenerateAPIKey membuat API key baru untuk outlet.
+// ServiceChargeTaxable kosong berarti true saat create dan tidak diubah saat update.
+type OutletInput struct {
+	Name                 string  `json:"name"`
+	Address              string  `json:"address"`
+	IsDefault            bool    `json:"is_default"`
+	ServiceChargeRate    float64 `json:"service_charge_rate"`
+	ServiceChargeTaxable *bool   `json:"service_charge_taxable,omitempty"`
+	GenerateAPIKey       bool    `json:"generate_api_key"`
+}
+
+// OutletStock adalah stok satu produk di satu outlet
+type OutletStock struct {
+	OutletID    int    `json:"outlet_id"`
+	OutletName  string `json:"outlet_name,omitempty"`
+	ProductID   int    `json:"product_id"`
+	ProductName string `json:"product_name,omitempty"`
+	Stock       int    `json:"stock"`
+}
+
+// status dokumen transfer stok
+const (
+	StockTransferStatusDraft     = "draft"
+	StockTransferStatusInTransit = "in_transit"
+	StockTransferStatusReceived  = "received"
+	StockTransferStatusCancelled = "cancelled"
+)
+
+// StockTransfer memindahkan stok antar outlet. Saat dikirim stok keluar dari outlet asal dan berstatus
+// in_transit sampai diterima di outlet tujuan.
+type StockTransfer struct {
+	ID             int                 `json:"id"`
+	FromOutletID   int                 `json:"from_outlet_id"`
+	FromOutletName string              `json:"from_outlet_name"`
+	ToOutletID     int                 `json:"to_outlet_id"`
+	ToOutletName   string              `json:"to_outlet_name"`
+	Status         string              `json:"status"`
+	Note           string              `json:"note,omitempty"`
+	CreatedBy      string              `json:"created_by,omitempty"`
+	SentBy         string              `json:"sent_by,omitempty"`
+	ReceivedBy     string              `json:"received_by,omitempty"`
+	CreatedAt      time.Time           `json:"created_at"`
+	SentAt         *time.Time          `json:"sent_at,omitempty"`
+	ReceivedAt     *time.Time          `json:"received_at,omitempty"`
+	Items          []StockTransferItem `json:"items"`
+}
+
+type StockTransferItem struct {
+	ProductID   int    `json:"product_id"`
+	ProductName string `json:"product_name,omitempty"`
+	Quantity    int    `json:"quantity"`
+}
+
+type StockTransferInput struct {
+	FromOutletID int                 `json:"from_outlet_id"`
+	ToOutletID   int                 `json:"to_outlet_id"`
+	Note         string              `json:"note"`
+	CreatedBy    string              `json:"created_by"`
+	Items        []StockTransferItem `json:"items"`
+}
+
+// StockTransferAction adalah body kirim, terima atau batalkan transfer
+type StockTransferAction struct {
+	User string `json:"user"`
+}
+
+type StockTransferFilter struct {
+	Status   string
+	OutletID int
+}
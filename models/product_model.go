@@ -1,16 +1,69 @@
 package models
 
+// Product berisi harga jual Price dan harga pokok CostPrice. ReorderPoint kosong berarti stok tidak dipantau.
+// Varian (misal ukuran atau rasa) adalah produk dengan ParentID dan Options; Stock induknya adalah jumlah stok varian.
+// Stok dicatat dalam satuan dasar Unit, Units adalah satuan lain untuk jual dan beli (misal karton isi 40).
+// Produk dengan TrackExpiry mencatat stoknya per lot dengan tanggal kedaluwarsa, lihat StockLot.
+// Produk komposisi dibuat dari Components dan Stock-nya adalah jumlah yang bisa dibuat dari stok bahan di outlet request.
+// CategoryPath adalah breadcrumb kategori produk dari kategori akar, misal ["Beverages", "Soft Drinks", "Soda"].
 type Product struct {
-	ID       int    `json:"id"`
-	Name     string `json:"name"`
-	Price    int    `json:"price"`
-	Stock    int    `json:"stock"`
-	Category string `json:"category"`
+	ID              int                `json:"id"`
+	ParentID        *int               `json:"parent_id,omitempty"`
+	Name            string             `json:"name"`
+	Options         map[string]string  `json:"options,omitempty"`
+	SKU             string             `json:"sku,omitempty"`
+	Barcodes        []string           `json:"barcodes"`
+	Price           int                `json:"price"`
+	CostPrice       int                `json:"cost_price"`
+	Stock           int                `json:"stock"`
+	Unit            string             `json:"unit"`
+	Units           []ProductUnit      `json:"units"`
+	TrackExpiry     bool               `json:"track_expiry"`
+	Components      []ProductComponent `json:"components,omitempty"`
+	ReorderPoint    *int               `json:"reorder_point"`
+	ReorderQuantity int                `json:"reorder_quantity"`
+	CategoryID      int                `json:"category_id"`
+	Category        string             `json:"category"`
+	CategoryPath    []string           `json:"category_path"`
+	Variants        []Product          `json:"variants,omitempty"`
 }
 
+// ProductComponent adalah satu bahan resep produk komposisi. Quantity dalam satuan dasar bahan untuk
+// satu satuan dasar produk. Bahan harus produk biasa yang menyimpan stok, bukan produk induk atau produk komposisi lain.
+type ProductComponent struct {
+	ProductID int    `json:"product_id"`
+	Name      string `json:"name,omitempty"`
+	Quantity  int    `json:"quantity"`
+}
+
+// ProductUnit adalah satuan selain satuan dasar. Factor adalah jumlah satuan dasar dalam satu satuan ini
+// dan Price adalah harga jual per satuan ini. Barcode satuan terpisah dari barcode produk.
+type ProductUnit struct {
+	Name     string   `json:"name"`
+	Factor   int      `json:"factor"`
+	Price    int      `json:"price"`
+	Barcodes []string `json:"barcodes"`
+}
+
+// ProductInput adalah body create/update produk, lihat ProductRepository.Create dan Update. Perubahan Stock
+// dicatat di buku stok outlet OutletID (0 berarti outlet default) dengan alasan StockReason dan user ChangedBy.
 type ProductInput struct {
-	Name       string `json:"name"`
-	Price      int    `json:"price"`
-	Stock      int    `json:"stock"`
-	CategoryID int    `json:"category_id"`
+	ParentID        *int               `json:"parent_id,omitempty"`
+	Name            string             `json:"name"`
+	Options         map[string]string  `json:"options,omitempty"`
+	SKU             *string            `json:"sku,omitempty"`
+	Barcodes        []string           `json:"barcodes"`
+	Price           int                `json:"price"`
+	CostPrice       *int               `json:"cost_price,omitempty"`
+	Stock           *int               `json:"stock,omitempty"`
+	Unit            string             `json:"unit"`
+	Units           []ProductUnit      `json:"units"`
+	TrackExpiry     *bool              `json:"track_expiry,omitempty"`
+	Components      []ProductComponent `json:"components"`
+	ReorderPoint    *int               `json:"reorder_point,omitempty"`
+	ReorderQuantity *int               `json:"reorder_quantity,omitempty"`
+	CategoryID      int                `json:"category_id"`
+	StockReason     string             `json:"stock_reason,omitempty"`
+	ChangedBy       string             `json:"changed_by,omitempty"`
+	OutletID        int                `json:"-"`
 }
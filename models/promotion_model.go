@@ -0,0 +1,46 @@
+package models
+
+import "time"
+
+// jenis promo
+const (
+	PromotionTypeBOGO            = "bogo"             // beli X gratis Y untuk satu produk
+	PromotionTypeBundle          = "bundle"           // harga paket untuk kombinasi produk
+	PromotionTypeCategoryPercent = "category_percent" // diskon persen untuk satu kategori
+	PromotionTypeHappyHour       = "happy_hour"       // diskon persen pada jam tertentu
+)
+
+// Promotion adalah aturan promo yang dievaluasi otomatis saat checkout. Promo dengan Priority lebih
+// besar dievaluasi lebih dulu. Promo yang tidak Stackable tidak bisa digabung dengan promo lain pada
+// baris item yang sama. StartTime dan EndTime berformat "15:04" dan membatasi jam berlakunya promo.
+type Promotion struct {
+	ID          int                   `json:"id"`
+	Name        string                `json:"name"`
+	Type        string                `json:"type"`
+	Priority    int                   `json:"priority"`
+	Stackable   bool                  `json:"stackable"`
+	Active      bool                  `json:"active"`
+	ProductID   *int                  `json:"product_id,omitempty"`
+	CategoryID  *int                  `json:"category_id,omitempty"`
+	BuyQuantity int                   `json:"buy_quantity,omitempty"`
+	GetQuantity int                   `json:"get_quantity,omitempty"`
+	BundlePrice int                   `json:"bundle_price,omitempty"`
+	Percent     float64               `json:"percent,omitempty"`
+	BundleItems []PromotionBundleItem `json:"bundle_items,omitempty"`
+	StartDate   *time.Time            `json:"start_date,omitempty"`
+	EndDate     *time.Time            `json:"end_date,omitempty"`
+	StartTime   string                `json:"start_time,omitempty"`
+	EndTime     string                `json:"end_time,omitempty"`
+}
+
+type PromotionBundleItem struct {
+	ProductID int `json:"product_id"`
+	Quantity  int `json:"quantity"`
+}
+
+// AppliedPromotion adalah promo yang diterapkan pada sebuah transaksi
+type AppliedPromotion struct {
+	PromotionID    int    `json:"promotion_id"`
+	Name           string `json:"name"`
+	DiscountAmount int    `json:"discount_amount"`
+}
@@ -0,0 +1,101 @@
+package models
+
+import "time"
+
+// status purchase order
+const (
+	PurchaseOrderStatusDraft             = "draft"
+	PurchaseOrderStatusOrdered           = "ordered"
+	PurchaseOrderStatusPartiallyReceived = "partially_received"
+	PurchaseOrderStatusClosed            = "closed"
+)
+
+// PurchaseOrder adalah pesanan pembelian ke supplier. Line hanya bisa diubah selama draft;
+// setelah ordered, stok outlet PO bertambah lewat goods receipt sampai semua line diterima atau PO ditutup.
+type PurchaseOrder struct {
+	ID           int                 `json:"id"`
+	OutletID     int                 `json:"outlet_id"`
+	SupplierID   int                 `json:"supplier_id"`
+	SupplierName string              `json:"supplier_name"`
+	Status       string              `json:"status"`
+	Note         string              `json:"note,omitempty"`
+	CreatedBy    string              `json:"created_by,omitempty"`
+	CreatedAt    time.Time           `json:"created_at"`
+	OrderedAt    *time.Time          `json:"ordered_at,omitempty"`
+	ClosedAt     *time.Time          `json:"closed_at,omitempty"`
+	TotalCost    int                 `json:"total_cost"`
+	Lines        []PurchaseOrderLine `json:"lines"`
+	Receipts     []GoodsReceipt      `json:"receipts"`
+}
+
+// PurchaseOrderLine berisi quantity dan harga beli per unit yang diharapkan. Quantity, UnitCost dan
+// quantity yang diterima dalam satuan Unit (kosong berarti satuan dasar) berisi UnitFactor satuan dasar.
+type PurchaseOrderLine struct {
+	ID               int    `json:"id"`
+	ProductID        int    `json:"product_id"`
+	ProductName      string `json:"product_name,omitempty"`
+	Unit             string `json:"unit,omitempty"`
+	UnitFactor       int    `json:"unit_factor"`
+	Quantity         int    `json:"quantity"`
+	UnitCost         int    `json:"unit_cost"`
+	ReceivedQuantity int    `json:"received_quantity"`
+}
+
+type PurchaseOrderInput struct {
+	SupplierID int                      `json:"supplier_id"`
+	Note       string                   `json:"note"`
+	CreatedBy  string                   `json:"created_by"`
+	Lines      []PurchaseOrderLineInput `json:"lines"`
+	OutletID   int                      `json:"-"`
+}
+
+type PurchaseOrderLineInput struct {
+	ProductID int    `json:"product_id"`
+	Unit      string `json:"unit"`
+	Quantity  int    `json:"quantity"`
+	UnitCost  int    `json:"unit_cost"`
+}
+
+type PurchaseOrderFilter struct {
+	Status     string
+	SupplierID int
+	OutletID   int
+}
+
+// GoodsReceipt adalah satu penerimaan barang untuk PO, bisa sebagian
+type GoodsReceipt struct {
+	ID              int                `json:"id"`
+	PurchaseOrderID int                `json:"purchase_order_id"`
+	ReceivedBy      string             `json:"received_by,omitempty"`
+	Note            string             `json:"note,omitempty"`
+	CreatedAt       time.Time          `json:"created_at"`
+	Items           []GoodsReceiptItem `json:"items"`
+}
+
+// GoodsReceiptItem mencatat quantity yang diterima untuk satu line PO. UnitCost adalah harga beli aktual,
+// default harga di line PO. LotNumber dan ExpiryDate adalah lot tempat barang dicatat.
+type GoodsReceiptItem struct {
+	PurchaseOrderLineID int    `json:"purchase_order_line_id"`
+	ProductID           int    `json:"product_id"`
+	Quantity            int    `json:"quantity"`
+	UnitCost            int    `json:"unit_cost"`
+	LotNumber           string `json:"lot_number,omitempty"`
+	ExpiryDate          string `json:"expiry_date,omitempty"`
+}
+
+type GoodsReceiptRequest struct {
+	ReceivedBy string                    `json:"received_by"`
+	Note       string                    `json:"note"`
+	Items      []GoodsReceiptItemRequest `json:"items"`
+}
+
+// GoodsReceiptItemRequest menunjuk line PO lewat PurchaseOrderLineID atau ProductID. ExpiryDate (YYYY-MM-DD)
+// wajib untuk produk dengan TrackExpiry; line yang sama boleh muncul beberapa kali untuk lot yang berbeda.
+type GoodsReceiptItemRequest struct {
+	PurchaseOrderLineID int    `json:"purchase_order_line_id"`
+	ProductID           int    `json:"product_id"`
+	Quantity            int    `json:"quantity"`
+	UnitCost            *int   `json:"unit_cost,omitempty"`
+	LotNumber           string `json:"lot_number,omitempty"`
+	ExpiryDate          string `json:"expiry_date,omitempty"`
+}
@@ -1,12 +1,82 @@
 package models
 
 type Report struct {
-	TotalRevenue   int            `json:"total_revenue"`
-	TotalTransaksi int            `json:"total_transaksi"`
-	ProdukTerlaris ProdukTerlaris `json:"produk_terlaris"`
+	TotalRevenue    int             `json:"total_revenue"`
+	TotalTransaksi  int             `json:"total_transaksi"`
+	PenjualanKotor  int             `json:"penjualan_kotor"`
+	TotalDiskon     int             `json:"total_diskon"`
+	PenjualanBersih int             `json:"penjualan_bersih"`
+	TotalHPP        int             `json:"total_hpp"`
+	LabaKotor       int             `json:"laba_kotor"`
+	Margin          float64         `json:"margin"`
+	ProdukTerlaris  ProdukTerlaris  `json:"produk_terlaris"`
+	Pembayaran      []ReportPayment `json:"pembayaran"`
+}
+
+// ReportPayment adalah revenue per metode pembayaran (cash sudah dikurangi kembalian, refund dibagi
+// proporsional ke pembayaran transaksinya)
+type ReportPayment struct {
+	Metode         string `json:"metode"`
+	Total          int    `json:"total"`
+	TotalTransaksi int    `json:"total_transaksi"`
 }
 
 type ProdukTerlaris struct {
 	Nama       string `json:"nama"`
 	QtyTerjual int    `json:"qty_terjual"`
 }
+
+// pengelompokan laporan laba
+const (
+	ProfitGroupProduct  = "product"
+	ProfitGroupCategory = "category"
+	ProfitGroupDay      = "day"
+)
+
+// ProfitReport adalah laporan laba kotor. Penjualan adalah penjualan bersih setelah diskon, tanpa pajak
+// dan service charge, HPP dihitung dari harga pokok saat checkout, dan Margin adalah laba kotor dibagi
+// penjualan dalam persen. Baris yang di-refund dikurangi sesuai quantity yang di-refund.
+type ProfitReport struct {
+	StartDate  string      `json:"start_date"`
+	EndDate    string      `json:"end_date"`
+	GroupBy    string      `json:"group_by"`
+	OutletID   int         `json:"outlet_id,omitempty"`
+	CategoryID int         `json:"category_id,omitempty"`
+	Penjualan  int         `json:"penjualan"`
+	HPP        int         `json:"hpp"`
+	LabaKotor  int         `json:"laba_kotor"`
+	Margin     float64     `json:"margin"`
+	Rincian    []ProfitRow `json:"rincian"`
+}
+
+type ProfitRow struct {
+	Nama       string  `json:"nama"`
+	QtyTerjual int     `json:"qty_terjual"`
+	Penjualan  int     `json:"penjualan"`
+	HPP        int     `json:"hpp"`
+	LabaKotor  int     `json:"laba_kotor"`
+	Margin     float64 `json:"margin"`
+}
+
+// IngredientReport adalah laporan menu terjual dan bahan yang terpakai oleh produk komposisi. Quantity
+// dalam satuan dasar dan sudah dikurangi quantity yang di-refund; HPP bahan dihitung dari harga pokok saat checkout.
+type IngredientReport struct {
+	StartDate     string          `json:"start_date"`
+	EndDate       string          `json:"end_date"`
+	OutletID      int             `json:"outlet_id,omitempty"`
+	MenuTerjual   []MenuSoldRow   `json:"menu_terjual"`
+	BahanTerpakai []IngredientRow `json:"bahan_terpakai"`
+}
+
+type MenuSoldRow struct {
+	ProductID  *int   `json:"product_id"`
+	Nama       string `json:"nama"`
+	QtyTerjual int    `json:"qty_terjual"`
+}
+
+type IngredientRow struct {
+	ProductID *int   `json:"product_id"`
+	Nama      string `json:"nama"`
+	Qty       int    `json:"qty"`
+	HPP       int    `json:"hpp"`
+}
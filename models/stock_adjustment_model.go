@@ -0,0 +1,43 @@
+package models
+
+import "time"
+
+// kode alasan penyesuaian stok. found menambah stok, selain itu mengurangi stok.
+const (
+	AdjustmentReasonDamaged = "damaged"
+	AdjustmentReasonExpired = "expired"
+	AdjustmentReasonLost    = "lost"
+	AdjustmentReasonFound   = "found"
+)
+
+var AdjustmentReasons = []string{AdjustmentReasonDamaged, AdjustmentReasonExpired, AdjustmentReasonLost, AdjustmentReasonFound}
+
+// StockAdjustment adalah satu dokumen penyesuaian stok di luar penjualan, bisa berisi beberapa produk
+type StockAdjustment struct {
+	ID         int                   `json:"id"`
+	OutletID   int                   `json:"outlet_id"`
+	Reason     string                `json:"reason"`
+	Note       string                `json:"note,omitempty"`
+	AdjustedBy string                `json:"adjusted_by,omitempty"`
+	CreatedAt  time.Time             `json:"created_at"`
+	Items      []StockAdjustmentItem `json:"items"`
+}
+
+// StockAdjustmentItem berisi Quantity positif, arah perubahan ditentukan oleh Reason.
+// Balance adalah stok produk di outlet setelah penyesuaian. LotID memilih lot yang dikurangi untuk produk
+// yang dilacak kedaluwarsanya, kosong berarti lot yang paling cepat kedaluwarsa lebih dulu.
+type StockAdjustmentItem struct {
+	ProductID   int    `json:"product_id"`
+	ProductName string `json:"product_name,omitempty"`
+	LotID       *int   `json:"lot_id,omitempty"`
+	Quantity    int    `json:"quantity"`
+	Balance     int    `json:"balance"`
+}
+
+type StockAdjustmentRequest struct {
+	Reason     string                `json:"reason"`
+	Note       string                `json:"note"`
+	AdjustedBy string                `json:"adjusted_by"`
+	Items      []StockAdjustmentItem `json:"items"`
+	OutletID   int                   `json:"-"`
+}
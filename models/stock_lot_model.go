@@ -0,0 +1,30 @@
+package models
+
+import "time"
+
+// StockLot adalah stok satu produk di satu outlet dengan nomor lot dan tanggal kedaluwarsa yang sama.
+// Lot hanya dicatat untuk produk dengan TrackExpiry, dan jumlah Quantity semua lot selalu sama dengan stok outlet;
+// saat pelacakan dimatikan, lot ditutup dengan Quantity 0. Stok keluar mengambil lot yang paling cepat kedaluwarsa
+// lebih dulu (FEFO) dan lot yang sudah kedaluwarsa hanya bisa dikeluarkan lewat penyesuaian stok. ExpiryDate (YYYY-MM-DD) kosong
+// berarti stok tanpa tanggal kedaluwarsa, misal stok awal, dan diambil paling akhir.
+// DaysLeft negatif berarti lot sudah kedaluwarsa; Value adalah Quantity dikali harga pokok produk.
+type StockLot struct {
+	ID          int       `json:"id"`
+	ProductID   int       `json:"product_id"`
+	ProductName string    `json:"product_name,omitempty"`
+	Category    string    `json:"category,omitempty"`
+	OutletID    int       `json:"outlet_id"`
+	LotNumber   string    `json:"lot_number,omitempty"`
+	ExpiryDate  string    `json:"expiry_date,omitempty"`
+	DaysLeft    *int      `json:"days_left,omitempty"`
+	Quantity    int       `json:"quantity"`
+	Value       int       `json:"value"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// ExpiringLotFilter untuk laporan lot yang akan kedaluwarsa dalam Days hari ke depan, termasuk yang sudah lewat.
+// OutletID 0 berarti semua outlet.
+type ExpiringLotFilter struct {
+	Days     int
+	OutletID int
+}
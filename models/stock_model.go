@@ -0,0 +1,47 @@
+package models
+
+import "time"
+
+// jenis pergerakan stok
+const (
+	StockMovementInitial    = "initial"
+	StockMovementSale       = "sale"
+	StockMovementRefund     = "refund"
+	StockMovementVoid       = "void"
+	StockMovementAdjustment = "adjustment"
+	StockMovementReceiving  = "receiving"
+	StockMovementTransfer   = "transfer"
+)
+
+// StockMovement adalah satu baris buku stok. Quantity adalah perubahan (negatif untuk stok keluar)
+// dan Balance adalah stok produk di outlet tersebut setelah perubahan. ReferenceType dan ReferenceID menunjuk dokumen
+// asal perubahan, misal transaksi untuk penjualan dan refund.
+type StockMovement struct {
+	ID            int       `json:"id"`
+	ProductID     int       `json:"product_id"`
+	OutletID      int       `json:"outlet_id"`
+	Type          string    `json:"type"`
+	Quantity      int       `json:"quantity"`
+	Balance       int       `json:"balance"`
+	Reason        string    `json:"reason,omitempty"`
+	ReferenceType string    `json:"reference_type,omitempty"`
+	ReferenceID   *int      `json:"reference_id,omitempty"`
+	User          string    `json:"user,omitempty"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+type StockMovementFilter struct {
+	StartDate string
+	EndDate   string
+	Type      string
+	OutletID  int
+	Page      int
+	Limit     int
+}
+
+type StockMovementList struct {
+	Data  []StockMovement `json:"data"`
+	Page  int             `json:"page"`
+	Limit int             `json:"limit"`
+	Total int             `json:"total"`
+}
@@ -0,0 +1,67 @@
+package models
+
+import "time"
+
+// status sesi stock opname
+const (
+	StocktakeStatusOpen      = "open"
+	StocktakeStatusPosted    = "posted"
+	StocktakeStatusCancelled = "cancelled"
+)
+
+// Stocktake adalah satu sesi stock opname. Selama open, hitungan bisa dikirim dari beberapa perangkat;
+// saat di-post semua selisih dibukukan sebagai penyesuaian stok dalam satu transaksi database.
+// Sesi selalu menghitung stok satu outlet.
+type Stocktake struct {
+	ID        int              `json:"id"`
+	OutletID  int              `json:"outlet_id"`
+	Name      string           `json:"name"`
+	Status    string           `json:"status"`
+	OpenedBy  string           `json:"opened_by,omitempty"`
+	PostedBy  string           `json:"posted_by,omitempty"`
+	CreatedAt time.Time        `json:"created_at"`
+	PostedAt  *time.Time       `json:"posted_at,omitempty"`
+	Counts    []StocktakeCount `json:"counts"`
+	// ringkasan selisih dari semua hitungan
+	TotalVariance int `json:"total_variance"`
+}
+
+// StocktakeCount adalah hasil hitung satu produk. SystemStock adalah stok outlet saat produk dihitung
+// dan Variance adalah selisih hitungan terhadapnya, yang dibukukan saat posting.
+type StocktakeCount struct {
+	ProductID       int       `json:"product_id"`
+	ProductName     string    `json:"product_name"`
+	CountedQuantity int       `json:"counted_quantity"`
+	SystemStock     int       `json:"system_stock"`
+	Variance        int       `json:"variance"`
+	CountedBy       string    `json:"counted_by,omitempty"`
+	UpdatedAt       time.Time `json:"updated_at"`
+}
+
+type StocktakeInput struct {
+	Name     string `json:"name"`
+	OpenedBy string `json:"opened_by"`
+	OutletID int    `json:"-"`
+}
+
+// mode pengiriman hitungan: set mengganti hitungan produk, add menambahkan ke hitungan sebelumnya
+// (misal produk yang sama ada di beberapa rak dan dihitung dari perangkat berbeda)
+const (
+	StocktakeCountModeSet = "set"
+	StocktakeCountModeAdd = "add"
+)
+
+type StocktakeCountRequest struct {
+	Mode      string               `json:"mode"`
+	CountedBy string               `json:"counted_by"`
+	Items     []StocktakeCountItem `json:"items"`
+}
+
+type StocktakeCountItem struct {
+	ProductID int `json:"product_id"`
+	Quantity  int `json:"quantity"`
+}
+
+type StocktakePostRequest struct {
+	PostedBy string `json:"posted_by"`
+}
@@ -0,0 +1,13 @@
+package models
+
+import "time"
+
+type Supplier struct {
+	ID        int       `json:"id"`
+	Name      string    `json:"name"`
+	Contact   string    `json:"contact,omitempty"`
+	Phone     string    `json:"phone,omitempty"`
+	Email     string    `json:"email,omitempty"`
+	Address   string    `json:"address,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
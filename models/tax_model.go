@@ -0,0 +1,47 @@
+package models
+
+// mode perhitungan pajak
+const (
+	TaxModeExclusive = "exclusive" // harga produk belum termasuk pajak
+	TaxModeInclusive = "inclusive" // harga produk sudah termasuk pajak
+)
+
+// TaxConfig adalah konfigurasi pajak (PPN) dan service charge yang dipakai saat checkout.
+// Rate dan ServiceChargeRate dalam persen, misal 11 untuk PPN 11%. Service charge diisi dari outlet transaksi.
+type TaxConfig struct {
+	Mode              string
+	Rate              float64
+	ServiceChargeRate float64
+	// ServiceChargeTaxable menentukan apakah service charge ikut dikenakan pajak
+	ServiceChargeTaxable bool
+}
+
+// RateFor mengembalikan tarif pajak untuk produk dengan tarif dan status bebas pajak dari kategorinya
+func (c TaxConfig) RateFor(categoryRate *float64, exempt bool) float64 {
+	if exempt {
+		return 0
+	}
+	if categoryRate != nil {
+		return *categoryRate
+	}
+	return c.Rate
+}
+
+// TaxReport adalah ringkasan pajak dan service charge dalam satu periode
+type TaxReport struct {
+	StartDate      string          `json:"start_date"`
+	EndDate        string          `json:"end_date"`
+	OutletID       int             `json:"outlet_id,omitempty"`
+	TotalTransaksi int             `json:"total_transaksi"`
+	DPP            int             `json:"dpp"`
+	TotalPajak     int             `json:"total_pajak"`
+	ServiceCharge  int             `json:"service_charge"`
+	PerTarif       []TaxRateReport `json:"per_tarif"`
+}
+
+// TaxRateReport adalah dasar pengenaan pajak (DPP) dan pajak untuk satu tarif
+type TaxRateReport struct {
+	Tarif      float64 `json:"tarif"`
+	DPP        int     `json:"dpp"`
+	TotalPajak int     `json:"total_pajak"`
+}
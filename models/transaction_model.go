@@ -2,27 +2,182 @@ package models
 
 import "time"
 
+// status transaksi
+const (
+	TransactionStatusCompleted         = "completed"
+	TransactionStatusPartiallyRefunded = "partially_refunded"
+	TransactionStatusRefunded          = "refunded"
+	TransactionStatusVoided            = "voided"
+)
+
 type Transaction struct {
-	ID          int                 `json:"id"`
-	TotalAmount int                 `json:"total_amount"`
-	CreatedAt   time.Time           `json:"created_at"`
-	Details     []TransactionDetail `json:"details"`
+	ID                      int                 `json:"id"`
+	OutletID                int                 `json:"outlet_id"`
+	GrossAmount             int                 `json:"gross_amount"`
+	PromotionDiscountAmount int                 `json:"promotion_discount_amount"`
+	LineDiscountAmount      int                 `json:"line_discount_amount"`
+	DiscountAmount          int                 `json:"discount_amount"`
+	VoucherCode             string              `json:"voucher_code,omitempty"`
+	VoucherDiscountAmount   int                 `json:"voucher_discount_amount"`
+	Customer                string              `json:"customer,omitempty"`
+	SubtotalAmount          int                 `json:"subtotal_amount"`
+	TaxMode                 string              `json:"tax_mode"`
+	TaxableAmount           int                 `json:"taxable_amount"`
+	TaxAmount               int                 `json:"tax_amount"`
+	ServiceChargeAmount     int                 `json:"service_charge_amount"`
+	TotalAmount             int                 `json:"total_amount"`
+	Status                  string              `json:"status"`
+	RefundedAmount          int                 `json:"refunded_amount"`
+	PaidAmount              int                 `json:"paid_amount"`
+	ChangeAmount            int                 `json:"change_amount"`
+	CreatedAt               time.Time           `json:"created_at"`
+	Details                 []TransactionDetail `json:"details"`
+	Payments                []Payment           `json:"payments"`
+	Promotions              []AppliedPromotion  `json:"promotions,omitempty"`
+	Refunds                 []TransactionRefund `json:"refunds,omitempty"`
 }
 
+// TransactionDetail adalah satu baris item transaksi. UnitPrice, UnitCost dan Quantity dalam satuan Unit,
+// Subtotal setelah promo dan diskon baris, LineTotal termasuk pajak dan service charge.
 type TransactionDetail struct {
-	ID            int    `json:"id"`
-	TransactionID int    `json:"transaction_id"`
-	ProductID     int    `json:"produt_id"`
-	ProductName   string `json:"product_name,omitempty"`
-	Quantity      int    `json:"quantity"`
-	Subtotal      int    `json:"subtotal"`
+	ID                  int                          `json:"id"`
+	TransactionID       int                          `json:"transaction_id"`
+	ProductID           int                          `json:"produt_id"`
+	ProductName         string                       `json:"product_name,omitempty"`
+	CategoryName        string                       `json:"category_name,omitempty"`
+	UnitPrice           int                          `json:"unit_price"`
+	UnitCost            int                          `json:"unit_cost"`
+	Quantity            int                          `json:"quantity"`
+	Unit                string                       `json:"unit,omitempty"`
+	UnitFactor          int                          `json:"unit_factor"`
+	PromotionDiscount   int                          `json:"promotion_discount"`
+	DiscountAmount      int                          `json:"discount_amount"`
+	Subtotal            int                          `json:"subtotal"`
+	AllocatedDiscount   int                          `json:"allocated_discount"`
+	TaxRate             float64                      `json:"tax_rate"`
+	TaxableAmount       int                          `json:"taxable_amount"`
+	TaxAmount           int                          `json:"tax_amount"`
+	ServiceChargeAmount int                          `json:"service_charge_amount"`
+	LineTotal           int                          `json:"line_total"`
+	RefundedQuantity    int                          `json:"refunded_quantity"`
+	Components          []TransactionDetailComponent `json:"components,omitempty"`
+}
+
+// TransactionDetailComponent adalah bahan yang dipakai satu baris produk komposisi. Quantity dalam satuan dasar
+// bahan untuk satu quantity baris, UnitCost adalah harga pokok bahan per satuan dasar saat checkout.
+type TransactionDetailComponent struct {
+	ProductID   int    `json:"product_id"`
+	ProductName string `json:"product_name"`
+	Quantity    int    `json:"quantity"`
+	UnitCost    int    `json:"unit_cost"`
+}
+
+// metode pembayaran yang diterima saat checkout
+const (
+	PaymentMethodCash     = "cash"
+	PaymentMethodDebit    = "debit"
+	PaymentMethodQRIS     = "qris"
+	PaymentMethodEWallet  = "e_wallet"
+	PaymentMethodTransfer = "transfer"
+)
+
+var PaymentMethods = []string{PaymentMethodCash, PaymentMethodDebit, PaymentMethodQRIS, PaymentMethodEWallet, PaymentMethodTransfer}
+
+// Payment adalah satu pembayaran pada transaksi. Amount untuk cash adalah uang yang diserahkan pelanggan,
+// ChangeAmount adalah kembalian yang diberikan dari pembayaran tersebut
+type Payment struct {
+	Method       string `json:"method"`
+	Amount       int    `json:"amount"`
+	ChangeAmount int    `json:"change_amount,omitempty"`
+}
+
+// TransactionRefund mencatat void (type "void") atau refund sebagian (type "refund")
+type TransactionRefund struct {
+	ID            int                     `json:"id"`
+	TransactionID int                     `json:"transaction_id"`
+	Type          string                  `json:"type"`
+	Reason        string                  `json:"reason"`
+	RefundedBy    string                  `json:"refunded_by"`
+	Amount        int                     `json:"amount"`
+	CreatedAt     time.Time               `json:"created_at"`
+	Items         []TransactionRefundItem `json:"items"`
+}
+
+type TransactionRefundItem struct {
+	TransactionDetailID int `json:"transaction_detail_id"`
+	Quantity            int `json:"quantity"`
+	Amount              int `json:"amount"`
+}
+
+type VoidRequest struct {
+	Reason   string `json:"reason"`
+	VoidedBy string `json:"voided_by"`
+}
+
+type RefundRequest struct {
+	Reason     string       `json:"reason"`
+	RefundedBy string       `json:"refunded_by"`
+	Items      []RefundItem `json:"items"`
+}
+
+type RefundItem struct {
+	TransactionDetailID int `json:"transaction_detail_id"`
+	Quantity            int `json:"quantity"`
+}
+
+// jenis diskon
+const (
+	DiscountTypePercent = "percent"
+	DiscountTypeFixed   = "fixed"
+)
+
+// Discount berisi diskon persen (Value 0-100) atau potongan rupiah (Value nominal)
+type Discount struct {
+	Type  string  `json:"type"`
+	Value float64 `json:"value"`
 }
 
+// CheckoutItem menunjuk produk lewat ProductID atau Barcode hasil scan. Unit adalah nama satuan jual
+// produk (misal karton), kosong berarti satuan dasar; barcode satuan otomatis mengisi Unit.
 type CheckoutItem struct {
-	ProductID int `json:"product_id"`
-	Quantity  int `json:"quantity"`
+	ProductID int       `json:"product_id"`
+	Barcode   string    `json:"barcode,omitempty"`
+	Unit      string    `json:"unit,omitempty"`
+	Quantity  int       `json:"quantity"`
+	Discount  *Discount `json:"discount,omitempty"`
 }
 
+// CheckoutRequest adalah body POST /api/checkout. Customer adalah identitas pelanggan (misal nomor HP)
+// dan wajib diisi untuk voucher yang dibatasi per pelanggan.
 type CheckoutRequest struct {
-	Items []CheckoutItem `json:"items"`
+	Items       []CheckoutItem `json:"items"`
+	Discount    *Discount      `json:"discount,omitempty"`
+	VoucherCode string         `json:"voucher_code,omitempty"`
+	Customer    string         `json:"customer,omitempty"`
+	Cashier     string         `json:"cashier,omitempty"`
+	Payments    []Payment      `json:"payments"`
+	// CartID diisi saat checkout berasal dari cart, bukan dari body request
+	CartID int `json:"-"`
+	// OutletID diisi dari API key atau header X-Outlet-ID, 0 berarti outlet default
+	OutletID int `json:"-"`
+}
+
+// TransactionFilter berisi parameter filter dan pagination untuk riwayat transaksi
+type TransactionFilter struct {
+	StartDate string
+	EndDate   string
+	MinAmount *int
+	MaxAmount *int
+	ProductID int
+	Status    string
+	OutletID  int
+	Page      int
+	Limit     int
+}
+
+type TransactionList struct {
+	Data  []Transaction `json:"data"`
+	Page  int           `json:"page"`
+	Limit int           `json:"limit"`
+	Total int           `json:"total"`
 }
@@ -0,0 +1,48 @@
+package models
+
+import "time"
+
+// VoucherBatch adalah satu kampanye voucher beserta aturannya. Semua kode dalam batch memakai aturan
+// yang sama. UsageLimit adalah batas pemakaian per kode dan PerCustomerLimit batas pemakaian per
+// pelanggan untuk seluruh kode dalam batch; nilai 0 berarti tidak dibatasi.
+type VoucherBatch struct {
+	ID               int        `json:"id"`
+	Name             string     `json:"name"`
+	Type             string     `json:"type"`
+	Value            float64    `json:"value"`
+	MaxDiscount      int        `json:"max_discount"`
+	MinSpend         int        `json:"min_spend"`
+	ExpiresAt        *time.Time `json:"expires_at"`
+	UsageLimit       int        `json:"usage_limit"`
+	PerCustomerLimit int        `json:"per_customer_limit"`
+	Active           bool       `json:"active"`
+	CreatedAt        time.Time  `json:"created_at"`
+	TotalCodes       int        `json:"total_codes"`
+	TotalRedeemed    int        `json:"total_redeemed"`
+	Vouchers         []Voucher  `json:"vouchers,omitempty"`
+}
+
+type Voucher struct {
+	ID        int    `json:"id"`
+	BatchID   int    `json:"batch_id"`
+	Code      string `json:"code"`
+	UsedCount int    `json:"used_count"`
+}
+
+// VoucherBatchInput dipakai untuk membuat batch. Jika Codes diisi, kode tersebut dipakai apa adanya,
+// jika tidak, sejumlah Quantity kode acak dibuat dengan awalan Prefix.
+type VoucherBatchInput struct {
+	VoucherBatch
+	Quantity int      `json:"quantity"`
+	Prefix   string   `json:"prefix"`
+	Codes    []string `json:"codes"`
+}
+
+// VoucherStatus adalah hasil pengecekan satu kode voucher sebelum checkout
+type VoucherStatus struct {
+	Code      string       `json:"code"`
+	UsedCount int          `json:"used_count"`
+	Batch     VoucherBatch `json:"batch"`
+	Valid     bool         `json:"valid"`
+	Reason    string       `json:"reason,omitempty"`
+}
@@ -0,0 +1,142 @@
+// Package pdf adalah penulis PDF minimal yang hanya memakai font standar PDF
+// (tanpa embedding) dan bentuk persegi, cukup untuk struk dan label harga.
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// Font adalah salah satu font standar PDF yang selalu tersedia di viewer
+type Font string
+
+const (
+	Helvetica     Font = "Helvetica"
+	HelveticaBold Font = "Helvetica-Bold"
+	Courier       Font = "Courier"
+	CourierBold   Font = "Courier-Bold"
+)
+
+var fonts = []Font{Helvetica, HelveticaBold, Courier, CourierBold}
+
+// ukuran kertas dalam point (1 mm = 72/25.4 pt)
+const (
+	MM = 72 / 25.4
+
+	A4Width  = 210 * MM
+	A4Height = 297 * MM
+	A6Width  = 105 * MM
+	A6Height = 148 * MM
+)
+
+type Document struct {
+	pages []*Page
+}
+
+// Page adalah satu halaman. Koordinat dalam point dengan titik (0,0) di kiri bawah.
+type Page struct {
+	Width, Height float64
+	content       bytes.Buffer
+}
+
+func New() *Document {
+	return &Document{}
+}
+
+func (d *Document) AddPage(width, height float64) *Page {
+	p := &Page{Width: width, Height: height}
+	d.pages = append(d.pages, p)
+	return p
+}
+
+// Text menulis teks dengan baseline di (x, y)
+func (p *Page) Text(x, y float64, font Font, size float64, text string) {
+	fmt.Fprintf(&p.content, "BT /F%d %.2f Tf %.2f %.2f Td (%s) Tj ET\n", fontIndex(font), size, x, y, escape(text))
+}
+
+// Rect menggambar persegi hitam terisi dengan sudut kiri bawah di (x, y)
+func (p *Page) Rect(x, y, width, height float64) {
+	fmt.Fprintf(&p.content, "%.3f %.3f %.3f %.3f re f\n", x, y, width, height)
+}
+
+// StrokeRect menggambar garis tepi persegi
+func (p *Page) StrokeRect(x, y, width, height, lineWidth float64) {
+	fmt.Fprintf(&p.content, "%.2f w %.3f %.3f %.3f %.3f re S\n", lineWidth, x, y, width, height)
+}
+
+// Bytes menghasilkan file PDF lengkap
+func (d *Document) Bytes() []byte {
+	var out bytes.Buffer
+	offsets := make([]int, 0)
+	object := func(body string) {
+		offsets = append(offsets, out.Len())
+		fmt.Fprintf(&out, "%d 0 obj\n%s\nendobj\n", len(offsets), body)
+	}
+
+	out.WriteString("%PDF-1.4\n")
+
+	// nomor objek: 1 catalog, 2 pages, 3.. font, lalu pasangan page + content
+	fontStart := 3
+	pageStart := fontStart + len(fonts)
+
+	object("<< /Type /Catalog /Pages 2 0 R >>")
+
+	kids := make([]string, len(d.pages))
+	for i := range d.pages {
+		kids[i] = fmt.Sprintf("%d 0 R", pageStart+i*2)
+	}
+	object(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(d.pages)))
+
+	fontRefs := make([]string, len(fonts))
+	for i, f := range fonts {
+		object(fmt.Sprintf("<< /Type /Font /Subtype /Type1 /BaseFont /%s /Encoding /WinAnsiEncoding >>", f))
+		fontRefs[i] = fmt.Sprintf("/F%d %d 0 R", i, fontStart+i)
+	}
+	resources := fmt.Sprintf("<< /Font << %s >> >>", strings.Join(fontRefs, " "))
+
+	for i, p := range d.pages {
+		object(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %.2f %.2f] /Resources %s /Contents %d 0 R >>",
+			p.Width, p.Height, resources, pageStart+i*2+1))
+		object(fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", p.content.Len(), p.content.String()))
+	}
+
+	xref := out.Len()
+	fmt.Fprintf(&out, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&out, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&out, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+
+	return out.Bytes()
+}
+
+func fontIndex(font Font) int {
+	for i, f := range fonts {
+		if f == font {
+			return i
+		}
+	}
+	return 0
+}
+
+// escape meng-escape karakter khusus string PDF dan mengganti karakter di luar Latin-1 dengan "?"
+func escape(text string) string {
+	var b strings.Builder
+	for _, r := range text {
+		switch {
+		case r == '(' || r == ')' || r == '\\':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r < 32:
+			b.WriteByte(' ')
+		case r < 128:
+			b.WriteRune(r)
+		case r < 256:
+			fmt.Fprintf(&b, "\\%03o", r)
+		default:
+			b.WriteByte('?')
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,77 @@
+package receipt
+
+import (
+	"bytes"
+	"kasir-api/models"
+)
+
+// perintah ESC/POS
+var (
+	escInit        = []byte{0x1b, '@'}
+	escAlignLeft   = []byte{0x1b, 'a', 0}
+	escAlignCenter = []byte{0x1b, 'a', 1}
+	escBoldOn      = []byte{0x1b, 'E', 1}
+	escBoldOff     = []byte{0x1b, 'E', 0}
+	escDoubleSize  = []byte{0x1d, '!', 0x11}
+	escNormalSize  = []byte{0x1d, '!', 0x00}
+	escFeedAndCut  = []byte{0x1d, 'V', 66, 3}
+	escOpenDrawer  = []byte{0x1b, 'p', 0, 25, 250}
+)
+
+// ESCPOS merender struk sebagai perintah ESC/POS untuk printer thermal 58 atau 80 mm.
+// Laci kas dibuka jika ada pembayaran tunai.
+func ESCPOS(t *models.Transaction, cfg Config, width int) []byte {
+	cols := Columns(width)
+
+	var b bytes.Buffer
+	b.Write(escInit)
+	for _, l := range layout(t, cfg, cols) {
+		if l.align == alignCenter {
+			b.Write(escAlignCenter)
+		}
+		if l.bold {
+			b.Write(escBoldOn)
+		}
+		if l.large {
+			b.Write(escDoubleSize)
+		}
+
+		// teks sudah diratakan oleh printer, jadi spasi padding tidak perlu dikirim
+		b.WriteString(ascii(l.text))
+		b.WriteByte('\n')
+
+		if l.large {
+			b.Write(escNormalSize)
+		}
+		if l.bold {
+			b.Write(escBoldOff)
+		}
+		if l.align == alignCenter {
+			b.Write(escAlignLeft)
+		}
+	}
+	b.WriteString("\n\n")
+	b.Write(escFeedAndCut)
+
+	for _, p := range t.Payments {
+		if p.Method == models.PaymentMethodCash {
+			b.Write(escOpenDrawer)
+			break
+		}
+	}
+
+	return b.Bytes()
+}
+
+// ascii mengganti karakter di luar ASCII karena code page printer berbeda-beda
+func ascii(s string) string {
+	out := make([]byte, 0, len(s))
+	for _, r := range s {
+		if r >= 32 && r < 127 {
+			out = append(out, byte(r))
+		} else {
+			out = append(out, '?')
+		}
+	}
+	return string(out)
+}
@@ -0,0 +1,48 @@
+package receipt
+
+import (
+	"kasir-api/models"
+	"kasir-api/pdf"
+	"math"
+)
+
+// ukuran kertas PDF
+const (
+	SizeA4 = "a4"
+	SizeA6 = "a6"
+)
+
+// PDF merender struk ke PDF ukuran A4 atau A6 dengan font monospace agar layout sama dengan struk thermal
+func PDF(t *models.Transaction, cfg Config, width int, size string) []byte {
+	pageWidth, pageHeight := pdf.A6Width, pdf.A6Height
+	if size == SizeA4 {
+		pageWidth, pageHeight = pdf.A4Width, pdf.A4Height
+	}
+
+	cols := Columns(width)
+	margin := 8 * pdf.MM
+
+	// Courier lebarnya 0.6 x ukuran font per karakter
+	fontSize := math.Min(10, (pageWidth-2*margin)/(float64(cols)*0.6))
+	lineHeight := fontSize * 1.25
+	x := (pageWidth - float64(cols)*0.6*fontSize) / 2
+
+	doc := pdf.New()
+	var page *pdf.Page
+	y := 0.0
+	for _, l := range layout(t, cfg, cols) {
+		if page == nil || y < margin {
+			page = doc.AddPage(pageWidth, pageHeight)
+			y = pageHeight - margin - fontSize
+		}
+
+		font := pdf.Courier
+		if l.bold {
+			font = pdf.CourierBold
+		}
+		page.Text(x, y, font, fontSize, l.pad(cols))
+		y -= lineHeight
+	}
+
+	return doc.Bytes()
+}
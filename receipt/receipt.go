@@ -0,0 +1,220 @@
+// Package receipt menyusun layout struk transaksi dan merendernya ke teks biasa,
+// perintah ESC/POS untuk printer thermal, dan PDF. Ketiga format memakai layout
+// yang sama sehingga semua kasir mencetak struk yang identik.
+package receipt
+
+import (
+	"fmt"
+	"kasir-api/format"
+	"kasir-api/models"
+	"strings"
+	"time"
+)
+
+// lebar kertas thermal yang didukung (mm) dan jumlah karakter per baris dengan font A
+const (
+	Width58 = 58
+	Width80 = 80
+)
+
+// Config berisi header dan footer toko yang dicetak di setiap struk
+type Config struct {
+	Header   []string
+	Footer   []string
+	Location *time.Location
+}
+
+// Columns mengembalikan jumlah karakter per baris untuk lebar kertas
+func Columns(width int) int {
+	if width == Width80 {
+		return 48
+	}
+	return 32
+}
+
+type align int
+
+const (
+	alignLeft align = iota
+	alignCenter
+)
+
+type line struct {
+	text  string
+	align align
+	bold  bool
+	large bool
+}
+
+// layout menyusun baris-baris struk dengan lebar cols karakter
+func layout(t *models.Transaction, cfg Config, cols int) []line {
+	var lines []line
+	separator := line{text: strings.Repeat("-", cols)}
+
+	for i, h := range cfg.Header {
+		if i == 0 {
+			// baris pertama dicetak dua kali lebar sehingga hanya muat setengah kolom
+			lines = append(lines, line{text: truncate(h, cols/2), align: alignCenter, bold: true, large: true})
+			continue
+		}
+		lines = append(lines, line{text: truncate(h, cols), align: alignCenter})
+	}
+	if len(cfg.Header) > 0 {
+		lines = append(lines, separator)
+	}
+
+	loc := cfg.Location
+	if loc == nil {
+		loc = time.Local
+	}
+	lines = append(lines, line{text: twoColumns(fmt.Sprintf("No. %d", t.ID), t.CreatedAt.In(loc).Format("02/01/2006 15:04"), cols)})
+	if t.Customer != "" {
+		lines = append(lines, line{text: truncate("Pelanggan: "+t.Customer, cols)})
+	}
+	lines = append(lines, separator)
+
+	for _, d := range t.Details {
+		name := d.ProductName
+		if name == "" {
+			name = fmt.Sprintf("Produk #%d", d.ProductID)
+		}
+		for _, w := range wrap(name, cols) {
+			lines = append(lines, line{text: w})
+		}
+		qty := fmt.Sprintf("  %d x %s", d.Quantity, format.Number(d.UnitPrice))
+		if d.Unit != "" {
+			qty = fmt.Sprintf("  %d %s x %s", d.Quantity, d.Unit, format.Number(d.UnitPrice))
+		}
+		lines = append(lines, line{text: twoColumns(qty, format.Number(d.UnitPrice*d.Quantity), cols)})
+		if d.PromotionDiscount > 0 {
+			lines = append(lines, line{text: twoColumns("  Promo", format.Number(-d.PromotionDiscount), cols)})
+		}
+		if d.DiscountAmount > 0 {
+			lines = append(lines, line{text: twoColumns("  Diskon", format.Number(-d.DiscountAmount), cols)})
+		}
+		if d.RefundedQuantity > 0 {
+			lines = append(lines, line{text: fmt.Sprintf("  (retur %d)", d.RefundedQuantity)})
+		}
+	}
+	lines = append(lines, separator)
+
+	lines = append(lines, line{text: twoColumns("Subtotal", format.Number(t.GrossAmount-t.PromotionDiscountAmount-t.LineDiscountAmount), cols)})
+	if t.DiscountAmount > 0 {
+		lines = append(lines, line{text: twoColumns("Diskon", format.Number(-t.DiscountAmount), cols)})
+	}
+	if t.VoucherDiscountAmount > 0 {
+		lines = append(lines, line{text: twoColumns(truncate("Voucher "+t.VoucherCode, cols-12), format.Number(-t.VoucherDiscountAmount), cols)})
+	}
+	if t.ServiceChargeAmount > 0 {
+		lines = append(lines, line{text: twoColumns("Service charge", format.Number(t.ServiceChargeAmount), cols)})
+	}
+	if t.TaxAmount > 0 {
+		label := "Pajak"
+		if t.TaxMode == models.TaxModeInclusive {
+			label = "Pajak (termasuk)"
+		}
+		lines = append(lines, line{text: twoColumns(label, format.Number(t.TaxAmount), cols)})
+	}
+	lines = append(lines, line{text: twoColumns("TOTAL", format.Number(t.TotalAmount), cols), bold: true})
+	lines = append(lines, separator)
+
+	for _, p := range t.Payments {
+		lines = append(lines, line{text: twoColumns(paymentLabel(p.Method), format.Number(p.Amount), cols)})
+	}
+	lines = append(lines, line{text: twoColumns("Kembali", format.Number(t.ChangeAmount), cols)})
+
+	switch t.Status {
+	case models.TransactionStatusVoided:
+		lines = append(lines, separator, line{text: "*** TRANSAKSI DIBATALKAN ***", align: alignCenter, bold: true})
+	case models.TransactionStatusRefunded, models.TransactionStatusPartiallyRefunded:
+		lines = append(lines, separator, line{text: twoColumns("Retur", format.Number(-t.RefundedAmount), cols)})
+	}
+
+	if len(cfg.Footer) > 0 {
+		lines = append(lines, separator)
+		for _, f := range cfg.Footer {
+			for _, w := range wrap(f, cols) {
+				lines = append(lines, line{text: w, align: alignCenter})
+			}
+		}
+	}
+
+	return lines
+}
+
+func paymentLabel(method string) string {
+	switch method {
+	case models.PaymentMethodCash:
+		return "Tunai"
+	case models.PaymentMethodDebit:
+		return "Debit"
+	case models.PaymentMethodQRIS:
+		return "QRIS"
+	case models.PaymentMethodEWallet:
+		return "E-Wallet"
+	case models.PaymentMethodTransfer:
+		return "Transfer"
+	}
+	return method
+}
+
+// twoColumns menaruh left rata kiri dan right rata kanan dalam satu baris
+func twoColumns(left, right string, cols int) string {
+	space := cols - len([]rune(left)) - len([]rune(right))
+	if space < 1 {
+		left = truncate(left, cols-len([]rune(right))-1)
+		space = 1
+	}
+	return left + strings.Repeat(" ", space) + right
+}
+
+func truncate(s string, n int) string {
+	r := []rune(s)
+	if n < 0 {
+		n = 0
+	}
+	if len(r) <= n {
+		return s
+	}
+	return string(r[:n])
+}
+
+// wrap memecah teks per kata agar muat dalam cols karakter
+func wrap(s string, cols int) []string {
+	var out []string
+	current := ""
+	for _, word := range strings.Fields(s) {
+		for len([]rune(word)) > cols {
+			if current != "" {
+				out = append(out, current)
+				current = ""
+			}
+			out = append(out, string([]rune(word)[:cols]))
+			word = string([]rune(word)[cols:])
+		}
+		switch {
+		case current == "":
+			current = word
+		case len([]rune(current))+1+len([]rune(word)) <= cols:
+			current += " " + word
+		default:
+			out = append(out, current)
+			current = word
+		}
+	}
+	if current != "" {
+		out = append(out, current)
+	}
+	return out
+}
+
+// pad mengembalikan teks baris yang sudah diratakan sesuai align
+func (l line) pad(cols int) string {
+	if l.align == alignCenter {
+		n := len([]rune(l.text))
+		if n < cols {
+			return strings.Repeat(" ", (cols-n)/2) + l.text
+		}
+	}
+	return l.text
+}
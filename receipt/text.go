@@ -0,0 +1,18 @@
+package receipt
+
+import (
+	"kasir-api/models"
+	"strings"
+)
+
+// Text merender struk sebagai teks biasa dengan lebar kertas 58 atau 80 mm
+func Text(t *models.Transaction, cfg Config, width int) []byte {
+	cols := Columns(width)
+
+	var b strings.Builder
+	for _, l := range layout(t, cfg, cols) {
+		b.WriteString(strings.TrimRight(l.pad(cols), " "))
+		b.WriteByte('\n')
+	}
+	return []byte(b.String())
+}
@@ -0,0 +1,421 @@
+package repositories
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"kasir-api/models"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+type CartRepository struct {
+	db        *sql.DB
+	taxConfig models.TaxConfig
+	location  *time.Location
+}
+
+// location adalah zona waktu toko, dipakai untuk jam promo
+func NewCartRepository(db *sql.DB, taxConfig models.TaxConfig, location *time.Location) *CartRepository {
+	return &CartRepository{db: db, taxConfig: taxConfig, location: location}
+}
+
+// GetAll mengembalikan cart dengan status tertentu (kosong berarti semua) di outlet outletID (0 berarti semua outlet)
+func (repo *CartRepository) GetAll(status string, outletID int) ([]models.Cart, error) {
+	query := "SELECT id FROM carts WHERE ($1 = '' OR status = $1) AND ($2::bigint = 0 OR outlet_id = $2) ORDER BY id DESC"
+
+	rows, err := repo.db.Query(query, status, outletID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ids := make([]int, 0)
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	carts := make([]models.Cart, 0, len(ids))
+	for _, id := range ids {
+		cart, err := repo.GetByID(id)
+		if err != nil {
+			return nil, err
+		}
+		carts = append(carts, *cart)
+	}
+
+	return carts, nil
+}
+
+// GetByID mengambil cart beserta item dan total yang dihitung dari harga produk saat ini
+func (repo *CartRepository) GetByID(id int) (*models.Cart, error) {
+	var c models.Cart
+	var name, customer sql.NullString
+	err := repo.db.QueryRow("SELECT id, coalesce(outlet_id, 0), name, customer, status, reserve_stock, transaction_id, created_at, updated_at FROM carts WHERE id = $1", id).
+		Scan(&c.ID, &c.OutletID, &name, &customer, &c.Status, &c.ReserveStock, &c.TransactionID, &c.CreatedAt, &c.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return nil, errors.New("cart not found")
+	}
+	if err != nil {
+		return nil, err
+	}
+	c.Name, c.Customer = name.String, customer.String
+
+	rows, err := repo.db.Query(`
+		SELECT ci.product_id, p.parent_id, p.name, ci.unit, coalesce(u.price, p.price),
+			coalesce((SELECT min(coalesce(cs.stock, 0) / pc.quantity) FROM product_components pc
+				LEFT JOIN outlet_stocks cs ON cs.product_id = pc.component_id AND cs.outlet_id = $2 WHERE pc.product_id = p.id), os.stock, 0),
+			p.category_id, c.tax_rate, coalesce(c.tax_exempt, false), ci.quantity
+		FROM cart_items ci
+		JOIN products p ON ci.product_id = p.id
+		LEFT JOIN product_units u ON u.product_id = p.id AND u.name = ci.unit
+		LEFT JOIN categories c ON p.category_id = c.id
+		LEFT JOIN outlet_stocks os ON os.product_id = p.id AND os.outlet_id = $2
+		WHERE ci.cart_id = $1
+		ORDER BY ci.created_at, ci.product_id, ci.unit`, id, c.OutletID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	c.Items = make([]models.CartItem, 0)
+	promoLines := make([]promoLine, 0)
+	taxRates := make([]float64, 0)
+	for rows.Next() {
+		var item models.CartItem
+		var parentID *int
+		var categoryID int
+		var taxRate *float64
+		var taxExempt bool
+		err := rows.Scan(&item.ProductID, &parentID, &item.ProductName, &item.Unit, &item.UnitPrice, &item.Stock, &categoryID, &taxRate, &taxExempt, &item.Quantity)
+		if err != nil {
+			return nil, err
+		}
+		c.Items = append(c.Items, item)
+		promoLines = append(promoLines, promoLine{productID: item.ProductID, parentID: parentID, categoryID: categoryID, unitPrice: item.UnitPrice, quantity: item.Quantity})
+		taxRates = append(taxRates, repo.taxConfig.RateFor(taxRate, taxExempt))
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	rows.Close()
+
+	// hitung total dengan promo otomatis dan pajak yang sama seperti saat checkout
+	now := time.Now().In(repo.location)
+	promotions, err := loadActivePromotions(repo.db, now)
+	if err != nil {
+		return nil, err
+	}
+	evaluatePromotions(promotions, promoLines, now)
+
+	taxLines := make([]taxLine, len(c.Items))
+	for i := range c.Items {
+		item := &c.Items[i]
+		item.PromotionDiscount = promoLines[i].discount
+		item.Subtotal = item.UnitPrice*item.Quantity - item.PromotionDiscount
+		c.GrossAmount += item.UnitPrice * item.Quantity
+		c.PromotionDiscountAmount += item.PromotionDiscount
+		taxLines[i] = taxLine{net: item.Subtotal, rate: taxRates[i]}
+	}
+	taxConfig, err := outletTaxConfig(repo.db, repo.taxConfig, c.OutletID)
+	if err != nil {
+		return nil, err
+	}
+	for _, t := range calculateTaxes(taxConfig, taxLines) {
+		c.TaxAmount += t.tax
+		c.ServiceChargeAmount += t.serviceCharge
+		c.TotalAmount += t.total
+	}
+
+	return &c, nil
+}
+
+func (repo *CartRepository) Create(input *models.CartInput) (*models.Cart, error) {
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	outletID, err := resolveOutlet(tx, input.OutletID)
+	if err != nil {
+		return nil, err
+	}
+
+	var id int
+	err = tx.QueryRow("INSERT INTO carts (outlet_id, name, customer, reserve_stock) VALUES ($1, $2, $3, $4) RETURNING id", outletID, input.Name, input.Customer, input.ReserveStock).Scan(&id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := resolveItemBarcodes(tx, input.Items); err != nil {
+		return nil, err
+	}
+	for _, item := range input.Items {
+		if err := setCartItem(tx, id, item.ProductID, item.Unit, item.Quantity, true); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return repo.GetByID(id)
+}
+
+// AddItem menambah quantity produk di cart. Satuan berbeda dari produk yang sama menjadi item terpisah.
+func (repo *CartRepository) AddItem(cartID int, input *models.CartItemInput) (*models.Cart, error) {
+	return repo.updateItem(cartID, input.ProductID, input.Unit, input.Quantity, true)
+}
+
+// SetItem mengganti quantity produk di cart, quantity 0 menghapus item
+func (repo *CartRepository) SetItem(cartID int, input *models.CartItemInput) (*models.Cart, error) {
+	return repo.updateItem(cartID, input.ProductID, input.Unit, input.Quantity, false)
+}
+
+func (repo *CartRepository) RemoveItem(cartID, productID int, unit string) (*models.Cart, error) {
+	return repo.updateItem(cartID, productID, unit, 0, false)
+}
+
+func (repo *CartRepository) updateItem(cartID, productID int, unit string, quantity int, increment bool) (*models.Cart, error) {
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if err := lockOpenCart(tx, cartID); err != nil {
+		return nil, err
+	}
+	if err := setCartItem(tx, cartID, productID, unit, quantity, increment); err != nil {
+		return nil, err
+	}
+	if _, err := tx.Exec("UPDATE carts SET updated_at = now() WHERE id = $1", cartID); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return repo.GetByID(cartID)
+}
+
+// Cancel membatalkan cart yang masih open dan melepas reservasi stoknya
+func (repo *CartRepository) Cancel(id int) error {
+	result, err := repo.db.Exec("UPDATE carts SET status = $1, updated_at = now() WHERE id = $2 AND status = $3", models.CartStatusCancelled, id, models.CartStatusOpen)
+	if err != nil {
+		return err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return errors.New("cart not found or not open")
+	}
+
+	return nil
+}
+
+// setCartItem menambah (increment) atau mengganti quantity item. Untuk cart yang mereservasi stok,
+// quantity dicek terhadap stok outlet cart dikurangi reservasi cart lain di outlet yang sama.
+// unit kosong berarti satuan dasar; quantity dalam satuan unit dan reservasi dihitung dalam satuan dasar.
+func setCartItem(tx *sql.Tx, cartID, productID int, unit string, quantity int, increment bool) error {
+	if quantity < 0 || (increment && quantity == 0) {
+		return fmt.Errorf("quantity for product id %d must be greater than 0", productID)
+	}
+
+	var current int
+	err := tx.QueryRow("SELECT quantity FROM cart_items WHERE cart_id = $1 AND product_id = $2 AND unit = $3", cartID, productID, unit).Scan(&current)
+	if err != nil && err != sql.ErrNoRows {
+		return err
+	}
+	if increment {
+		quantity += current
+	}
+
+	if quantity == 0 {
+		_, err := tx.Exec("DELETE FROM cart_items WHERE cart_id = $1 AND product_id = $2 AND unit = $3", cartID, productID, unit)
+		return err
+	}
+
+	var outletID int
+	var reserve, hasVariants bool
+	err = tx.QueryRow(`SELECT c.reserve_stock, c.outlet_id, EXISTS (SELECT 1 FROM products v WHERE v.parent_id = p.id)
+		FROM products p JOIN carts c ON c.id = $2
+		WHERE p.id = $1 FOR UPDATE OF p`, productID, cartID).Scan(&reserve, &outletID, &hasVariants)
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("product id %d not found", productID)
+	}
+	if err != nil {
+		return err
+	}
+	if hasVariants {
+		return fmt.Errorf("product id %d has variants, choose a variant", productID)
+	}
+
+	factor := 1
+	if unit != "" {
+		if factor, _, err = lookupUnit(tx, productID, unit); err != nil {
+			return err
+		}
+	}
+
+	if reserve {
+		if err := checkCartStock(tx, cartID, outletID, productID, unit, quantity*factor); err != nil {
+			return err
+		}
+	}
+
+	_, err = tx.Exec(`INSERT INTO cart_items (cart_id, product_id, unit, unit_factor, quantity) VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (cart_id, product_id, unit) DO UPDATE SET unit_factor = EXCLUDED.unit_factor, quantity = EXCLUDED.quantity`, cartID, productID, unit, factor, quantity)
+	return err
+}
+
+// checkCartStock memastikan stok outlet cukup untuk quantity (satuan dasar) item setelah dikurangi reservasi
+// cart lain dan item lain di cart ini. Produk komposisi dicek terhadap stok bahan-bahannya.
+func checkCartStock(tx *sql.Tx, cartID, outletID, productID int, unit string, quantity int) error {
+	needs, err := stockNeeds(tx, productID, quantity)
+	if err != nil {
+		return err
+	}
+	ids := make([]int, 0, len(needs))
+	for id := range needs {
+		ids = append(ids, id)
+	}
+
+	reserved, err := reservedStock(tx, outletID, ids, cartID)
+	if err != nil {
+		return err
+	}
+
+	// item lain di cart ini (produk yang sama dengan satuan lain, atau produk lain dengan bahan yang sama) ikut memakai stok
+	rows, err := tx.Query(`SELECT s.product_id, sum(s.quantity) FROM (`+cartItemStock+`) s
+		WHERE s.cart_id = $1 AND s.product_id = ANY($2) AND NOT (s.item_product_id = $3 AND s.unit = $4)
+		GROUP BY s.product_id`, cartID, pq.Array(ids), productID, unit)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	used := make(map[int]int)
+	for rows.Next() {
+		var id, qty int
+		if err := rows.Scan(&id, &qty); err != nil {
+			return err
+		}
+		used[id] = qty
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	rows.Close()
+
+	for id, need := range needs {
+		stock, err := lockProductStock(tx, outletID, id)
+		if err != nil {
+			return err
+		}
+		if need > stock-reserved[id]-used[id] {
+			return fmt.Errorf("insufficient stock for product id %d", id)
+		}
+	}
+	return nil
+}
+
+// lockOpenCart mengunci cart dan memastikan statusnya masih open
+func lockOpenCart(tx *sql.Tx, cartID int) error {
+	var status string
+	err := tx.QueryRow("SELECT status FROM carts WHERE id = $1 FOR UPDATE", cartID).Scan(&status)
+	if err == sql.ErrNoRows {
+		return errors.New("cart not found")
+	}
+	if err != nil {
+		return err
+	}
+	if status != models.CartStatusOpen {
+		return fmt.Errorf("cart is already %s", status)
+	}
+	return nil
+}
+
+// loadCartCheckout mengunci cart req.CartID dan mengisi item, outlet dan customer (jika kosong) request checkout dari cart
+func loadCartCheckout(tx *sql.Tx, req *models.CheckoutRequest) error {
+	if err := lockOpenCart(tx, req.CartID); err != nil {
+		return err
+	}
+
+	var outletID int
+	var customer sql.NullString
+	if err := tx.QueryRow("SELECT coalesce(outlet_id, 0), customer FROM carts WHERE id = $1", req.CartID).Scan(&outletID, &customer); err != nil {
+		return err
+	}
+	req.OutletID = outletID
+	if req.Customer == "" {
+		req.Customer = customer.String
+	}
+
+	rows, err := tx.Query("SELECT product_id, unit, quantity FROM cart_items WHERE cart_id = $1 ORDER BY created_at, product_id, unit", req.CartID)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	req.Items = make([]models.CheckoutItem, 0)
+	for rows.Next() {
+		var item models.CheckoutItem
+		if err := rows.Scan(&item.ProductID, &item.Unit, &item.Quantity); err != nil {
+			return err
+		}
+		req.Items = append(req.Items, item)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	if len(req.Items) == 0 {
+		return errors.New("cart is empty")
+	}
+	return nil
+}
+
+// cartItemStock menjabarkan item cart menjadi pemakaian stok per produk dalam satuan dasar.
+// Item produk komposisi memakai stok bahan-bahannya.
+const cartItemStock = `SELECT ci.cart_id, ci.product_id AS item_product_id, ci.unit, coalesce(pc.component_id, ci.product_id) AS product_id,
+		ci.quantity * ci.unit_factor * coalesce(pc.quantity, 1) AS quantity
+	FROM cart_items ci LEFT JOIN product_components pc ON pc.product_id = ci.product_id`
+
+// reservedStock menghitung quantity (satuan dasar) yang direservasi cart open lain di outlet per produk
+func reservedStock(q queryer, outletID int, productIDs []int, excludeCartID int) (map[int]int, error) {
+	rows, err := q.Query(`
+		SELECT s.product_id, sum(s.quantity)
+		FROM (`+cartItemStock+`) s
+		JOIN carts c ON s.cart_id = c.id
+		WHERE c.status = 'open' AND c.reserve_stock AND c.id <> $1 AND s.product_id = ANY($2) AND c.outlet_id = $3
+		GROUP BY s.product_id`, excludeCartID, pq.Array(productIDs), outletID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	reserved := make(map[int]int)
+	for rows.Next() {
+		var productID, qty int
+		if err := rows.Scan(&productID, &qty); err != nil {
+			return nil, err
+		}
+		reserved[productID] = qty
+	}
+
+	return reserved, rows.Err()
+}
@@ -3,7 +3,10 @@ package repositories
 import (
 	"database/sql"
 	"errors"
+	"fmt"
 	"kasir-api/models"
+
+	"github.com/lib/pq"
 )
 
 type CategoryRepository struct {
@@ -14,37 +17,138 @@ func NewCategoryRepository(db *sql.DB) *CategoryRepository {
 	return &CategoryRepository{db: db}
 }
 
-func (repo *CategoryRepository) GetAll() ([]models.Category, error) {
-	query := "SELECT id, name, description FROM categories ORDER BY id"
-	rows, err := repo.db.Query(query)
+// categorySubtree adalah subquery id kategori $n beserta semua turunannya, dipakai dengan fmt.Sprintf
+const categorySubtree = `WITH RECURSIVE subtree AS (
+		SELECT id FROM categories WHERE id = $%[1]d
+		UNION ALL
+		SELECT c.id FROM categories c JOIN subtree s ON c.parent_id = s.id
+	) SELECT id FROM subtree`
+
+// inCategory membuat kondisi column termasuk kategori $n atau turunannya, $n = 0 berarti semua kategori
+func inCategory(column string, n int) string {
+	return fmt.Sprintf("($%d::bigint = 0 OR %s IN (%s))", n, column, fmt.Sprintf(categorySubtree, n))
+}
+
+// categoryPaths mengembalikan nama kategori dari akar sampai kategori itu sendiri untuk setiap id di ids
+func categoryPaths(q queryer, ids []int) (map[int][]string, error) {
+	paths := make(map[int][]string)
+	if len(ids) == 0 {
+		return paths, nil
+	}
+
+	rows, err := q.Query(`WITH RECURSIVE up AS (
+			SELECT id AS category_id, parent_id, coalesce(name, '') AS name, 0 AS depth FROM categories WHERE id = ANY($1)
+			UNION ALL
+			SELECT up.category_id, c.parent_id, coalesce(c.name, ''), up.depth + 1 FROM up JOIN categories c ON c.id = up.parent_id
+		) SELECT category_id, array_agg(name ORDER BY depth DESC) FROM up GROUP BY category_id`, pq.Array(ids))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id int
+		var path []string
+		if err := rows.Scan(&id, pq.Array(&path)); err != nil {
+			return nil, err
+		}
+		paths[id] = path
+	}
+	return paths, rows.Err()
+}
+
+// GetAll mengembalikan semua kategori, kategori yang diarsipkan hanya jika includeArchived
+func (repo *CategoryRepository) GetAll(includeArchived bool) ([]models.Category, error) {
+	query := "SELECT id, parent_id, name, description, tax_rate, tax_exempt, archived_at FROM categories WHERE $1 OR archived_at IS NULL ORDER BY id"
+	rows, err := repo.db.Query(query, includeArchived)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
 	categories := make([]models.Category, 0)
+	ids := make([]int, 0)
 	for rows.Next() {
 		var c models.Category
-		err := rows.Scan(&c.ID, &c.Name, &c.Description)
+		err := rows.Scan(&c.ID, &c.ParentID, &c.Name, &c.Description, &c.TaxRate, &c.TaxExempt, &c.ArchivedAt)
 		if err != nil {
 			return nil, err
 		}
 		categories = append(categories, c)
+		ids = append(ids, c.ID)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	paths, err := categoryPaths(repo.db, ids)
+	if err != nil {
+		return nil, err
+	}
+	for i := range categories {
+		categories[i].Path = paths[categories[i].ID]
 	}
 
 	return categories, nil
 }
 
+// GetTree mengembalikan kategori akar beserta turunannya di Children, urut berdasarkan id.
+// Turunan kategori yang diarsipkan selalu ikut diarsipkan, jadi pohon tanpa arsip tetap utuh.
+func (repo *CategoryRepository) GetTree(includeArchived bool) ([]models.Category, error) {
+	categories, err := repo.GetAll(includeArchived)
+	if err != nil {
+		return nil, err
+	}
+
+	children := make(map[int][]models.Category)
+	for _, c := range categories {
+		if c.ParentID != nil {
+			children[*c.ParentID] = append(children[*c.ParentID], c)
+		}
+	}
+
+	var build func(c models.Category) models.Category
+	build = func(c models.Category) models.Category {
+		for _, child := range children[c.ID] {
+			c.Children = append(c.Children, build(child))
+		}
+		return c
+	}
+
+	tree := make([]models.Category, 0)
+	for _, c := range categories {
+		if c.ParentID == nil {
+			tree = append(tree, build(c))
+		}
+	}
+	return tree, nil
+}
+
 func (repo *CategoryRepository) Create(category *models.Category) error {
-	query := "INSERT INTO categories (name, description) VALUES ($1, $2) RETURNING id"
-	err := repo.db.QueryRow(query, category.Name, category.Description).Scan(&category.ID)
-	return err
+	if category.ParentID != nil {
+		if err := checkActiveCategory(repo.db, *category.ParentID, "parent category"); err != nil {
+			return err
+		}
+	}
+
+	query := "INSERT INTO categories (parent_id, name, description, tax_rate, tax_exempt) VALUES ($1, $2, $3, $4, $5) RETURNING id"
+	err := repo.db.QueryRow(query, category.ParentID, category.Name, category.Description, category.TaxRate, category.TaxExempt).Scan(&category.ID)
+	if err != nil {
+		return err
+	}
+
+	paths, err := categoryPaths(repo.db, []int{category.ID})
+	if err != nil {
+		return err
+	}
+	category.Path = paths[category.ID]
+	return nil
 }
 
 func (repo *CategoryRepository) GetByID(id int) (*models.Category, error) {
-	query := "SELECT id, name, description FROM categories WHERE id = $1"
+	query := "SELECT id, parent_id, name, description, tax_rate, tax_exempt, archived_at FROM categories WHERE id = $1"
 	var c models.Category
-	err := repo.db.QueryRow(query, id).Scan(&c.ID, &c.Name, &c.Description)
+	err := repo.db.QueryRow(query, id).Scan(&c.ID, &c.ParentID, &c.Name, &c.Description, &c.TaxRate, &c.TaxExempt, &c.ArchivedAt)
 	if err == sql.ErrNoRows {
 		return nil, errors.New("category not found")
 	}
@@ -52,41 +156,205 @@ func (repo *CategoryRepository) GetByID(id int) (*models.Category, error) {
 		return nil, err
 	}
 
+	paths, err := categoryPaths(repo.db, []int{c.ID})
+	if err != nil {
+		return nil, err
+	}
+	c.Path = paths[c.ID]
+
 	return &c, nil
 }
 
+// Update mengubah kategori termasuk induknya. Kategori tidak boleh dipindah ke bawah dirinya sendiri
+// atau turunannya supaya hierarki tidak membentuk siklus, dan tidak bisa dipindah ke kategori yang diarsipkan.
 func (repo *CategoryRepository) Update(category *models.Category) error {
-	query := "UPDATE categories SET name = $1, description = $2 WHERE id = $3"
-	result, err := repo.db.Exec(query, category.Name, category.Description, category.ID)
+	tx, err := repo.db.Begin()
 	if err != nil {
 		return err
 	}
+	defer tx.Rollback()
+
+	if category.ParentID != nil {
+		// perubahan induk diserialkan supaya dua pemindahan bersamaan tidak bisa membentuk siklus
+		if _, err := tx.Exec("LOCK TABLE categories IN SHARE ROW EXCLUSIVE MODE"); err != nil {
+			return err
+		}
+	}
 
-	rows, err := result.RowsAffected()
+	var currentParent *int
+	err = tx.QueryRow("SELECT parent_id FROM categories WHERE id = $1 FOR UPDATE", category.ID).Scan(&currentParent)
+	if err == sql.ErrNoRows {
+		return errors.New("category not found")
+	}
 	if err != nil {
 		return err
 	}
-	if rows == 0 {
-		return errors.New("category not found")
+
+	if category.ParentID != nil && (currentParent == nil || *currentParent != *category.ParentID) {
+		if err := checkActiveCategory(tx, *category.ParentID, "parent category"); err != nil {
+			return err
+		}
+		if err := checkNotInSubtree(tx, *category.ParentID, category.ID); err != nil {
+			return errors.New("category cannot be moved under itself or its subcategory")
+		}
+	}
+
+	query := "UPDATE categories SET parent_id = $1, name = $2, description = $3, tax_rate = $4, tax_exempt = $5 WHERE id = $6 RETURNING archived_at"
+	err = tx.QueryRow(query, category.ParentID, category.Name, category.Description, category.TaxRate, category.TaxExempt, category.ID).Scan(&category.ArchivedAt)
+	if err != nil {
+		return err
 	}
 
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	paths, err := categoryPaths(repo.db, []int{category.ID})
+	if err != nil {
+		return err
+	}
+	category.Path = paths[category.ID]
 	return nil
 }
 
-func (repo *CategoryRepository) Delete(id int) error {
-	query := "DELETE FROM categories WHERE id = $1"
-	result, err := repo.db.Exec(query, id)
+// checkActiveCategory memastikan kategori ada dan tidak diarsipkan, what dipakai di pesan error.
+// Baris kategori dikunci supaya tidak diarsipkan atau dihapus sampai transaksi selesai.
+func checkActiveCategory(q queryer, id int, what string) error {
+	var archived bool
+	err := q.QueryRow("SELECT archived_at IS NOT NULL FROM categories WHERE id = $1 FOR SHARE", id).Scan(&archived)
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("%s not found", what)
+	}
 	if err != nil {
 		return err
 	}
+	if archived {
+		return fmt.Errorf("%s is archived", what)
+	}
+	return nil
+}
 
-	rows, err := result.RowsAffected()
-	if err != nil {
+// checkNotInSubtree mengembalikan error jika kategori id adalah rootID atau salah satu turunannya
+func checkNotInSubtree(q queryer, id, rootID int) error {
+	var inSubtree bool
+	if err := q.QueryRow("SELECT $1 IN ("+fmt.Sprintf(categorySubtree, 2)+")", id, rootID).Scan(&inSubtree); err != nil {
 		return err
 	}
-	if rows == 0 {
-		return errors.New("category not found")
+	if inSubtree {
+		return errors.New("category is inside the subtree")
+	}
+	return nil
+}
+
+// Delete menghapus kategori. Tanpa reassignTo, kategori yang masih punya produk atau sub-kategori ditolak.
+// Dengan reassignTo, produk dan sub-kategori langsungnya dipindah ke kategori reassignTo dalam transaksi yang sama,
+// jadi kategori tujuan tidak boleh diarsipkan atau berada di bawah kategori yang dihapus.
+// Mengembalikan jumlah produk yang dipindah.
+func (repo *CategoryRepository) Delete(id, reassignTo int) (int, error) {
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	if reassignTo != 0 {
+		// sub-kategori ikut pindah induk, diserialkan dengan perubahan hierarki lain
+		if _, err := tx.Exec("LOCK TABLE categories IN SHARE ROW EXCLUSIVE MODE"); err != nil {
+			return 0, err
+		}
+	}
+
+	var products int
+	var hasChildren bool
+	err = tx.QueryRow(`SELECT (SELECT count(*) FROM products WHERE category_id = c.id), EXISTS (SELECT 1 FROM categories s WHERE s.parent_id = c.id)
+		FROM categories c WHERE c.id = $1 FOR UPDATE`, id).Scan(&products, &hasChildren)
+	if err == sql.ErrNoRows {
+		return 0, errors.New("category not found")
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	if reassignTo == 0 {
+		if hasChildren {
+			return 0, errors.New("category has subcategories, move them first or use reassign_to")
+		}
+		if products > 0 {
+			return 0, fmt.Errorf("category still has %d products, move them with reassign_to or archive the category instead", products)
+		}
+	} else {
+		if err := checkActiveCategory(tx, reassignTo, "reassign_to category"); err != nil {
+			return 0, err
+		}
+		if err := checkNotInSubtree(tx, reassignTo, id); err != nil {
+			return 0, errors.New("reassign_to must not be the category itself or one of its subcategories")
+		}
+		// produk yang dibuat atau dipindah ke kategori ini di saat yang sama tertahan oleh kunci baris kategori di atas
+		result, err := tx.Exec("UPDATE products SET category_id = $1 WHERE category_id = $2", reassignTo, id)
+		if err != nil {
+			return 0, err
+		}
+		moved, err := result.RowsAffected()
+		if err != nil {
+			return 0, err
+		}
+		products = int(moved)
+		if _, err := tx.Exec("UPDATE categories SET parent_id = $1 WHERE parent_id = $2", reassignTo, id); err != nil {
+			return 0, err
+		}
 	}
 
-	return err
+	if _, err := tx.Exec("DELETE FROM categories WHERE id = $1", id); err != nil {
+		return 0, err
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	return products, nil
+}
+
+// Archive mengarsipkan kategori beserta semua turunannya. Produknya tetap di kategori itu dan tetap bisa dijual.
+func (repo *CategoryRepository) Archive(id int) (*models.Category, error) {
+	var archived bool
+	err := repo.db.QueryRow("SELECT archived_at IS NOT NULL FROM categories WHERE id = $1", id).Scan(&archived)
+	if err == sql.ErrNoRows {
+		return nil, errors.New("category not found")
+	}
+	if err != nil {
+		return nil, err
+	}
+	if archived {
+		return nil, errors.New("category is already archived")
+	}
+
+	_, err = repo.db.Exec("UPDATE categories SET archived_at = now() WHERE archived_at IS NULL AND id IN ("+fmt.Sprintf(categorySubtree, 1)+")", id)
+	if err != nil {
+		return nil, err
+	}
+	return repo.GetByID(id)
+}
+
+// Unarchive mengaktifkan lagi kategori beserta semua turunannya. Induknya harus aktif lebih dulu.
+func (repo *CategoryRepository) Unarchive(id int) (*models.Category, error) {
+	var archived, parentArchived bool
+	err := repo.db.QueryRow(`SELECT c.archived_at IS NOT NULL, coalesce(p.archived_at IS NOT NULL, false)
+		FROM categories c LEFT JOIN categories p ON c.parent_id = p.id WHERE c.id = $1`, id).Scan(&archived, &parentArchived)
+	if err == sql.ErrNoRows {
+		return nil, errors.New("category not found")
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !archived {
+		return nil, errors.New("category is not archived")
+	}
+	if parentArchived {
+		return nil, errors.New("parent category is archived, unarchive it first")
+	}
+
+	_, err = repo.db.Exec("UPDATE categories SET archived_at = NULL WHERE id IN ("+fmt.Sprintf(categorySubtree, 1)+")", id)
+	if err != nil {
+		return nil, err
+	}
+	return repo.GetByID(id)
 }
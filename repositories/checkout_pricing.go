@@ -0,0 +1,115 @@
+package repositories
+
+import (
+	"fmt"
+	"kasir-api/models"
+	"math"
+)
+
+// discountAmount menghitung nominal diskon terhadap amount. Diskon persen dibulatkan ke rupiah terdekat,
+// dan diskon tidak boleh melebihi amount yang didiskon.
+func discountAmount(amount int, discount *models.Discount) (int, error) {
+	if discount == nil {
+		return 0, nil
+	}
+	if discount.Value < 0 {
+		return 0, fmt.Errorf("discount value must not be negative")
+	}
+
+	var result int
+	switch discount.Type {
+	case models.DiscountTypePercent:
+		if discount.Value > 100 {
+			return 0, fmt.Errorf("percentage discount must not exceed 100")
+		}
+		result = int(math.Round(float64(amount) * discount.Value / 100))
+	case models.DiscountTypeFixed:
+		if discount.Value != math.Trunc(discount.Value) {
+			return 0, fmt.Errorf("fixed discount must be a whole rupiah amount")
+		}
+		result = int(discount.Value)
+	default:
+		return 0, fmt.Errorf("invalid discount type %q", discount.Type)
+	}
+
+	if result > amount {
+		return 0, fmt.Errorf("discount %d exceeds price %d", result, amount)
+	}
+	return result, nil
+}
+
+// allocateProportionally membagi total ke setiap bobot secara proporsional. Sisa pembulatan
+// diberikan ke bobot terbesar supaya jumlah hasil alokasi selalu sama dengan total.
+func allocateProportionally(total int, weights []int) []int {
+	result := make([]int, len(weights))
+	sum, largest := 0, -1
+	for i, w := range weights {
+		sum += w
+		if largest == -1 || w > weights[largest] {
+			largest = i
+		}
+	}
+	if sum == 0 || total == 0 {
+		return result
+	}
+
+	allocated := 0
+	for i, w := range weights {
+		result[i] = total * w / sum
+		allocated += result[i]
+	}
+	result[largest] += total - allocated
+
+	return result
+}
+
+type taxLine struct {
+	net  int // nominal baris setelah semua diskon
+	rate float64
+}
+
+type taxResult struct {
+	taxable       int // dasar pengenaan pajak (DPP)
+	tax           int
+	serviceCharge int
+	total         int // nominal yang dibayar pelanggan untuk baris ini
+}
+
+// calculateTaxes menghitung pajak dan service charge per baris. Pada mode inclusive pajak diambil dari
+// harga, pada mode exclusive pajak ditambahkan ke harga. Service charge dihitung dari nominal sebelum
+// pajak dan, jika ServiceChargeTaxable, ikut dikenakan pajak dengan tarif baris yang sama.
+func calculateTaxes(config models.TaxConfig, lines []taxLine) []taxResult {
+	results := make([]taxResult, len(lines))
+	bases := make([]int, len(lines))
+	itemTaxes := make([]int, len(lines))
+	serviceBase := 0
+	for i, line := range lines {
+		if config.Mode == models.TaxModeInclusive {
+			itemTaxes[i] = int(math.Round(float64(line.net) * line.rate / (100 + line.rate)))
+			bases[i] = line.net - itemTaxes[i]
+		} else {
+			bases[i] = line.net
+			itemTaxes[i] = int(math.Round(float64(line.net) * line.rate / 100))
+		}
+		serviceBase += bases[i]
+	}
+
+	serviceCharge := int(math.Round(float64(serviceBase) * config.ServiceChargeRate / 100))
+	shares := allocateProportionally(serviceCharge, bases)
+
+	for i, line := range lines {
+		r := taxResult{taxable: bases[i], tax: itemTaxes[i], serviceCharge: shares[i]}
+		if config.ServiceChargeTaxable && shares[i] > 0 {
+			r.taxable += shares[i]
+			r.tax += int(math.Round(float64(shares[i]) * line.rate / 100))
+		}
+
+		r.total = line.net + r.serviceCharge + (r.tax - itemTaxes[i])
+		if config.Mode != models.TaxModeInclusive {
+			r.total += itemTaxes[i]
+		}
+		results[i] = r
+	}
+
+	return results
+}
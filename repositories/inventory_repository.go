@@ -0,0 +1,116 @@
+package repositories
+
+import (
+	"database/sql"
+	"kasir-api/models"
+
+	"github.com/lib/pq"
+)
+
+type InventoryRepository struct {
+	db *sql.DB
+}
+
+func NewInventoryRepository(db *sql.DB) *InventoryRepository {
+	return &InventoryRepository{db: db}
+}
+
+// lowStockColumns mengambil data produk beserta quantity yang masih ditunggu dari PO
+const lowStockColumns = `p.id, p.name, coalesce(c.name, ''), coalesce(p.stock, 0), p.reorder_point, p.reorder_quantity,
+	coalesce((SELECT sum((l.quantity - l.received_quantity) * l.unit_factor) FROM purchase_order_lines l JOIN purchase_orders po ON l.purchase_order_id = po.id
+		WHERE l.product_id = p.id AND po.status IN ('ordered', 'partially_received')), 0)`
+
+// GetLowStock mengembalikan semua produk yang stoknya sudah mencapai reorder point, paling kritis di atas.
+// outletID 0 memakai total stok semua outlet, selain itu stok dan PO outlet tersebut.
+func (repo *InventoryRepository) GetLowStock(outletID int) ([]models.LowStockItem, error) {
+	if outletID == 0 {
+		return repo.queryLowStock(`SELECT ` + lowStockColumns + `
+			FROM products p LEFT JOIN categories c ON p.category_id = c.id
+			WHERE p.reorder_point IS NOT NULL AND coalesce(p.stock, 0) <= p.reorder_point
+			ORDER BY coalesce(p.stock, 0) - p.reorder_point, p.name`)
+	}
+
+	return repo.queryLowStock(`SELECT p.id, p.name, coalesce(c.name, ''), coalesce(os.stock, 0), p.reorder_point, p.reorder_quantity,
+			coalesce((SELECT sum((l.quantity - l.received_quantity) * l.unit_factor) FROM purchase_order_lines l JOIN purchase_orders po ON l.purchase_order_id = po.id
+				WHERE l.product_id = p.id AND po.outlet_id = $1 AND po.status IN ('ordered', 'partially_received')), 0)
+		FROM products p LEFT JOIN categories c ON p.category_id = c.id
+		LEFT JOIN outlet_stocks os ON os.product_id = p.id AND os.outlet_id = $1
+		WHERE p.reorder_point IS NOT NULL AND coalesce(os.stock, 0) <= p.reorder_point
+		ORDER BY coalesce(os.stock, 0) - p.reorder_point, p.name`, outletID)
+}
+
+// ClaimLowStockAlerts menandai stok outlet yang menipis dan belum diperingatkan, lalu mengembalikannya.
+// Reorder point dibandingkan dengan stok tiap outlet, bukan total semua outlet, sehingga outlet yang kehabisan
+// tetap diperingatkan walaupun outlet lain masih punya stok. Penandaan dilakukan dengan satu UPDATE sehingga
+// setiap produk hanya diperingatkan sekali per outlet sampai stoknya kembali di atas reorder point, kecuali
+// tandanya dilepas lagi dengan ReleaseLowStockAlert. productIDs kosong berarti semua produk dicek.
+func (repo *InventoryRepository) ClaimLowStockAlerts(productIDs []int) ([]models.LowStockItem, error) {
+	query := `WITH claimed AS (
+			UPDATE outlet_stocks os SET low_stock_alerted_at = now()
+			FROM products p
+			WHERE p.id = os.product_id AND p.reorder_point IS NOT NULL AND os.stock <= p.reorder_point AND os.low_stock_alerted_at IS NULL
+			  AND ($1::bigint[] IS NULL OR os.product_id = ANY($1))
+			RETURNING os.outlet_id, os.product_id, os.stock
+		)
+		SELECT claimed.outlet_id, p.id, p.name, coalesce(c.name, ''), claimed.stock, p.reorder_point, p.reorder_quantity,
+			coalesce((SELECT sum((l.quantity - l.received_quantity) * l.unit_factor) FROM purchase_order_lines l JOIN purchase_orders po ON l.purchase_order_id = po.id
+				WHERE l.product_id = p.id AND po.outlet_id = claimed.outlet_id AND po.status IN ('ordered', 'partially_received')), 0)
+		FROM claimed JOIN products p ON p.id = claimed.product_id LEFT JOIN categories c ON p.category_id = c.id
+		ORDER BY p.id, claimed.outlet_id`
+
+	var ids interface{}
+	if len(productIDs) > 0 {
+		ids = pq.Array(productIDs)
+	}
+
+	rows, err := repo.db.Query(query, ids)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := make([]models.LowStockItem, 0)
+	for rows.Next() {
+		var item models.LowStockItem
+		if err := rows.Scan(&item.OutletID, &item.ProductID, &item.Name, &item.Category, &item.Stock, &item.ReorderPoint, &item.ReorderQuantity, &item.OnOrder); err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+
+	return items, rows.Err()
+}
+
+// ReleaseLowStockAlert melepas tanda peringatan stok menipis satu produk di satu outlet supaya
+// pengecekan berikutnya mengirimnya lagi, dipakai saat pengiriman peringatan gagal
+func (repo *InventoryRepository) ReleaseLowStockAlert(productID, outletID int) error {
+	_, err := repo.db.Exec("UPDATE outlet_stocks SET low_stock_alerted_at = NULL WHERE product_id = $1 AND outlet_id = $2", productID, outletID)
+	return err
+}
+
+func (repo *InventoryRepository) queryLowStock(query string, args ...interface{}) ([]models.LowStockItem, error) {
+	rows, err := repo.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := make([]models.LowStockItem, 0)
+	for rows.Next() {
+		var item models.LowStockItem
+		if err := rows.Scan(&item.ProductID, &item.Name, &item.Category, &item.Stock, &item.ReorderPoint, &item.ReorderQuantity, &item.OnOrder); err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+
+	return items, rows.Err()
+}
+
+// GetExpiringLots mengembalikan lot yang masih berisi dan kedaluwarsa dalam filter.Days hari ke depan,
+// termasuk yang sudah lewat dan belum dihapus, paling cepat kedaluwarsa di atas
+func (repo *InventoryRepository) GetExpiringLots(filter models.ExpiringLotFilter) ([]models.StockLot, error) {
+	return queryStockLots(repo.db, `WHERE l.quantity > 0 AND l.expiry_date <= current_date + $1::integer
+			AND ($2::bigint = 0 OR l.outlet_id = $2)
+		ORDER BY l.expiry_date, p.name, l.outlet_id`, filter.Days, filter.OutletID)
+}
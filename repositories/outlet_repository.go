@@ -0,0 +1,217 @@
+package repositories
+
+import (
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"kasir-api/models"
+)
+
+type OutletRepository struct {
+	db *sql.DB
+}
+
+func NewOutletRepository(db *sql.DB) *OutletRepository {
+	return &OutletRepository{db: db}
+}
+
+const outletColumns = "id, name, coalesce(address, ''), coalesce(api_key, ''), is_default, service_charge_rate, service_charge_taxable, created_at"
+
+func (repo *OutletRepository) GetAll() ([]models.Outlet, error) {
+	rows, err := repo.db.Query("SELECT " + outletColumns + " FROM outlets ORDER BY id")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	outlets := make([]models.Outlet, 0)
+	for rows.Next() {
+		var o models.Outlet
+		if err := rows.Scan(&o.ID, &o.Name, &o.Address, &o.APIKey, &o.IsDefault, &o.ServiceChargeRate, &o.ServiceChargeTaxable, &o.CreatedAt); err != nil {
+			return nil, err
+		}
+		outlets = append(outlets, o)
+	}
+
+	return outlets, rows.Err()
+}
+
+func (repo *OutletRepository) GetByID(id int) (*models.Outlet, error) {
+	var o models.Outlet
+	err := repo.db.QueryRow("SELECT "+outletColumns+" FROM outlets WHERE id = $1", id).
+		Scan(&o.ID, &o.Name, &o.Address, &o.APIKey, &o.IsDefault, &o.ServiceChargeRate, &o.ServiceChargeTaxable, &o.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, errors.New("outlet not found")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &o, nil
+}
+
+func (repo *OutletRepository) Create(input *models.OutletInput) (*models.Outlet, error) {
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if input.IsDefault {
+		if _, err := tx.Exec("UPDATE outlets SET is_default = false WHERE is_default"); err != nil {
+			return nil, err
+		}
+	}
+
+	var apiKey sql.NullString
+	if input.GenerateAPIKey {
+		if apiKey.String, err = generateAPIKey(); err != nil {
+			return nil, err
+		}
+		apiKey.Valid = true
+	}
+
+	serviceChargeTaxable := input.ServiceChargeTaxable == nil || *input.ServiceChargeTaxable
+
+	var id int
+	err = tx.QueryRow(`INSERT INTO outlets (name, address, api_key, is_default, service_charge_rate, service_charge_taxable)
+		VALUES ($1, $2, $3, $4, $5, $6) RETURNING id`,
+		input.Name, nullString(input.Address), apiKey, input.IsDefault, input.ServiceChargeRate, serviceChargeTaxable).Scan(&id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return repo.GetByID(id)
+}
+
+// Update mengubah outlet. Outlet default hanya bisa dipindah dengan menjadikan outlet lain default.
+func (repo *OutletRepository) Update(id int, input *models.OutletInput) (*models.Outlet, error) {
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var isDefault bool
+	err = tx.QueryRow("SELECT is_default FROM outlets WHERE id = $1 FOR UPDATE", id).Scan(&isDefault)
+	if err == sql.ErrNoRows {
+		return nil, errors.New("outlet not found")
+	}
+	if err != nil {
+		return nil, err
+	}
+	if isDefault && !input.IsDefault {
+		return nil, errors.New("make another outlet the default instead")
+	}
+	if input.IsDefault && !isDefault {
+		if _, err := tx.Exec("UPDATE outlets SET is_default = false WHERE is_default"); err != nil {
+			return nil, err
+		}
+	}
+
+	_, err = tx.Exec(`UPDATE outlets SET name = $1, address = $2, is_default = $3, service_charge_rate = $4,
+		service_charge_taxable = coalesce($5, service_charge_taxable) WHERE id = $6`,
+		input.Name, nullString(input.Address), input.IsDefault, input.ServiceChargeRate, input.ServiceChargeTaxable, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if input.GenerateAPIKey {
+		apiKey, err := generateAPIKey()
+		if err != nil {
+			return nil, err
+		}
+		if _, err := tx.Exec("UPDATE outlets SET api_key = $1 WHERE id = $2", apiKey, id); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return repo.GetByID(id)
+}
+
+// FindByAPIKey mengembalikan id outlet pemilik API key
+func (repo *OutletRepository) FindByAPIKey(apiKey string) (int, bool, error) {
+	var id int
+	err := repo.db.QueryRow("SELECT id FROM outlets WHERE api_key = $1", apiKey).Scan(&id)
+	if err == sql.ErrNoRows {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, err
+	}
+	return id, true, nil
+}
+
+// GetStock mengembalikan stok semua produk di outlet
+func (repo *OutletRepository) GetStock(outletID int) ([]models.OutletStock, error) {
+	if _, err := repo.GetByID(outletID); err != nil {
+		return nil, err
+	}
+
+	rows, err := repo.db.Query(`SELECT $1::bigint, p.id, p.name, coalesce(os.stock, 0)
+		FROM products p LEFT JOIN outlet_stocks os ON os.product_id = p.id AND os.outlet_id = $1
+		ORDER BY p.id`, outletID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	stocks := make([]models.OutletStock, 0)
+	for rows.Next() {
+		var s models.OutletStock
+		if err := rows.Scan(&s.OutletID, &s.ProductID, &s.ProductName, &s.Stock); err != nil {
+			return nil, err
+		}
+		stocks = append(stocks, s)
+	}
+
+	return stocks, rows.Err()
+}
+
+// resolveOutlet mengembalikan id outlet default jika id 0, selain itu memastikan outletnya ada
+func resolveOutlet(q queryer, id int) (int, error) {
+	if id == 0 {
+		err := q.QueryRow("SELECT id FROM outlets WHERE is_default").Scan(&id)
+		if err == sql.ErrNoRows {
+			return 0, errors.New("no default outlet configured")
+		}
+		return id, err
+	}
+
+	var exists bool
+	if err := q.QueryRow("SELECT EXISTS (SELECT 1 FROM outlets WHERE id = $1)", id).Scan(&exists); err != nil {
+		return 0, err
+	}
+	if !exists {
+		return 0, fmt.Errorf("outlet id %d not found", id)
+	}
+	return id, nil
+}
+
+// outletTaxConfig melengkapi konfigurasi pajak dengan service charge outlet, outlet default jika id 0
+func outletTaxConfig(q queryer, config models.TaxConfig, outletID int) (models.TaxConfig, error) {
+	err := q.QueryRow("SELECT service_charge_rate, service_charge_taxable FROM outlets WHERE CASE WHEN $1::bigint = 0 THEN is_default ELSE id = $1 END",
+		outletID).Scan(&config.ServiceChargeRate, &config.ServiceChargeTaxable)
+	if err == sql.ErrNoRows {
+		return config, fmt.Errorf("outlet id %d not found", outletID)
+	}
+	return config, err
+}
+
+func generateAPIKey() (string, error) {
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
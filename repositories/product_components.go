@@ -0,0 +1,140 @@
+package repositories
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"kasir-api/models"
+
+	"github.com/lib/pq"
+)
+
+// recipeComponent adalah satu bahan produk komposisi, quantity dalam satuan dasar bahan per satuan dasar produk
+type recipeComponent struct {
+	productID int
+	quantity  int
+}
+
+// loadRecipes mengambil resep produk-produk di productIDs, produk yang bukan komposisi tidak ada di hasil
+func loadRecipes(q queryer, productIDs []int) (map[int][]recipeComponent, error) {
+	recipes := make(map[int][]recipeComponent)
+	if len(productIDs) == 0 {
+		return recipes, nil
+	}
+
+	rows, err := q.Query("SELECT product_id, component_id, quantity FROM product_components WHERE product_id = ANY($1) ORDER BY product_id, component_id", pq.Array(productIDs))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var productID int
+		var c recipeComponent
+		if err := rows.Scan(&productID, &c.productID, &c.quantity); err != nil {
+			return nil, err
+		}
+		recipes[productID] = append(recipes[productID], c)
+	}
+	return recipes, rows.Err()
+}
+
+// stockNeeds menjabarkan quantity (satuan dasar) produk menjadi kebutuhan stok per produk:
+// bahan-bahannya untuk produk komposisi, produk itu sendiri untuk produk biasa
+func stockNeeds(q queryer, productID, quantity int) (map[int]int, error) {
+	recipes, err := loadRecipes(q, []int{productID})
+	if err != nil {
+		return nil, err
+	}
+
+	needs := make(map[int]int)
+	components, ok := recipes[productID]
+	if !ok {
+		needs[productID] = quantity
+		return needs, nil
+	}
+	for _, c := range components {
+		needs[c.productID] += quantity * c.quantity
+	}
+	return needs, nil
+}
+
+// getComponents mengambil resep semua produk di productIDs beserta nama bahannya, dikelompokkan per produk
+func (repo *ProductRepository) getComponents(productIDs []int) (map[int][]models.ProductComponent, error) {
+	components := make(map[int][]models.ProductComponent)
+	if len(productIDs) == 0 {
+		return components, nil
+	}
+
+	rows, err := repo.db.Query(`SELECT pc.product_id, pc.component_id, coalesce(p.name, ''), pc.quantity
+		FROM product_components pc JOIN products p ON pc.component_id = p.id
+		WHERE pc.product_id = ANY($1) ORDER BY pc.product_id, pc.component_id`, pq.Array(productIDs))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var productID int
+		var c models.ProductComponent
+		if err := rows.Scan(&productID, &c.ProductID, &c.Name, &c.Quantity); err != nil {
+			return nil, err
+		}
+		components[productID] = append(components[productID], c)
+	}
+	return components, rows.Err()
+}
+
+// setProductComponents mengganti resep produk. Produk komposisi tidak menyimpan stok sendiri, jadi stoknya
+// harus 0 dan tidak boleh punya varian; bahannya harus produk biasa yang menyimpan stok.
+func setProductComponents(tx *sql.Tx, productID int, components []models.ProductComponent) error {
+	if _, err := tx.Exec("DELETE FROM product_components WHERE product_id = $1", productID); err != nil {
+		return err
+	}
+	if len(components) == 0 {
+		return nil
+	}
+
+	var stock int
+	var hasVariants, isComponent bool
+	err := tx.QueryRow(`SELECT coalesce(stock, 0), EXISTS (SELECT 1 FROM products v WHERE v.parent_id = p.id),
+			EXISTS (SELECT 1 FROM product_components pc WHERE pc.component_id = p.id)
+		FROM products p WHERE id = $1`, productID).Scan(&stock, &hasVariants, &isComponent)
+	if err != nil {
+		return err
+	}
+	if hasVariants {
+		return errors.New("product with variants cannot have components")
+	}
+	if isComponent {
+		return errors.New("product is a component of another product and cannot have components")
+	}
+	if stock != 0 {
+		return errors.New("product with stock cannot have components, adjust its stock to 0 first")
+	}
+
+	for _, c := range components {
+		// bahan dikunci supaya tidak berubah menjadi produk induk atau komposisi di saat yang sama
+		var hasVariants, isComposite bool
+		err := tx.QueryRow(`SELECT EXISTS (SELECT 1 FROM products v WHERE v.parent_id = p.id),
+				EXISTS (SELECT 1 FROM product_components pc WHERE pc.product_id = p.id)
+			FROM products p WHERE id = $1 FOR UPDATE`, c.ProductID).Scan(&hasVariants, &isComposite)
+		if err == sql.ErrNoRows {
+			return fmt.Errorf("component product id %d not found", c.ProductID)
+		}
+		if err != nil {
+			return err
+		}
+		if hasVariants {
+			return fmt.Errorf("component product id %d has variants, choose a variant", c.ProductID)
+		}
+		if isComposite {
+			return fmt.Errorf("component product id %d is itself made from components", c.ProductID)
+		}
+
+		if _, err := tx.Exec("INSERT INTO product_components (product_id, component_id, quantity) VALUES ($1, $2, $3)", productID, c.ProductID, c.Quantity); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -2,8 +2,15 @@ package repositories
 
 import (
 	"database/sql"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"kasir-api/barcode"
 	"kasir-api/models"
+	"sort"
+	"strings"
+
+	"github.com/lib/pq"
 )
 
 type ProductRepository struct {
@@ -14,15 +21,123 @@ func NewProductRepository(db *sql.DB) *ProductRepository {
 	return &ProductRepository{db: db}
 }
 
-func (repo *ProductRepository) GetAll(name string) ([]models.Product, error) {
-	query := "SELECT p.id, p.name, p.price, p.stock, c.name AS category FROM products p LEFT JOIN categories c ON p.category_id = c.id"
+// productColumns mengambil kolom produk; harga pokok produk komposisi dihitung dari bahan-bahannya dan stoknya
+// dari stok bahan di outlet pada parameter %[1]d, karena produk komposisi hanya bisa dijual dari stok outlet itu
+const productColumns = `p.id, p.parent_id, p.name, p.options, coalesce(p.sku, ''), p.price,
+	coalesce((SELECT sum(s.cost_price * pc.quantity) FROM product_components pc JOIN products s ON pc.component_id = s.id WHERE pc.product_id = p.id), p.cost_price),
+	coalesce((SELECT min(coalesce(cs.stock, 0) / pc.quantity) FROM product_components pc
+		LEFT JOIN outlet_stocks cs ON cs.product_id = pc.component_id AND cs.outlet_id = $%[1]d WHERE pc.product_id = p.id), p.stock),
+	p.unit, p.track_expiry, p.reorder_point, p.reorder_quantity, p.category_id, c.name AS category`
+
+func scanProduct(scan func(dest ...interface{}) error) (models.Product, error) {
+	var (
+		p        models.Product
+		parentID sql.NullInt64
+		options  []byte
+	)
+	err := scan(&p.ID, &parentID, &p.Name, &options, &p.SKU, &p.Price, &p.CostPrice, &p.Stock, &p.Unit, &p.TrackExpiry, &p.ReorderPoint, &p.ReorderQuantity, &p.CategoryID, &p.Category)
+	if err != nil {
+		return p, err
+	}
+	if parentID.Valid {
+		id := int(parentID.Int64)
+		p.ParentID = &id
+	}
+	if options != nil {
+		if err := json.Unmarshal(options, &p.Options); err != nil {
+			return p, err
+		}
+	}
+	return p, nil
+}
+
+// GetAll mencari produk berdasarkan nama atau SKU (ILIKE), atau barcode yang sama persis, dalam kategori
+// categoryID beserta sub-kategorinya (0 berarti semua kategori).
+// Varian dikelompokkan di bawah induknya; varian yang cocok ikut membawa induk beserta semua variannya.
+func (repo *ProductRepository) GetAll(name string, categoryID, outletID int) ([]models.Product, error) {
+	if name == "" {
+		products, err := repo.queryProducts(outletID, inCategory("p.category_id", 1), categoryID)
+		if err != nil {
+			return nil, err
+		}
+		return groupVariants(products), nil
+	}
+
+	code := name
+	if normalized, err := barcode.Normalize(name); err == nil {
+		code = normalized
+	}
+	matched, err := repo.queryProducts(outletID, "(p.name ILIKE $1 OR p.sku ILIKE $1 OR EXISTS (SELECT 1 FROM product_barcodes b WHERE b.product_id = p.id AND b.code = $2)) AND "+inCategory("p.category_id", 3),
+		"%"+name+"%", code, categoryID)
+	if err != nil {
+		return nil, err
+	}
 
-	var args []interface{}
-	if name != "" {
-		query += " WHERE p.name ILIKE $1"
-		args = append(args, "%"+name+"%")
+	ids := make([]int, 0, len(matched))
+	for _, p := range matched {
+		if p.ParentID != nil {
+			ids = append(ids, *p.ParentID)
+		} else {
+			ids = append(ids, p.ID)
+		}
 	}
+	products, err := repo.queryProducts(outletID, "p.id = ANY($1) OR p.parent_id = ANY($1)", pq.Array(ids))
+	if err != nil {
+		return nil, err
+	}
+	return groupVariants(products), nil
+}
+
+// GetByIDs mengembalikan produk yang bisa dijual sesuai urutan ids, produk induk diganti dengan
+// semua variannya. Id yang sama boleh muncul lebih dari sekali.
+func (repo *ProductRepository) GetByIDs(ids []int, outletID int) ([]models.Product, error) {
+	found, err := repo.queryProducts(outletID, "p.id = ANY($1) OR p.parent_id = ANY($1)", pq.Array(ids))
+	if err != nil {
+		return nil, err
+	}
+
+	byID := make(map[int]models.Product, len(found))
+	variants := make(map[int][]models.Product)
+	for _, p := range found {
+		byID[p.ID] = p
+		if p.ParentID != nil {
+			variants[*p.ParentID] = append(variants[*p.ParentID], p)
+		}
+	}
+
+	products := make([]models.Product, 0, len(ids))
+	for _, id := range ids {
+		p, ok := byID[id]
+		if !ok {
+			return nil, fmt.Errorf("product id %d not found", id)
+		}
+		if len(variants[id]) > 0 {
+			products = append(products, variants[id]...)
+			continue
+		}
+		products = append(products, p)
+	}
+	return products, nil
+}
+
+// GetByCategory mengembalikan semua produk yang bisa dijual dalam satu kategori beserta sub-kategorinya, tanpa produk induk
+func (repo *ProductRepository) GetByCategory(categoryID, outletID int) ([]models.Product, error) {
+	return repo.queryProducts(outletID, "p.category_id IN ("+fmt.Sprintf(categorySubtree, 1)+") AND NOT EXISTS (SELECT 1 FROM products v WHERE v.parent_id = p.id)", categoryID)
+}
 
+// queryProducts mengambil produk beserta barcode-nya dengan kondisi WHERE opsional, urut berdasarkan id.
+// Varian dikembalikan sebagai baris terpisah. Stok produk komposisi dihitung di outlet outletID (0 berarti outlet default).
+func (repo *ProductRepository) queryProducts(outletID int, condition string, args ...interface{}) ([]models.Product, error) {
+	outletID, err := resolveOutlet(repo.db, outletID)
+	if err != nil {
+		return nil, err
+	}
+	args = append(args, outletID)
+
+	query := "SELECT " + fmt.Sprintf(productColumns, len(args)) + " FROM products p LEFT JOIN categories c ON p.category_id = c.id"
+	if condition != "" {
+		query += " WHERE " + condition
+	}
 	query += " ORDER BY p.id"
 
 	rows, err := repo.db.Query(query, args...)
@@ -32,64 +147,407 @@ func (repo *ProductRepository) GetAll(name string) ([]models.Product, error) {
 	defer rows.Close()
 
 	products := make([]models.Product, 0)
+	ids := make([]int, 0)
 	for rows.Next() {
-		var p models.Product
-
-		err := rows.Scan(&p.ID, &p.Name, &p.Price, &p.Stock, &p.Category)
+		p, err := scanProduct(rows.Scan)
 		if err != nil {
 			return nil, err
 		}
 		products = append(products, p)
+		ids = append(ids, p.ID)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	barcodes, err := repo.getBarcodes(ids)
+	if err != nil {
+		return nil, err
+	}
+	units, err := repo.getUnits(ids)
+	if err != nil {
+		return nil, err
+	}
+	components, err := repo.getComponents(ids)
+	if err != nil {
+		return nil, err
+	}
+	categoryIDs := make([]int, 0, len(products))
+	for _, p := range products {
+		categoryIDs = append(categoryIDs, p.CategoryID)
+	}
+	paths, err := categoryPaths(repo.db, categoryIDs)
+	if err != nil {
+		return nil, err
+	}
+	for i := range products {
+		products[i].Barcodes = barcodes[products[i].ID]
+		products[i].Units = units[products[i].ID]
+		products[i].Components = components[products[i].ID]
+		products[i].CategoryPath = paths[products[i].CategoryID]
 	}
 
 	return products, nil
 }
 
+// groupVariants memindahkan varian ke dalam Variants induknya. Stok induk yang punya varian
+// adalah jumlah stok variannya.
+func groupVariants(products []models.Product) []models.Product {
+	variants := make(map[int][]models.Product)
+	for _, p := range products {
+		if p.ParentID != nil {
+			variants[*p.ParentID] = append(variants[*p.ParentID], p)
+		}
+	}
+
+	grouped := make([]models.Product, 0)
+	for _, p := range products {
+		if p.ParentID != nil {
+			continue
+		}
+		if v := variants[p.ID]; len(v) > 0 {
+			p.Variants = v
+			p.Stock = 0
+			for _, variant := range v {
+				p.Stock += variant.Stock
+			}
+		}
+		grouped = append(grouped, p)
+	}
+	return grouped
+}
+
+// Create membuat produk. Varian wajib punya Options dengan nama opsi yang sama dengan varian lain dari
+// induk yang sama, Name kosong diisi nama induk ditambah nilai opsinya, dan kategorinya selalu mengikuti induk.
+// Stock kosong berarti 0, Unit kosong berarti "pcs" dan ReorderPoint kosong atau negatif berarti stok produk
+// tidak dipantau.
 func (repo *ProductRepository) Create(input *models.ProductInput) (*models.Product, error) {
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// produk dibuat dengan stok 0, stok awal dicatat lewat buku stok
+	costPrice := 0
+	if input.CostPrice != nil {
+		costPrice = *input.CostPrice
+	}
+
+	var sku string
+	if input.SKU != nil {
+		sku = *input.SKU
+	}
+	if err := checkSKU(tx, sku, 0); err != nil {
+		return nil, err
+	}
+
+	// varian selalu masuk kategori induknya
+	name, categoryID := input.Name, input.CategoryID
+	var options interface{}
+	if input.ParentID != nil {
+		parent, err := lockVariantParent(tx, *input.ParentID)
+		if err != nil {
+			return nil, err
+		}
+		if err := checkVariantOptions(tx, *input.ParentID, 0, input.Options); err != nil {
+			return nil, err
+		}
+		if name == "" {
+			name = variantName(parent.name, input.Options)
+		}
+		categoryID = parent.categoryID
+		if options, err = marshalOptions(input.Options); err != nil {
+			return nil, err
+		}
+	} else if err := checkActiveCategory(tx, categoryID, "category"); err != nil {
+		return nil, err
+	}
+
+	unit := input.Unit
+	if unit == "" {
+		unit = "pcs"
+	}
+
+	trackExpiry := input.TrackExpiry != nil && *input.TrackExpiry
+
+	reorderPoint, reorderQuantity := input.ReorderPoint, 0
+	if reorderPoint != nil && *reorderPoint < 0 {
+		reorderPoint = nil
+	}
+	if input.ReorderQuantity != nil {
+		reorderQuantity = *input.ReorderQuantity
+	}
+
 	var id int
-	query := "INSERT INTO products (name, price, stock, category_id) VALUES ($1, $2, $3, $4) RETURNING id"
-	err := repo.db.QueryRow(query, input.Name, input.Price, input.Stock, input.CategoryID).Scan(&id)
+	query := "INSERT INTO products (parent_id, name, options, sku, price, cost_price, stock, unit, track_expiry, reorder_point, reorder_quantity, category_id) VALUES ($1, $2, $3, $4, $5, $6, 0, $7, $8, $9, $10, $11) RETURNING id"
+	err = tx.QueryRow(query, input.ParentID, name, options, nullString(sku), input.Price, costPrice, unit, trackExpiry, reorderPoint, reorderQuantity, categoryID).Scan(&id)
 	if err != nil {
 		return nil, err
 	}
-	return repo.GetByID(id)
-}
 
-func (repo *ProductRepository) GetByID(id int) (*models.Product, error) {
-	query := "SELECT p.id, p.name, p.price, p.stock, c.name AS category FROM products p LEFT JOIN categories c ON p.category_id = c.id WHERE p.id = $1"
+	if err := setProductBarcodes(tx, id, input.Barcodes); err != nil {
+		return nil, err
+	}
+	if err := setProductUnits(tx, id, input.Units); err != nil {
+		return nil, err
+	}
+	if err := setProductComponents(tx, id, input.Components); err != nil {
+		return nil, err
+	}
 
-	var p models.Product
-	err := repo.db.QueryRow(query, id).Scan(&p.ID, &p.Name, &p.Price, &p.Stock, &p.Category)
-	if err == sql.ErrNoRows {
-		return nil, errors.New("product not found")
+	if input.Stock != nil && *input.Stock != 0 {
+		outletID, err := resolveOutlet(tx, input.OutletID)
+		if err != nil {
+			return nil, err
+		}
+		_, err = applyStockChange(tx, stockChange{
+			outletID:     outletID,
+			productID:    id,
+			quantity:     *input.Stock,
+			movementType: models.StockMovementInitial,
+			reason:       input.StockReason,
+			user:         input.ChangedBy,
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
 	}
+	return repo.GetByID(id, input.OutletID)
+}
+
+// GetByID mengembalikan produk beserta variannya jika produk adalah induk
+func (repo *ProductRepository) GetByID(id, outletID int) (*models.Product, error) {
+	products, err := repo.queryProducts(outletID, "p.id = $1 OR p.parent_id = $1", id)
 	if err != nil {
 		return nil, err
 	}
+	if len(products) == 0 {
+		return nil, errors.New("product not found")
+	}
 
-	return &p, nil
+	// varian tidak punya varian lagi, jadi hasilnya hanya varian itu sendiri
+	grouped := groupVariants(products)
+	if len(grouped) == 0 {
+		return &products[0], nil
+	}
+	return &grouped[0], nil
 }
 
-func (repo *ProductRepository) Update(id int, input *models.ProductInput) (*models.Product, error) {
-	query := "UPDATE products SET name = $1, price = $2, stock = $3, category_id = $4 WHERE id = $5"
-	result, err := repo.db.Exec(query, input.Name, input.Price, input.Stock, input.CategoryID, id)
+// GetByBarcode mencari produk dari barcode yang sudah dinormalisasi
+func (repo *ProductRepository) GetByBarcode(code string, outletID int) (*models.Product, error) {
+	id, _, err := findProductByBarcode(repo.db, code)
 	if err != nil {
 		return nil, err
 	}
+	return repo.GetByID(id, outletID)
+}
 
-	rows, err := result.RowsAffected()
+// Update mengubah produk, ParentID tidak bisa diubah. Field pointer, slice dan map yang kosong (null) serta Unit
+// kosong berarti tidak diubah; SKU berisi string kosong menghapus SKU dan array kosong menghapus semua satuan,
+// barcode atau resep. ReorderPoint negatif mematikan pemantauan stok produk dan reorder point yang berubah
+// membuka lagi peringatan stok menipis di semua outlet.
+func (repo *ProductRepository) Update(id int, input *models.ProductInput) (*models.Product, error) {
+	tx, err := repo.db.Begin()
 	if err != nil {
 		return nil, err
 	}
+	defer tx.Rollback()
 
-	if rows == 0 {
+	var (
+		parentID        sql.NullInt64
+		currentOptions  []byte
+		hasVariants     bool
+		trackExpiry     bool
+		currentCategory int
+	)
+	err = tx.QueryRow(`SELECT parent_id, options, EXISTS (SELECT 1 FROM products v WHERE v.parent_id = products.id), track_expiry, category_id
+		FROM products WHERE id = $1 FOR UPDATE`, id).Scan(&parentID, &currentOptions, &hasVariants, &trackExpiry, &currentCategory)
+	if err == sql.ErrNoRows {
 		return nil, errors.New("product not found")
 	}
+	if err != nil {
+		return nil, err
+	}
+
+	if input.SKU != nil {
+		if err := checkSKU(tx, *input.SKU, id); err != nil {
+			return nil, err
+		}
+	}
+
+	// varian mengikuti kategori induknya, Options kosong berarti opsi lama dipakai
+	name, categoryID := input.Name, input.CategoryID
+	var options interface{}
+	if parentID.Valid {
+		var parentName string
+		err := tx.QueryRow("SELECT coalesce(name, ''), category_id FROM products WHERE id = $1", parentID.Int64).Scan(&parentName, &categoryID)
+		if err != nil {
+			return nil, err
+		}
+
+		variantOptions := input.Options
+		if variantOptions != nil {
+			if err := checkVariantOptions(tx, int(parentID.Int64), id, variantOptions); err != nil {
+				return nil, err
+			}
+			if options, err = marshalOptions(variantOptions); err != nil {
+				return nil, err
+			}
+		} else if err := json.Unmarshal(currentOptions, &variantOptions); err != nil {
+			return nil, err
+		}
+		if name == "" {
+			name = variantName(parentName, variantOptions)
+		}
+	} else if input.Options != nil {
+		return nil, errors.New("options can only be set on a variant")
+	} else if categoryID != currentCategory {
+		// produk yang sudah ada boleh tetap di kategori yang diarsipkan, tapi tidak bisa dipindah ke sana
+		if err := checkActiveCategory(tx, categoryID, "category"); err != nil {
+			return nil, err
+		}
+	}
+
+	query := `UPDATE products SET name = $1, sku = CASE WHEN $2::text IS NULL THEN sku ELSE nullif($2, '') END, price = $3, category_id = $4,
+		cost_price = coalesce($5, cost_price), reorder_quantity = coalesce($6, reorder_quantity), options = coalesce($7::jsonb, options), unit = coalesce(nullif($8, ''), unit)
+		WHERE id = $9`
+	_, err = tx.Exec(query, name, input.SKU, input.Price, categoryID, input.CostPrice, input.ReorderQuantity, options, input.Unit, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if input.ReorderPoint != nil {
+		var reorderPoint *int
+		if *input.ReorderPoint >= 0 {
+			reorderPoint = input.ReorderPoint
+		}
+		_, err = tx.Exec(`UPDATE outlet_stocks SET low_stock_alerted_at = NULL
+			WHERE product_id = $1 AND EXISTS (SELECT 1 FROM products WHERE id = $1 AND reorder_point IS DISTINCT FROM $2)`, id, reorderPoint)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := tx.Exec("UPDATE products SET reorder_point = $1 WHERE id = $2", reorderPoint, id); err != nil {
+			return nil, err
+		}
+	}
+
+	if hasVariants {
+		if _, err := tx.Exec("UPDATE products SET category_id = $1 WHERE parent_id = $2", categoryID, id); err != nil {
+			return nil, err
+		}
+	}
 
-	return repo.GetByID(id)
+	if input.Barcodes != nil {
+		if err := setProductBarcodes(tx, id, input.Barcodes); err != nil {
+			return nil, err
+		}
+	}
+	if input.Units != nil {
+		if err := setProductUnits(tx, id, input.Units); err != nil {
+			return nil, err
+		}
+	}
+	if input.TrackExpiry != nil && *input.TrackExpiry != trackExpiry {
+		if err := setTrackExpiry(tx, id, *input.TrackExpiry); err != nil {
+			return nil, err
+		}
+	}
+	if input.Components != nil {
+		if err := setProductComponents(tx, id, input.Components); err != nil {
+			return nil, err
+		}
+	}
+	var isComposite bool
+	if err := tx.QueryRow("SELECT EXISTS (SELECT 1 FROM product_components WHERE product_id = $1)", id).Scan(&isComposite); err != nil {
+		return nil, err
+	}
+
+	// stok tidak ditimpa langsung, selisih terhadap stok outlet yang dipilih dicatat sebagai penyesuaian
+	// di buku stok outlet itu. Stok induk adalah jumlah stok varian dan stok produk komposisi
+	// dihitung dari bahannya sehingga keduanya tidak diubah.
+	if input.Stock != nil && !hasVariants && !isComposite {
+		outletID, err := resolveOutlet(tx, input.OutletID)
+		if err != nil {
+			return nil, err
+		}
+		reason := input.StockReason
+		if reason == "" {
+			reason = "product update"
+		}
+		if err := setOutletStock(tx, outletID, id, *input.Stock, reason, input.ChangedBy); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return repo.GetByID(id, input.OutletID)
+}
+
+// setOutletStock mencatat selisih stok produk di outlet terhadap target sebagai penyesuaian di buku stok
+func setOutletStock(tx *sql.Tx, outletID, productID, target int, reason, user string) error {
+	stock, err := lockProductStock(tx, outletID, productID)
+	if err != nil {
+		return err
+	}
+	if target == stock {
+		return nil
+	}
+	_, err = applyStockChange(tx, stockChange{
+		outletID:     outletID,
+		productID:    productID,
+		quantity:     target - stock,
+		movementType: models.StockMovementAdjustment,
+		reason:       reason,
+		user:         user,
+	})
+	return err
 }
 
 func (repo *ProductRepository) Delete(id int) error {
+	var hasVariants bool
+	if err := repo.db.QueryRow("SELECT EXISTS (SELECT 1 FROM products WHERE parent_id = $1)", id).Scan(&hasVariants); err != nil {
+		return err
+	}
+	if hasVariants {
+		return errors.New("product has variants, delete the variants first")
+	}
+	var isComponent bool
+	if err := repo.db.QueryRow("SELECT EXISTS (SELECT 1 FROM product_components WHERE component_id = $1)", id).Scan(&isComponent); err != nil {
+		return err
+	}
+	if isComponent {
+		return errors.New("product is used as a component, remove it from those products first")
+	}
+	var onPurchaseOrder bool
+	if err := repo.db.QueryRow("SELECT EXISTS (SELECT 1 FROM purchase_order_lines WHERE product_id = $1)", id).Scan(&onPurchaseOrder); err != nil {
+		return err
+	}
+	if onPurchaseOrder {
+		return errors.New("product is referenced by purchase orders and cannot be deleted")
+	}
+	var onTransfer bool
+	if err := repo.db.QueryRow("SELECT EXISTS (SELECT 1 FROM stock_transfer_items WHERE product_id = $1)", id).Scan(&onTransfer); err != nil {
+		return err
+	}
+	if onTransfer {
+		return errors.New("product is referenced by stock transfers and cannot be deleted")
+	}
+	// buku stok adalah jejak audit, produk yang pernah punya pergerakan stok tidak bisa dihapus
+	var hasMovements bool
+	if err := repo.db.QueryRow("SELECT EXISTS (SELECT 1 FROM stock_movements WHERE product_id = $1)", id).Scan(&hasMovements); err != nil {
+		return err
+	}
+	if hasMovements {
+		return errors.New("product has stock movements and cannot be deleted")
+	}
+
 	query := "DELETE FROM products WHERE id = $1"
 	result, err := repo.db.Exec(query, id)
 	if err != nil {
@@ -107,3 +565,231 @@ func (repo *ProductRepository) Delete(id int) error {
 
 	return nil
 }
+
+// getBarcodes mengambil barcode semua produk di productIDs, dikelompokkan per produk
+func (repo *ProductRepository) getBarcodes(productIDs []int) (map[int][]string, error) {
+	barcodes := make(map[int][]string)
+	for _, id := range productIDs {
+		barcodes[id] = make([]string, 0)
+	}
+	if len(productIDs) == 0 {
+		return barcodes, nil
+	}
+
+	rows, err := repo.db.Query("SELECT product_id, code FROM product_barcodes WHERE product_id = ANY($1) AND unit_id IS NULL ORDER BY created_at, code", pq.Array(productIDs))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			productID int
+			code      string
+		)
+		if err := rows.Scan(&productID, &code); err != nil {
+			return nil, err
+		}
+		barcodes[productID] = append(barcodes[productID], code)
+	}
+
+	return barcodes, rows.Err()
+}
+
+// checkSKU memastikan SKU belum dipakai produk lain
+func checkSKU(tx *sql.Tx, sku string, productID int) error {
+	if sku == "" {
+		return nil
+	}
+	var exists bool
+	err := tx.QueryRow("SELECT EXISTS (SELECT 1 FROM products WHERE sku = $1 AND id <> $2)", sku, productID).Scan(&exists)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return fmt.Errorf("sku %s already exists", sku)
+	}
+	return nil
+}
+
+// setProductBarcodes mengganti semua barcode produk di satuan dasar. Barcode sudah dinormalisasi oleh service.
+func setProductBarcodes(tx *sql.Tx, productID int, codes []string) error {
+	if _, err := tx.Exec("DELETE FROM product_barcodes WHERE product_id = $1 AND unit_id IS NULL", productID); err != nil {
+		return err
+	}
+
+	for _, code := range codes {
+		if err := insertBarcode(tx, productID, 0, code); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// insertBarcode menyimpan barcode produk, unitID 0 berarti barcode satuan dasar
+func insertBarcode(tx *sql.Tx, productID, unitID int, code string) error {
+	var unit sql.NullInt64
+	if unitID != 0 {
+		unit = sql.NullInt64{Int64: int64(unitID), Valid: true}
+	}
+
+	result, err := tx.Exec("INSERT INTO product_barcodes (code, product_id, unit_id) VALUES ($1, $2, $3) ON CONFLICT (code) DO NOTHING", code, productID, unit)
+	if err != nil {
+		return err
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return fmt.Errorf("barcode %s is already used by another product", code)
+	}
+	return nil
+}
+
+// findProductByBarcode mengembalikan id produk pemilik barcode dan nama satuannya, kosong untuk satuan dasar
+func findProductByBarcode(q queryer, code string) (int, string, error) {
+	var (
+		id   int
+		unit string
+	)
+	err := q.QueryRow(`SELECT b.product_id, coalesce(u.name, '') FROM product_barcodes b
+		LEFT JOIN product_units u ON b.unit_id = u.id WHERE b.code = $1`, code).Scan(&id, &unit)
+	if err == sql.ErrNoRows {
+		return 0, "", fmt.Errorf("barcode %s not found", code)
+	}
+	return id, unit, err
+}
+
+// resolveItemBarcodes mengisi ProductID dan Unit item yang dikirim dengan barcode hasil scan
+func resolveItemBarcodes(q queryer, items []models.CheckoutItem) error {
+	for i, item := range items {
+		if item.Barcode == "" {
+			continue
+		}
+		code, err := barcode.Normalize(item.Barcode)
+		if err != nil {
+			return err
+		}
+		id, unit, err := findProductByBarcode(q, code)
+		if err != nil {
+			return err
+		}
+		if item.ProductID != 0 && item.ProductID != id {
+			return fmt.Errorf("barcode %s does not belong to product id %d", item.Barcode, item.ProductID)
+		}
+		if item.Unit != "" && item.Unit != unit {
+			return fmt.Errorf("barcode %s does not belong to unit %s", item.Barcode, item.Unit)
+		}
+		items[i].ProductID = id
+		items[i].Unit = unit
+	}
+	return nil
+}
+
+type variantParent struct {
+	name       string
+	categoryID int
+}
+
+// lockVariantParent mengunci produk induk dan memastikan produk itu bisa punya varian:
+// bukan varian dan tidak punya stok sendiri, karena stok induk adalah jumlah stok variannya
+func lockVariantParent(tx *sql.Tx, parentID int) (*variantParent, error) {
+	var (
+		p                        variantParent
+		grandParent              sql.NullInt64
+		stock                    int
+		isComposite, isComponent bool
+	)
+	err := tx.QueryRow(`SELECT coalesce(name, ''), category_id, parent_id, coalesce(stock, 0),
+			EXISTS (SELECT 1 FROM product_components pc WHERE pc.product_id = products.id),
+			EXISTS (SELECT 1 FROM product_components pc WHERE pc.component_id = products.id)
+		FROM products WHERE id = $1 FOR UPDATE`, parentID).
+		Scan(&p.name, &p.categoryID, &grandParent, &stock, &isComposite, &isComponent)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("parent product id %d not found", parentID)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if grandParent.Valid {
+		return nil, errors.New("a variant cannot have variants")
+	}
+	if stock != 0 {
+		return nil, errors.New("parent product still has stock, move it to a variant first")
+	}
+	if isComposite || isComponent {
+		return nil, errors.New("a product with components or used as a component cannot have variants")
+	}
+	return &p, nil
+}
+
+// checkVariantOptions memastikan opsi varian memakai nama opsi yang sama dengan varian lain
+// dari induk yang sama dan kombinasinya belum dipakai varian lain
+func checkVariantOptions(tx *sql.Tx, parentID, productID int, options map[string]string) error {
+	rows, err := tx.Query("SELECT id, options FROM products WHERE parent_id = $1 AND id <> $2 ORDER BY id", parentID, productID)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			id      int
+			raw     []byte
+			sibling map[string]string
+		)
+		if err := rows.Scan(&id, &raw); err != nil {
+			return err
+		}
+		if err := json.Unmarshal(raw, &sibling); err != nil {
+			return err
+		}
+
+		if len(sibling) != len(options) {
+			return fmt.Errorf("variant options must be %s", strings.Join(optionNames(sibling), ", "))
+		}
+		same := true
+		for name, value := range sibling {
+			v, ok := options[name]
+			if !ok {
+				return fmt.Errorf("variant options must be %s", strings.Join(optionNames(sibling), ", "))
+			}
+			if v != value {
+				same = false
+			}
+		}
+		if same {
+			return fmt.Errorf("variant with the same options already exists (product id %d)", id)
+		}
+	}
+
+	return rows.Err()
+}
+
+func optionNames(options map[string]string) []string {
+	names := make([]string, 0, len(options))
+	for name := range options {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// variantName menyusun nama varian dari nama induk dan nilai opsinya, misal "Teh Botol 1L"
+func variantName(parentName string, options map[string]string) string {
+	parts := []string{parentName}
+	for _, name := range optionNames(options) {
+		parts = append(parts, options[name])
+	}
+	return strings.Join(parts, " ")
+}
+
+// marshalOptions mengubah opsi varian menjadi parameter jsonb
+func marshalOptions(options map[string]string) (interface{}, error) {
+	b, err := json.Marshal(options)
+	if err != nil {
+		return nil, err
+	}
+	return string(b), nil
+}
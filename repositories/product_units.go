@@ -0,0 +1,79 @@
+package repositories
+
+import (
+	"database/sql"
+	"fmt"
+	"kasir-api/models"
+
+	"github.com/lib/pq"
+)
+
+// getUnits mengambil satuan semua produk di productIDs beserta barcode-nya, dikelompokkan per produk
+func (repo *ProductRepository) getUnits(productIDs []int) (map[int][]models.ProductUnit, error) {
+	units := make(map[int][]models.ProductUnit)
+	for _, id := range productIDs {
+		units[id] = make([]models.ProductUnit, 0)
+	}
+	if len(productIDs) == 0 {
+		return units, nil
+	}
+
+	rows, err := repo.db.Query(`SELECT u.product_id, u.name, u.factor, u.price, coalesce(array_agg(b.code ORDER BY b.created_at, b.code) FILTER (WHERE b.code IS NOT NULL), '{}')
+		FROM product_units u LEFT JOIN product_barcodes b ON b.unit_id = u.id
+		WHERE u.product_id = ANY($1)
+		GROUP BY u.id ORDER BY u.factor, u.name`, pq.Array(productIDs))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			productID int
+			u         models.ProductUnit
+		)
+		if err := rows.Scan(&productID, &u.Name, &u.Factor, &u.Price, pq.Array(&u.Barcodes)); err != nil {
+			return nil, err
+		}
+		units[productID] = append(units[productID], u)
+	}
+
+	return units, rows.Err()
+}
+
+// setProductUnits mengganti semua satuan produk. Satuan dicocokkan berdasarkan nama; satuan yang
+// tidak ada lagi dihapus beserta barcode-nya.
+func setProductUnits(tx *sql.Tx, productID int, units []models.ProductUnit) error {
+	names := make([]string, 0, len(units))
+	for _, u := range units {
+		var unitID int
+		err := tx.QueryRow(`INSERT INTO product_units (product_id, name, factor, price) VALUES ($1, $2, $3, $4)
+			ON CONFLICT (product_id, name) DO UPDATE SET factor = EXCLUDED.factor, price = EXCLUDED.price RETURNING id`,
+			productID, u.Name, u.Factor, u.Price).Scan(&unitID)
+		if err != nil {
+			return err
+		}
+
+		if _, err := tx.Exec("DELETE FROM product_barcodes WHERE unit_id = $1", unitID); err != nil {
+			return err
+		}
+		for _, code := range u.Barcodes {
+			if err := insertBarcode(tx, productID, unitID, code); err != nil {
+				return err
+			}
+		}
+		names = append(names, u.Name)
+	}
+
+	_, err := tx.Exec("DELETE FROM product_units WHERE product_id = $1 AND NOT (name = ANY($2))", productID, pq.Array(names))
+	return err
+}
+
+// lookupUnit mengembalikan faktor konversi dan harga jual satuan produk
+func lookupUnit(q queryer, productID int, unit string) (factor, price int, err error) {
+	err = q.QueryRow("SELECT factor, price FROM product_units WHERE product_id = $1 AND name = $2", productID, unit).Scan(&factor, &price)
+	if err == sql.ErrNoRows {
+		return 0, 0, fmt.Errorf("unit %s not found for product id %d", unit, productID)
+	}
+	return factor, price, err
+}
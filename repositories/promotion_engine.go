@@ -0,0 +1,178 @@
+package repositories
+
+import (
+	"kasir-api/models"
+	"math"
+	"time"
+)
+
+// promoLine adalah satu baris item checkout yang dievaluasi oleh promotion engine
+type promoLine struct {
+	productID  int
+	parentID   *int // induk jika produk adalah varian
+	categoryID int
+	unitPrice  int
+	quantity   int
+	discount   int  // total diskon promo pada baris ini
+	promoted   bool // sudah mendapat promo
+	locked     bool // sudah mendapat promo yang tidak bisa digabung
+}
+
+func (l *promoLine) remaining() int {
+	return l.unitPrice*l.quantity - l.discount
+}
+
+// evaluatePromotions menerapkan promo ke baris item sesuai urutan prioritas dan mengembalikan
+// promo yang benar-benar memberi potongan. Diskon per baris tidak pernah melebihi harga baris.
+func evaluatePromotions(promotions []models.Promotion, lines []promoLine, now time.Time) []models.AppliedPromotion {
+	applied := make([]models.AppliedPromotion, 0)
+
+	for _, promo := range promotions {
+		if !withinTimeWindow(promo, now) {
+			continue
+		}
+
+		// baris yang boleh mendapat promo ini
+		eligible := make([]int, 0)
+		for i := range lines {
+			l := &lines[i]
+			if l.locked || (!promo.Stackable && l.promoted) || l.remaining() <= 0 {
+				continue
+			}
+			if promotionMatches(promo, l) {
+				eligible = append(eligible, i)
+			}
+		}
+		if len(eligible) == 0 {
+			continue
+		}
+
+		discounts := promotionDiscounts(promo, lines, eligible)
+
+		total := 0
+		for i, amount := range discounts {
+			l := &lines[i]
+			amount = min(amount, l.remaining())
+			if amount <= 0 {
+				continue
+			}
+			l.discount += amount
+			l.promoted = true
+			if !promo.Stackable {
+				l.locked = true
+			}
+			total += amount
+		}
+
+		if total > 0 {
+			applied = append(applied, models.AppliedPromotion{PromotionID: promo.ID, Name: promo.Name, DiscountAmount: total})
+		}
+	}
+
+	return applied
+}
+
+// promotionMatches mengecek apakah baris termasuk dalam cakupan promo
+func promotionMatches(promo models.Promotion, l *promoLine) bool {
+	switch promo.Type {
+	case models.PromotionTypeBundle:
+		for _, item := range promo.BundleItems {
+			if item.ProductID == l.productID {
+				return true
+			}
+		}
+		return false
+	default:
+		// promo untuk produk induk berlaku untuk semua variannya
+		if promo.ProductID != nil && *promo.ProductID != l.productID && (l.parentID == nil || *promo.ProductID != *l.parentID) {
+			return false
+		}
+		if promo.CategoryID != nil && *promo.CategoryID != l.categoryID {
+			return false
+		}
+		return true
+	}
+}
+
+// promotionDiscounts menghitung potongan promo per index baris
+func promotionDiscounts(promo models.Promotion, lines []promoLine, eligible []int) map[int]int {
+	discounts := make(map[int]int)
+
+	switch promo.Type {
+	case models.PromotionTypeBOGO:
+		// setiap kelipatan (beli + gratis) unit, sejumlah "gratis" unit tidak dibayar
+		group := promo.BuyQuantity + promo.GetQuantity
+		qty := 0
+		for _, i := range eligible {
+			qty += lines[i].quantity
+		}
+		free := qty / group * promo.GetQuantity
+		if free == 0 {
+			return discounts
+		}
+		weights := make([]int, len(eligible))
+		for n, i := range eligible {
+			weights[n] = lines[i].quantity
+		}
+		for n, units := range allocateProportionally(free, weights) {
+			i := eligible[n]
+			discounts[i] = units * lines[i].unitPrice
+		}
+
+	case models.PromotionTypeBundle:
+		// jumlah paket yang terbentuk dibatasi item bundle yang paling sedikit
+		available := make(map[int]int)
+		prices := make(map[int]int)
+		for _, i := range eligible {
+			available[lines[i].productID] += lines[i].quantity
+			prices[lines[i].productID] = lines[i].unitPrice
+		}
+		sets, normalPrice := -1, 0
+		for _, item := range promo.BundleItems {
+			n := available[item.ProductID] / item.Quantity
+			if sets == -1 || n < sets {
+				sets = n
+			}
+			normalPrice += prices[item.ProductID] * item.Quantity
+		}
+		saving := sets * (normalPrice - promo.BundlePrice)
+		if sets <= 0 || saving <= 0 {
+			return discounts
+		}
+		// bagi potongan ke baris sesuai nilai unit yang masuk paket
+		weights := make([]int, len(eligible))
+		used := make(map[int]int)
+		for _, item := range promo.BundleItems {
+			used[item.ProductID] = sets * item.Quantity
+		}
+		for n, i := range eligible {
+			units := min(lines[i].quantity, used[lines[i].productID])
+			used[lines[i].productID] -= units
+			weights[n] = units * lines[i].unitPrice
+		}
+		for n, amount := range allocateProportionally(saving, weights) {
+			discounts[eligible[n]] = amount
+		}
+
+	case models.PromotionTypeCategoryPercent, models.PromotionTypeHappyHour:
+		for _, i := range eligible {
+			discounts[i] = int(math.Round(float64(lines[i].remaining()) * promo.Percent / 100))
+		}
+	}
+
+	return discounts
+}
+
+// withinTimeWindow mengecek batas jam promo terhadap jam lokal now, jadi now harus sudah dalam zona waktu toko.
+// Jendela yang melewati tengah malam (misal 22:00-02:00) didukung.
+func withinTimeWindow(promo models.Promotion, now time.Time) bool {
+	if promo.StartTime == "" || promo.EndTime == "" {
+		return true
+	}
+
+	current := now.Format("15:04")
+	if promo.StartTime <= promo.EndTime {
+		return current >= promo.StartTime && current < promo.EndTime
+	}
+	return current >= promo.StartTime || current < promo.EndTime
+}
@@ -0,0 +1,199 @@
+package repositories
+
+import (
+	"database/sql"
+	"errors"
+	"kasir-api/models"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+type PromotionRepository struct {
+	db *sql.DB
+}
+
+func NewPromotionRepository(db *sql.DB) *PromotionRepository {
+	return &PromotionRepository{db: db}
+}
+
+// queryer dipenuhi oleh *sql.DB dan *sql.Tx
+type queryer interface {
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+const promotionColumns = `id, name, type, priority, stackable, active, product_id, category_id, buy_quantity, get_quantity,
+	bundle_price, percent, start_date, end_date, to_char(start_time, 'HH24:MI'), to_char(end_time, 'HH24:MI')`
+
+func (repo *PromotionRepository) GetAll() ([]models.Promotion, error) {
+	return queryPromotions(repo.db, "SELECT "+promotionColumns+" FROM promotions ORDER BY priority DESC, id")
+}
+
+func (repo *PromotionRepository) GetByID(id int) (*models.Promotion, error) {
+	promotions, err := queryPromotions(repo.db, "SELECT "+promotionColumns+" FROM promotions WHERE id = $1", id)
+	if err != nil {
+		return nil, err
+	}
+	if len(promotions) == 0 {
+		return nil, errors.New("promotion not found")
+	}
+
+	return &promotions[0], nil
+}
+
+func (repo *PromotionRepository) Create(promotion *models.Promotion) error {
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := `INSERT INTO promotions (name, type, priority, stackable, active, product_id, category_id, buy_quantity, get_quantity,
+		bundle_price, percent, start_date, end_date, start_time, end_time)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15) RETURNING id`
+	err = tx.QueryRow(query, promotionArgs(promotion)...).Scan(&promotion.ID)
+	if err != nil {
+		return err
+	}
+
+	if err := insertBundleItems(tx, promotion); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (repo *PromotionRepository) Update(promotion *models.Promotion) error {
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := `UPDATE promotions SET name = $1, type = $2, priority = $3, stackable = $4, active = $5, product_id = $6, category_id = $7,
+		buy_quantity = $8, get_quantity = $9, bundle_price = $10, percent = $11, start_date = $12, end_date = $13, start_time = $14, end_time = $15
+		WHERE id = $16`
+	result, err := tx.Exec(query, append(promotionArgs(promotion), promotion.ID)...)
+	if err != nil {
+		return err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return errors.New("promotion not found")
+	}
+
+	if _, err := tx.Exec("DELETE FROM promotion_bundle_items WHERE promotion_id = $1", promotion.ID); err != nil {
+		return err
+	}
+	if err := insertBundleItems(tx, promotion); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (repo *PromotionRepository) Delete(id int) error {
+	result, err := repo.db.Exec("DELETE FROM promotions WHERE id = $1", id)
+	if err != nil {
+		return err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return errors.New("promotion not found")
+	}
+
+	return nil
+}
+
+func promotionArgs(p *models.Promotion) []interface{} {
+	nullTime := func(v string) interface{} {
+		if v == "" {
+			return nil
+		}
+		return v
+	}
+	return []interface{}{p.Name, p.Type, p.Priority, p.Stackable, p.Active, p.ProductID, p.CategoryID, p.BuyQuantity, p.GetQuantity,
+		p.BundlePrice, p.Percent, p.StartDate, p.EndDate, nullTime(p.StartTime), nullTime(p.EndTime)}
+}
+
+func insertBundleItems(tx *sql.Tx, promotion *models.Promotion) error {
+	for _, item := range promotion.BundleItems {
+		_, err := tx.Exec("INSERT INTO promotion_bundle_items (promotion_id, product_id, quantity) VALUES ($1, $2, $3)", promotion.ID, item.ProductID, item.Quantity)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// loadActivePromotions mengambil promo aktif yang tanggal berlakunya mencakup waktu now. Tanggal berlaku
+// disimpan sebagai timestamptz sehingga dibandingkan sebagai titik waktu, dan now dikirim ke Postgres bersama
+// zona waktunya. Batas jam (StartTime/EndTime) dicek saat evaluasi dengan jam lokal toko.
+func loadActivePromotions(q queryer, now time.Time) ([]models.Promotion, error) {
+	return queryPromotions(q, "SELECT "+promotionColumns+` FROM promotions
+		WHERE active AND (start_date IS NULL OR start_date <= $1) AND (end_date IS NULL OR end_date >= $1)
+		ORDER BY priority DESC, id`, now)
+}
+
+// queryPromotions menjalankan query promotions beserta bundle item-nya
+func queryPromotions(q queryer, query string, args ...interface{}) ([]models.Promotion, error) {
+	rows, err := q.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	promotions := make([]models.Promotion, 0)
+	index := make(map[int]int)
+	for rows.Next() {
+		var p models.Promotion
+		var startTime, endTime sql.NullString
+		err := rows.Scan(&p.ID, &p.Name, &p.Type, &p.Priority, &p.Stackable, &p.Active, &p.ProductID, &p.CategoryID, &p.BuyQuantity, &p.GetQuantity,
+			&p.BundlePrice, &p.Percent, &p.StartDate, &p.EndDate, &startTime, &endTime)
+		if err != nil {
+			return nil, err
+		}
+		p.StartTime, p.EndTime = startTime.String, endTime.String
+		index[p.ID] = len(promotions)
+		promotions = append(promotions, p)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	rows.Close()
+
+	if len(promotions) == 0 {
+		return promotions, nil
+	}
+
+	ids := make([]int, 0, len(promotions))
+	for _, p := range promotions {
+		ids = append(ids, p.ID)
+	}
+	itemRows, err := q.Query("SELECT promotion_id, product_id, quantity FROM promotion_bundle_items WHERE promotion_id = ANY($1) ORDER BY product_id", pq.Array(ids))
+	if err != nil {
+		return nil, err
+	}
+	defer itemRows.Close()
+
+	for itemRows.Next() {
+		var promotionID int
+		var item models.PromotionBundleItem
+		if err := itemRows.Scan(&promotionID, &item.ProductID, &item.Quantity); err != nil {
+			return nil, err
+		}
+		i := index[promotionID]
+		promotions[i].BundleItems = append(promotions[i].BundleItems, item)
+	}
+
+	return promotions, itemRows.Err()
+}
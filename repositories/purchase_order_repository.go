@@ -0,0 +1,526 @@
+package repositories
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"kasir-api/models"
+	"strings"
+)
+
+type PurchaseOrderRepository struct {
+	db *sql.DB
+}
+
+func NewPurchaseOrderRepository(db *sql.DB) *PurchaseOrderRepository {
+	return &PurchaseOrderRepository{db: db}
+}
+
+const purchaseOrderColumns = "po.id, coalesce(po.outlet_id, 0), po.supplier_id, s.name, po.status, coalesce(po.note, ''), coalesce(po.created_by, ''), po.created_at, po.ordered_at, po.closed_at"
+
+func scanPurchaseOrder(scan func(dest ...interface{}) error) (models.PurchaseOrder, error) {
+	var (
+		po                  models.PurchaseOrder
+		orderedAt, closedAt sql.NullTime
+	)
+	err := scan(&po.ID, &po.OutletID, &po.SupplierID, &po.SupplierName, &po.Status, &po.Note, &po.CreatedBy, &po.CreatedAt, &orderedAt, &closedAt)
+	if orderedAt.Valid {
+		po.OrderedAt = &orderedAt.Time
+	}
+	if closedAt.Valid {
+		po.ClosedAt = &closedAt.Time
+	}
+	return po, err
+}
+
+func (repo *PurchaseOrderRepository) GetAll(filter models.PurchaseOrderFilter) ([]models.PurchaseOrder, error) {
+	var (
+		conditions []string
+		args       []interface{}
+	)
+	if filter.Status != "" {
+		args = append(args, filter.Status)
+		conditions = append(conditions, fmt.Sprintf("po.status = $%d", len(args)))
+	}
+	if filter.SupplierID != 0 {
+		args = append(args, filter.SupplierID)
+		conditions = append(conditions, fmt.Sprintf("po.supplier_id = $%d", len(args)))
+	}
+	if filter.OutletID != 0 {
+		args = append(args, filter.OutletID)
+		conditions = append(conditions, fmt.Sprintf("po.outlet_id = $%d", len(args)))
+	}
+
+	query := "SELECT " + purchaseOrderColumns + " FROM purchase_orders po JOIN suppliers s ON po.supplier_id = s.id"
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	query += " ORDER BY po.id DESC"
+
+	rows, err := repo.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	orders := make([]models.PurchaseOrder, 0)
+	for rows.Next() {
+		po, err := scanPurchaseOrder(rows.Scan)
+		if err != nil {
+			return nil, err
+		}
+		orders = append(orders, po)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for i := range orders {
+		if orders[i].Lines, err = repo.getLines(orders[i].ID); err != nil {
+			return nil, err
+		}
+		orders[i].TotalCost = totalCost(orders[i].Lines)
+		orders[i].Receipts = make([]models.GoodsReceipt, 0)
+	}
+
+	return orders, nil
+}
+
+func (repo *PurchaseOrderRepository) GetByID(id int) (*models.PurchaseOrder, error) {
+	po, err := scanPurchaseOrder(repo.db.QueryRow("SELECT "+purchaseOrderColumns+" FROM purchase_orders po JOIN suppliers s ON po.supplier_id = s.id WHERE po.id = $1", id).Scan)
+	if err == sql.ErrNoRows {
+		return nil, errors.New("purchase order not found")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if po.Lines, err = repo.getLines(id); err != nil {
+		return nil, err
+	}
+	po.TotalCost = totalCost(po.Lines)
+
+	if po.Receipts, err = repo.getReceipts(id); err != nil {
+		return nil, err
+	}
+
+	return &po, nil
+}
+
+func totalCost(lines []models.PurchaseOrderLine) int {
+	total := 0
+	for _, l := range lines {
+		total += l.Quantity * l.UnitCost
+	}
+	return total
+}
+
+func (repo *PurchaseOrderRepository) getLines(purchaseOrderID int) ([]models.PurchaseOrderLine, error) {
+	rows, err := repo.db.Query(`SELECT l.id, l.product_id, p.name, coalesce(l.unit, ''), l.unit_factor, l.quantity, l.unit_cost, l.received_quantity
+		FROM purchase_order_lines l JOIN products p ON l.product_id = p.id
+		WHERE l.purchase_order_id = $1 ORDER BY l.id`, purchaseOrderID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	lines := make([]models.PurchaseOrderLine, 0)
+	for rows.Next() {
+		var l models.PurchaseOrderLine
+		if err := rows.Scan(&l.ID, &l.ProductID, &l.ProductName, &l.Unit, &l.UnitFactor, &l.Quantity, &l.UnitCost, &l.ReceivedQuantity); err != nil {
+			return nil, err
+		}
+		lines = append(lines, l)
+	}
+
+	return lines, rows.Err()
+}
+
+func (repo *PurchaseOrderRepository) getReceipts(purchaseOrderID int) ([]models.GoodsReceipt, error) {
+	rows, err := repo.db.Query(`SELECT id, purchase_order_id, coalesce(received_by, ''), coalesce(note, ''), created_at
+		FROM goods_receipts WHERE purchase_order_id = $1 ORDER BY id`, purchaseOrderID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	receipts := make([]models.GoodsReceipt, 0)
+	index := make(map[int]int)
+	for rows.Next() {
+		var r models.GoodsReceipt
+		if err := rows.Scan(&r.ID, &r.PurchaseOrderID, &r.ReceivedBy, &r.Note, &r.CreatedAt); err != nil {
+			return nil, err
+		}
+		r.Items = make([]models.GoodsReceiptItem, 0)
+		index[r.ID] = len(receipts)
+		receipts = append(receipts, r)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	rows.Close()
+
+	itemRows, err := repo.db.Query(`SELECT i.goods_receipt_id, i.purchase_order_line_id, i.product_id, i.quantity, i.unit_cost,
+			coalesce(i.lot_number, ''), coalesce(to_char(i.expiry_date, 'YYYY-MM-DD'), '')
+		FROM goods_receipt_items i JOIN goods_receipts r ON i.goods_receipt_id = r.id
+		WHERE r.purchase_order_id = $1 ORDER BY i.id`, purchaseOrderID)
+	if err != nil {
+		return nil, err
+	}
+	defer itemRows.Close()
+
+	for itemRows.Next() {
+		var (
+			receiptID int
+			item      models.GoodsReceiptItem
+		)
+		if err := itemRows.Scan(&receiptID, &item.PurchaseOrderLineID, &item.ProductID, &item.Quantity, &item.UnitCost, &item.LotNumber, &item.ExpiryDate); err != nil {
+			return nil, err
+		}
+		i := index[receiptID]
+		receipts[i].Items = append(receipts[i].Items, item)
+	}
+
+	return receipts, itemRows.Err()
+}
+
+func (repo *PurchaseOrderRepository) Create(input *models.PurchaseOrderInput) (*models.PurchaseOrder, error) {
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	outletID, err := resolveOutlet(tx, input.OutletID)
+	if err != nil {
+		return nil, err
+	}
+
+	var id int
+	err = tx.QueryRow("INSERT INTO purchase_orders (outlet_id, supplier_id, note, created_by) VALUES ($1, $2, $3, $4) RETURNING id",
+		outletID, input.SupplierID, nullString(input.Note), nullString(input.CreatedBy)).Scan(&id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := insertPurchaseOrderLines(tx, id, input.Lines); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return repo.GetByID(id)
+}
+
+// Update mengganti supplier, catatan dan semua line PO yang masih draft
+func (repo *PurchaseOrderRepository) Update(id int, input *models.PurchaseOrderInput) (*models.PurchaseOrder, error) {
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if _, err := lockPurchaseOrder(tx, id, models.PurchaseOrderStatusDraft); err != nil {
+		return nil, err
+	}
+
+	_, err = tx.Exec("UPDATE purchase_orders SET supplier_id = $1, note = $2 WHERE id = $3", input.SupplierID, nullString(input.Note), id)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := tx.Exec("DELETE FROM purchase_order_lines WHERE purchase_order_id = $1", id); err != nil {
+		return nil, err
+	}
+	if err := insertPurchaseOrderLines(tx, id, input.Lines); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return repo.GetByID(id)
+}
+
+// Delete menghapus PO yang masih draft
+func (repo *PurchaseOrderRepository) Delete(id int) error {
+	result, err := repo.db.Exec("DELETE FROM purchase_orders WHERE id = $1 AND status = $2", id, models.PurchaseOrderStatusDraft)
+	if err != nil {
+		return err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return errors.New("purchase order not found or not a draft")
+	}
+
+	return nil
+}
+
+// Order mengirim PO draft ke supplier, setelah ini line tidak bisa diubah
+func (repo *PurchaseOrderRepository) Order(id int) (*models.PurchaseOrder, error) {
+	result, err := repo.db.Exec("UPDATE purchase_orders SET status = $1, ordered_at = now() WHERE id = $2 AND status = $3",
+		models.PurchaseOrderStatusOrdered, id, models.PurchaseOrderStatusDraft)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return nil, err
+	}
+	if rows == 0 {
+		return nil, errors.New("purchase order not found or not a draft")
+	}
+
+	return repo.GetByID(id)
+}
+
+// Receive mencatat penerimaan barang dan menambah stok semua item dalam satu transaksi database.
+// Barang masuk ke outlet PO. PO menjadi closed jika semua line sudah diterima penuh, selain itu partially_received.
+func (repo *PurchaseOrderRepository) Receive(id int, req *models.GoodsReceiptRequest) (*models.PurchaseOrder, error) {
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if _, err := lockPurchaseOrder(tx, id, models.PurchaseOrderStatusOrdered, models.PurchaseOrderStatusPartiallyReceived); err != nil {
+		return nil, err
+	}
+
+	var outletID int
+	if err := tx.QueryRow("SELECT coalesce(outlet_id, 0) FROM purchase_orders WHERE id = $1", id).Scan(&outletID); err != nil {
+		return nil, err
+	}
+	if outletID, err = resolveOutlet(tx, outletID); err != nil {
+		return nil, err
+	}
+
+	lines, err := lockPurchaseOrderLines(tx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	var receiptID int
+	err = tx.QueryRow("INSERT INTO goods_receipts (purchase_order_id, received_by, note) VALUES ($1, $2, $3) RETURNING id",
+		id, nullString(req.ReceivedBy), nullString(req.Note)).Scan(&receiptID)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, item := range req.Items {
+		line, err := findPurchaseOrderLine(lines, item)
+		if err != nil {
+			return nil, err
+		}
+		if line.ReceivedQuantity+item.Quantity > line.Quantity {
+			return nil, fmt.Errorf("received quantity for product id %d exceeds outstanding quantity %d", line.ProductID, line.Quantity-line.ReceivedQuantity)
+		}
+
+		unitCost := line.UnitCost
+		if item.UnitCost != nil {
+			unitCost = *item.UnitCost
+		}
+
+		// produk yang dilacak kedaluwarsanya selalu diterima ke lot dengan tanggal kedaluwarsa
+		var trackExpiry bool
+		if err := tx.QueryRow("SELECT track_expiry FROM products WHERE id = $1", line.ProductID).Scan(&trackExpiry); err != nil {
+			return nil, err
+		}
+		if trackExpiry && item.ExpiryDate == "" {
+			return nil, fmt.Errorf("expiry_date is required for product id %d", line.ProductID)
+		}
+		var lot *stockLotKey
+		if item.LotNumber != "" || item.ExpiryDate != "" {
+			lot = &stockLotKey{number: item.LotNumber, expiry: item.ExpiryDate}
+		}
+
+		// quantity line dalam satuan beli, stok dan harga pokok dalam satuan dasar
+		baseQuantity := item.Quantity * line.UnitFactor
+		if err := updateMovingAverageCost(tx, line.ProductID, baseQuantity, item.Quantity*unitCost); err != nil {
+			return nil, err
+		}
+		_, err = applyStockChange(tx, stockChange{
+			outletID:      outletID,
+			productID:     line.ProductID,
+			quantity:      baseQuantity,
+			movementType:  models.StockMovementReceiving,
+			reason:        fmt.Sprintf("purchase order #%d", id),
+			referenceType: "goods_receipt",
+			referenceID:   receiptID,
+			user:          req.ReceivedBy,
+			lot:           lot,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		_, err = tx.Exec("INSERT INTO goods_receipt_items (goods_receipt_id, purchase_order_line_id, product_id, quantity, unit_cost, lot_number, expiry_date) VALUES ($1, $2, $3, $4, $5, $6, $7)",
+			receiptID, line.ID, line.ProductID, item.Quantity, unitCost, nullString(item.LotNumber), nullString(item.ExpiryDate))
+		if err != nil {
+			return nil, err
+		}
+		if _, err := tx.Exec("UPDATE purchase_order_lines SET received_quantity = received_quantity + $1 WHERE id = $2", item.Quantity, line.ID); err != nil {
+			return nil, err
+		}
+		line.ReceivedQuantity += item.Quantity
+	}
+
+	status := models.PurchaseOrderStatusClosed
+	for _, line := range lines {
+		if line.ReceivedQuantity < line.Quantity {
+			status = models.PurchaseOrderStatusPartiallyReceived
+			break
+		}
+	}
+
+	query := "UPDATE purchase_orders SET status = $1 WHERE id = $2"
+	if status == models.PurchaseOrderStatusClosed {
+		query = "UPDATE purchase_orders SET status = $1, closed_at = now() WHERE id = $2"
+	}
+	if _, err := tx.Exec(query, status, id); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return repo.GetByID(id)
+}
+
+// Close menutup PO yang sudah ordered walaupun masih ada sisa yang belum diterima
+func (repo *PurchaseOrderRepository) Close(id int) (*models.PurchaseOrder, error) {
+	result, err := repo.db.Exec("UPDATE purchase_orders SET status = $1, closed_at = now() WHERE id = $2 AND status IN ($3, $4)",
+		models.PurchaseOrderStatusClosed, id, models.PurchaseOrderStatusOrdered, models.PurchaseOrderStatusPartiallyReceived)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return nil, err
+	}
+	if rows == 0 {
+		return nil, errors.New("purchase order not found or not open for receiving")
+	}
+
+	return repo.GetByID(id)
+}
+
+// insertPurchaseOrderLines menyimpan line PO beserta faktor konversi satuannya saat PO dibuat
+func insertPurchaseOrderLines(tx *sql.Tx, purchaseOrderID int, lines []models.PurchaseOrderLineInput) error {
+	for _, line := range lines {
+		factor := 1
+		if line.Unit != "" {
+			var err error
+			if factor, _, err = lookupUnit(tx, line.ProductID, line.Unit); err != nil {
+				return err
+			}
+		}
+
+		_, err := tx.Exec("INSERT INTO purchase_order_lines (purchase_order_id, product_id, unit, unit_factor, quantity, unit_cost) VALUES ($1, $2, $3, $4, $5, $6)",
+			purchaseOrderID, line.ProductID, nullString(line.Unit), factor, line.Quantity, line.UnitCost)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// lockPurchaseOrder mengunci PO dan memastikan statusnya salah satu dari allowed
+func lockPurchaseOrder(tx *sql.Tx, id int, allowed ...string) (string, error) {
+	var status string
+	err := tx.QueryRow("SELECT status FROM purchase_orders WHERE id = $1 FOR UPDATE", id).Scan(&status)
+	if err == sql.ErrNoRows {
+		return "", errors.New("purchase order not found")
+	}
+	if err != nil {
+		return "", err
+	}
+	for _, s := range allowed {
+		if status == s {
+			return status, nil
+		}
+	}
+	return "", fmt.Errorf("purchase order is %s", status)
+}
+
+func lockPurchaseOrderLines(tx *sql.Tx, purchaseOrderID int) ([]*models.PurchaseOrderLine, error) {
+	rows, err := tx.Query("SELECT id, product_id, unit_factor, quantity, unit_cost, received_quantity FROM purchase_order_lines WHERE purchase_order_id = $1 ORDER BY id FOR UPDATE", purchaseOrderID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	lines := make([]*models.PurchaseOrderLine, 0)
+	for rows.Next() {
+		var l models.PurchaseOrderLine
+		if err := rows.Scan(&l.ID, &l.ProductID, &l.UnitFactor, &l.Quantity, &l.UnitCost, &l.ReceivedQuantity); err != nil {
+			return nil, err
+		}
+		lines = append(lines, &l)
+	}
+
+	return lines, rows.Err()
+}
+
+// findPurchaseOrderLine mencari line berdasarkan id line, atau berdasarkan product id jika id line kosong
+func findPurchaseOrderLine(lines []*models.PurchaseOrderLine, item models.GoodsReceiptItemRequest) (*models.PurchaseOrderLine, error) {
+	var found *models.PurchaseOrderLine
+	for _, line := range lines {
+		if item.PurchaseOrderLineID != 0 {
+			if line.ID == item.PurchaseOrderLineID {
+				return line, nil
+			}
+			continue
+		}
+		if line.ProductID != item.ProductID {
+			continue
+		}
+		if found != nil {
+			return nil, fmt.Errorf("product id %d appears on several lines, use purchase_order_line_id", item.ProductID)
+		}
+		found = line
+	}
+
+	if found == nil {
+		if item.PurchaseOrderLineID != 0 {
+			return nil, fmt.Errorf("purchase order line id %d not found", item.PurchaseOrderLineID)
+		}
+		return nil, fmt.Errorf("product id %d is not on this purchase order", item.ProductID)
+	}
+	return found, nil
+}
+
+// updateMovingAverageCost menghitung ulang harga pokok produk dengan rata-rata bergerak sebelum stok yang diterima ditambahkan:
+// (stok x harga pokok lama + total harga beli) / (stok + quantity), quantity dalam satuan dasar. Stok negatif dianggap 0.
+func updateMovingAverageCost(tx *sql.Tx, productID, quantity, cost int) error {
+	var stock, costPrice int
+	err := tx.QueryRow("SELECT coalesce(stock, 0), cost_price FROM products WHERE id = $1 FOR UPDATE", productID).Scan(&stock, &costPrice)
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("product id %d not found", productID)
+	}
+	if err != nil {
+		return err
+	}
+
+	if stock < 0 {
+		stock = 0
+	}
+	total := stock + quantity
+	if total <= 0 {
+		return nil
+	}
+	// dibulatkan ke rupiah terdekat
+	average := (stock*costPrice + cost + total/2) / total
+
+	_, err = tx.Exec("UPDATE products SET cost_price = $1 WHERE id = $2", average, productID)
+	return err
+}
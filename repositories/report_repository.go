@@ -2,7 +2,10 @@ package repositories
 
 import (
 	"database/sql"
+	"fmt"
 	"kasir-api/models"
+	"math"
+	"sort"
 )
 
 type ReportRepository struct {
@@ -13,14 +16,22 @@ func NewReportRepository(db *sql.DB) *ReportRepository {
 	return &ReportRepository{db: db}
 }
 
-func (repo *ReportRepository) GetReportToday() (*models.Report, error) {
+// outletCondition membatasi laporan ke satu outlet, parameter outlet 0 berarti konsolidasi semua outlet
+const outletCondition = "($%[1]d::bigint = 0 or t.outlet_id = $%[1]d)"
+
+func (repo *ReportRepository) GetReportToday(outletID int) (*models.Report, error) {
 	// query for total revenue and total transaksi
 	var totalRevenue, totalTransaksi int
 	err := repo.db.QueryRow(`
-	       select coalesce(sum(total_amount),0) as total_revenue, count(id) as total_transaksi
-	       from transactions
-	       where date(created_at) = current_date;
-       `).Scan(&totalRevenue, &totalTransaksi)
+	       select coalesce(sum(total_amount - refunded_amount),0) as total_revenue, count(id) as total_transaksi
+	       from transactions t
+	       where date(created_at) = current_date and status <> 'voided' and `+fmt.Sprintf(outletCondition, 1)+`;
+       `, outletID).Scan(&totalRevenue, &totalTransaksi)
+	if err != nil {
+		return nil, err
+	}
+
+	penjualanKotor, totalDiskon, err := repo.getSalesSummary("date(t.created_at) = current_date and "+fmt.Sprintf(outletCondition, 1), outletID)
 	if err != nil {
 		return nil, err
 	}
@@ -29,15 +40,16 @@ func (repo *ReportRepository) GetReportToday() (*models.Report, error) {
 	var nama string
 	var qtyTerjual int
 	err = repo.db.QueryRow(`
-	       select p.name, coalesce(sum(td.quantity),0) as qty_terjual
+	       select coalesce(td.product_name, p.name, '') as nama, coalesce(sum((td.quantity - td.refunded_quantity) * td.unit_factor),0) as qty_terjual
 	       from transaction_details td
-	       join products p on td.product_id = p.id
+	       left join products p on td.product_id = p.id
 	       join transactions t on td.transaction_id = t.id
-	       where date(t.created_at) = current_date
-	       group by p.name
+	       where date(t.created_at) = current_date and t.status <> 'voided' and `+fmt.Sprintf(outletCondition, 1)+`
+	       group by coalesce(td.product_name, p.name, '')
+	       having sum(td.quantity - td.refunded_quantity) > 0
 	       order by qty_terjual desc
 	       limit 1;
-       `).Scan(&nama, &qtyTerjual)
+       `, outletID).Scan(&nama, &qtyTerjual)
 	if err == sql.ErrNoRows {
 		nama = ""
 		qtyTerjual = 0
@@ -45,25 +57,47 @@ func (repo *ReportRepository) GetReportToday() (*models.Report, error) {
 		return nil, err
 	}
 
+	pembayaran, err := repo.getPaymentSummary("date(t.created_at) = current_date and "+fmt.Sprintf(outletCondition, 1), outletID)
+	if err != nil {
+		return nil, err
+	}
+
+	penjualan, hpp, err := repo.getProfitSummary("date(t.created_at) = current_date and "+fmt.Sprintf(outletCondition, 1), outletID)
+	if err != nil {
+		return nil, err
+	}
+
 	report := &models.Report{
-		TotalRevenue:   totalRevenue,
-		TotalTransaksi: totalTransaksi,
+		TotalRevenue:    totalRevenue,
+		TotalTransaksi:  totalTransaksi,
+		PenjualanKotor:  penjualanKotor,
+		TotalDiskon:     totalDiskon,
+		PenjualanBersih: penjualanKotor - totalDiskon,
+		TotalHPP:        hpp,
+		LabaKotor:       penjualan - hpp,
+		Margin:          margin(penjualan, hpp),
 		ProdukTerlaris: models.ProdukTerlaris{
 			Nama:       nama,
 			QtyTerjual: qtyTerjual,
 		},
+		Pembayaran: pembayaran,
 	}
 	return report, nil
 }
 
-func (repo *ReportRepository) GetReportByDate(startDate string, endDate string) (*models.Report, error) {
+func (repo *ReportRepository) GetReportByDate(startDate string, endDate string, outletID int) (*models.Report, error) {
 	// query for total revenue and total transaksi
 	var totalRevenue, totalTransaksi int
 	err := repo.db.QueryRow(`
-		select coalesce(sum(total_amount),0) as total_revenue, count(id) as total_transaksi
-		from transactions
-		where date(created_at) between $1 and $2;
-	`, startDate, endDate).Scan(&totalRevenue, &totalTransaksi)
+		select coalesce(sum(total_amount - refunded_amount),0) as total_revenue, count(id) as total_transaksi
+		from transactions t
+		where date(created_at) between $1 and $2 and status <> 'voided' and `+fmt.Sprintf(outletCondition, 3)+`;
+	`, startDate, endDate, outletID).Scan(&totalRevenue, &totalTransaksi)
+	if err != nil {
+		return nil, err
+	}
+
+	penjualanKotor, totalDiskon, err := repo.getSalesSummary("date(t.created_at) between $1 and $2 and "+fmt.Sprintf(outletCondition, 3), startDate, endDate, outletID)
 	if err != nil {
 		return nil, err
 	}
@@ -72,15 +106,16 @@ func (repo *ReportRepository) GetReportByDate(startDate string, endDate string)
 	var nama string
 	var qtyTerjual int
 	err = repo.db.QueryRow(`
-		select p.name, coalesce(sum(td.quantity),0) as qty_terjual
+		select coalesce(td.product_name, p.name, '') as nama, coalesce(sum((td.quantity - td.refunded_quantity) * td.unit_factor),0) as qty_terjual
 		from transaction_details td
-		join products p on td.product_id = p.id
+		left join products p on td.product_id = p.id
 		join transactions t on td.transaction_id = t.id
-		where date(t.created_at) between $1 and $2
-		group by p.name
+		where date(t.created_at) between $1 and $2 and t.status <> 'voided' and `+fmt.Sprintf(outletCondition, 3)+`
+		group by coalesce(td.product_name, p.name, '')
+		having sum(td.quantity - td.refunded_quantity) > 0
 		order by qty_terjual desc
 		limit 1;
-	`, startDate, endDate).Scan(&nama, &qtyTerjual)
+	`, startDate, endDate, outletID).Scan(&nama, &qtyTerjual)
 	if err == sql.ErrNoRows {
 		nama = ""
 		qtyTerjual = 0
@@ -88,13 +123,286 @@ func (repo *ReportRepository) GetReportByDate(startDate string, endDate string)
 		return nil, err
 	}
 
+	pembayaran, err := repo.getPaymentSummary("date(t.created_at) between $1 and $2 and "+fmt.Sprintf(outletCondition, 3), startDate, endDate, outletID)
+	if err != nil {
+		return nil, err
+	}
+
+	penjualan, hpp, err := repo.getProfitSummary("date(t.created_at) between $1 and $2 and "+fmt.Sprintf(outletCondition, 3), startDate, endDate, outletID)
+	if err != nil {
+		return nil, err
+	}
+
 	report := &models.Report{
-		TotalRevenue:   totalRevenue,
-		TotalTransaksi: totalTransaksi,
+		TotalRevenue:    totalRevenue,
+		TotalTransaksi:  totalTransaksi,
+		PenjualanKotor:  penjualanKotor,
+		TotalDiskon:     totalDiskon,
+		PenjualanBersih: penjualanKotor - totalDiskon,
+		TotalHPP:        hpp,
+		LabaKotor:       penjualan - hpp,
+		Margin:          margin(penjualan, hpp),
 		ProdukTerlaris: models.ProdukTerlaris{
 			Nama:       nama,
 			QtyTerjual: qtyTerjual,
 		},
+		Pembayaran: pembayaran,
+	}
+	return report, nil
+}
+
+// getSalesSummary menghitung penjualan kotor dan total diskon (promo, diskon baris, diskon transaksi dan voucher)
+// per baris detail untuk transaksi yang tidak di-void, dikurangi proporsional terhadap quantity yang di-refund
+func (repo *ReportRepository) getSalesSummary(condition string, args ...interface{}) (int, int, error) {
+	var penjualanKotor, totalDiskon int
+	err := repo.db.QueryRow(`
+		select coalesce(round(sum(td.unit_price * td.quantity * (td.quantity - td.refunded_quantity)::numeric / td.quantity)),0) as penjualan_kotor,
+		       coalesce(round(sum((td.promotion_discount + td.discount_amount + td.allocated_discount) * (td.quantity - td.refunded_quantity)::numeric / td.quantity)),0) as total_diskon
+		from transaction_details td
+		join transactions t on td.transaction_id = t.id
+		where `+condition+` and t.status <> 'voided';
+	`, args...).Scan(&penjualanKotor, &totalDiskon)
+	return penjualanKotor, totalDiskon, err
+}
+
+// getPaymentSummary menghitung revenue per metode pembayaran untuk transaksi yang tidak di-void. Refund
+// dibagi ke pembayaran transaksinya secara proporsional; pembulatan dihitung dari jumlah kumulatif supaya
+// bagian refund per transaksi tepat sama dengan refunded_amount dan total per metode cocok dengan total_revenue.
+func (repo *ReportRepository) getPaymentSummary(condition string, args ...interface{}) ([]models.ReportPayment, error) {
+	rows, err := repo.db.Query(`
+		select method, coalesce(sum(net - refund),0)::bigint as total, count(distinct transaction_id) as total_transaksi
+		from (
+			select tp.method, tp.transaction_id, tp.amount - tp.change_amount as net,
+			       coalesce(round(t.refunded_amount::numeric * sum(tp.amount - tp.change_amount) over w / nullif(sum(tp.amount - tp.change_amount) over p, 0))
+			              - round(t.refunded_amount::numeric * (sum(tp.amount - tp.change_amount) over w - (tp.amount - tp.change_amount)) / nullif(sum(tp.amount - tp.change_amount) over p, 0)), 0) as refund
+			from transaction_payments tp
+			join transactions t on tp.transaction_id = t.id
+			where `+condition+` and t.status <> 'voided'
+			window p as (partition by tp.transaction_id), w as (partition by tp.transaction_id order by tp.id)
+		) payments
+		group by method
+		order by total desc;
+	`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	pembayaran := make([]models.ReportPayment, 0)
+	for rows.Next() {
+		var p models.ReportPayment
+		if err := rows.Scan(&p.Metode, &p.Total, &p.TotalTransaksi); err != nil {
+			return nil, err
+		}
+		pembayaran = append(pembayaran, p)
+	}
+
+	return pembayaran, rows.Err()
+}
+
+// GetTaxReport merangkum DPP, pajak dan service charge per tarif. Nilai baris yang sudah di-refund
+// dikurangi secara proporsional terhadap quantity yang di-refund.
+func (repo *ReportRepository) GetTaxReport(startDate string, endDate string, outletID int) (*models.TaxReport, error) {
+	report := &models.TaxReport{
+		StartDate: startDate,
+		EndDate:   endDate,
+		OutletID:  outletID,
+		PerTarif:  make([]models.TaxRateReport, 0),
+	}
+
+	err := repo.db.QueryRow(`
+		select count(id) as total_transaksi
+		from transactions t
+		where date(created_at) between $1 and $2 and status <> 'voided' and `+fmt.Sprintf(outletCondition, 3)+`;
+	`, startDate, endDate, outletID).Scan(&report.TotalTransaksi)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := repo.db.Query(`
+		select td.tax_rate,
+		       coalesce(round(sum(td.taxable_amount * (td.quantity - td.refunded_quantity)::numeric / td.quantity)),0) as dpp,
+		       coalesce(round(sum(td.tax_amount * (td.quantity - td.refunded_quantity)::numeric / td.quantity)),0) as total_pajak,
+		       coalesce(round(sum(td.service_charge_amount * (td.quantity - td.refunded_quantity)::numeric / td.quantity)),0) as service_charge
+		from transaction_details td
+		join transactions t on td.transaction_id = t.id
+		where date(t.created_at) between $1 and $2 and t.status <> 'voided' and `+fmt.Sprintf(outletCondition, 3)+`
+		group by td.tax_rate
+		order by td.tax_rate desc;
+	`, startDate, endDate, outletID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var r models.TaxRateReport
+		var serviceCharge int
+		if err := rows.Scan(&r.Tarif, &r.DPP, &r.TotalPajak, &serviceCharge); err != nil {
+			return nil, err
+		}
+		report.DPP += r.DPP
+		report.TotalPajak += r.TotalPajak
+		report.ServiceCharge += serviceCharge
+		report.PerTarif = append(report.PerTarif, r)
+	}
+
+	return report, rows.Err()
+}
+
+// profitColumns menghitung penjualan bersih tanpa pajak dan service charge serta HPP per baris detail,
+// dikurangi secara proporsional terhadap quantity yang di-refund
+const profitColumns = `
+	coalesce(sum((td.quantity - td.refunded_quantity) * td.unit_factor),0) as qty_terjual,
+	coalesce(round(sum((td.line_total - td.tax_amount - td.service_charge_amount) * (td.quantity - td.refunded_quantity)::numeric / td.quantity)),0) as penjualan,
+	coalesce(sum(td.unit_cost * (td.quantity - td.refunded_quantity)),0) as hpp`
+
+// getProfitSummary menghitung total penjualan bersih dan HPP untuk transaksi yang tidak di-void
+func (repo *ReportRepository) getProfitSummary(condition string, args ...interface{}) (int, int, error) {
+	var qty, penjualan, hpp int
+	err := repo.db.QueryRow(`
+		select `+profitColumns+`
+		from transaction_details td
+		join transactions t on td.transaction_id = t.id
+		where `+condition+` and t.status <> 'voided';
+	`, args...).Scan(&qty, &penjualan, &hpp)
+	return penjualan, hpp, err
+}
+
+// GetProfitReport merangkum penjualan, HPP dan laba kotor per produk, kategori atau hari. categoryID membatasi
+// laporan ke produk dalam kategori itu beserta sub-kategorinya (menurut kategori produk saat ini), 0 berarti semua.
+func (repo *ReportRepository) GetProfitReport(startDate, endDate, groupBy string, outletID, categoryID int) (*models.ProfitReport, error) {
+	var group string
+	switch groupBy {
+	case models.ProfitGroupCategory:
+		group = "coalesce(nullif(td.category_name, ''), 'Tanpa kategori')"
+	case models.ProfitGroupDay:
+		group = "to_char(t.created_at, 'YYYY-MM-DD')"
+	default:
+		group = "coalesce(td.product_name, p.name, '')"
+	}
+
+	rows, err := repo.db.Query(`
+		select `+group+` as nama, `+profitColumns+`
+		from transaction_details td
+		left join products p on td.product_id = p.id
+		join transactions t on td.transaction_id = t.id
+		where date(t.created_at) between $1 and $2 and t.status <> 'voided' and `+fmt.Sprintf(outletCondition, 3)+` and `+inCategory("p.category_id", 4)+`
+		group by `+group+`
+		having sum(td.quantity - td.refunded_quantity) > 0
+		order by nama;
+	`, startDate, endDate, outletID, categoryID)
+	if err != nil {
+		return nil, err
 	}
+	defer rows.Close()
+
+	report := &models.ProfitReport{
+		StartDate:  startDate,
+		EndDate:    endDate,
+		GroupBy:    groupBy,
+		OutletID:   outletID,
+		CategoryID: categoryID,
+		Rincian:    make([]models.ProfitRow, 0),
+	}
+	for rows.Next() {
+		var r models.ProfitRow
+		if err := rows.Scan(&r.Nama, &r.QtyTerjual, &r.Penjualan, &r.HPP); err != nil {
+			return nil, err
+		}
+		r.LabaKotor = r.Penjualan - r.HPP
+		r.Margin = margin(r.Penjualan, r.HPP)
+		report.Penjualan += r.Penjualan
+		report.HPP += r.HPP
+		report.Rincian = append(report.Rincian, r)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	report.LabaKotor = report.Penjualan - report.HPP
+	report.Margin = margin(report.Penjualan, report.HPP)
+
+	// per hari diurutkan berdasarkan tanggal, selain itu dari laba kotor terbesar
+	if groupBy != models.ProfitGroupDay {
+		sort.SliceStable(report.Rincian, func(i, j int) bool {
+			return report.Rincian[i].LabaKotor > report.Rincian[j].LabaKotor
+		})
+	}
+
 	return report, nil
 }
+
+// margin mengembalikan laba kotor dibagi penjualan dalam persen, dibulatkan 2 desimal
+func margin(penjualan, hpp int) float64 {
+	if penjualan == 0 {
+		return 0
+	}
+	return math.Round(float64(penjualan-hpp)/float64(penjualan)*10000) / 100
+}
+
+// GetIngredientReport merangkum produk komposisi yang terjual dan bahan yang dipakainya dari snapshot resep saat checkout
+func (repo *ReportRepository) GetIngredientReport(startDate string, endDate string, outletID int) (*models.IngredientReport, error) {
+	report := &models.IngredientReport{
+		StartDate:     startDate,
+		EndDate:       endDate,
+		OutletID:      outletID,
+		MenuTerjual:   make([]models.MenuSoldRow, 0),
+		BahanTerpakai: make([]models.IngredientRow, 0),
+	}
+
+	rows, err := repo.db.Query(`
+		select td.product_id, max(coalesce(td.product_name, p.name, '')) as nama,
+		       sum((td.quantity - td.refunded_quantity) * td.unit_factor) as qty_terjual
+		from transaction_details td
+		left join products p on td.product_id = p.id
+		join transactions t on td.transaction_id = t.id
+		where date(t.created_at) between $1 and $2 and t.status <> 'voided' and `+fmt.Sprintf(outletCondition, 3)+`
+		  and exists (select 1 from transaction_detail_components tdc where tdc.transaction_detail_id = td.id)
+		group by td.product_id
+		having sum(td.quantity - td.refunded_quantity) > 0
+		order by qty_terjual desc, nama;
+	`, startDate, endDate, outletID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var r models.MenuSoldRow
+		if err := rows.Scan(&r.ProductID, &r.Nama, &r.QtyTerjual); err != nil {
+			return nil, err
+		}
+		report.MenuTerjual = append(report.MenuTerjual, r)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	rows.Close()
+
+	// quantity bahan di snapshot untuk satu quantity baris
+	rows, err = repo.db.Query(`
+		select tdc.product_id, tdc.product_name as nama,
+		       coalesce(sum(tdc.quantity * (td.quantity - td.refunded_quantity)),0) as qty,
+		       coalesce(sum(tdc.unit_cost * tdc.quantity * (td.quantity - td.refunded_quantity)),0) as hpp
+		from transaction_detail_components tdc
+		join transaction_details td on tdc.transaction_detail_id = td.id
+		join transactions t on td.transaction_id = t.id
+		where date(t.created_at) between $1 and $2 and t.status <> 'voided' and `+fmt.Sprintf(outletCondition, 3)+`
+		group by tdc.product_id, tdc.product_name
+		having sum(td.quantity - td.refunded_quantity) > 0
+		order by qty desc, nama;
+	`, startDate, endDate, outletID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var r models.IngredientRow
+		if err := rows.Scan(&r.ProductID, &r.Nama, &r.Qty, &r.HPP); err != nil {
+			return nil, err
+		}
+		report.BahanTerpakai = append(report.BahanTerpakai, r)
+	}
+	return report, rows.Err()
+}
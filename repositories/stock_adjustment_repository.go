@@ -0,0 +1,234 @@
+package repositories
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"kasir-api/models"
+)
+
+type StockAdjustmentRepository struct {
+	db *sql.DB
+}
+
+func NewStockAdjustmentRepository(db *sql.DB) *StockAdjustmentRepository {
+	return &StockAdjustmentRepository{db: db}
+}
+
+// Create membukukan semua item penyesuaian sekaligus, gagal satu maka semua dibatalkan
+func (repo *StockAdjustmentRepository) Create(req *models.StockAdjustmentRequest) (*models.StockAdjustment, error) {
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	outletID, err := resolveOutlet(tx, req.OutletID)
+	if err != nil {
+		return nil, err
+	}
+
+	var id int
+	err = tx.QueryRow("INSERT INTO stock_adjustments (outlet_id, reason, note, adjusted_by) VALUES ($1, $2, $3, $4) RETURNING id",
+		outletID, req.Reason, nullString(req.Note), nullString(req.AdjustedBy)).Scan(&id)
+	if err != nil {
+		return nil, err
+	}
+
+	sign := -1
+	if req.Reason == models.AdjustmentReasonFound {
+		sign = 1
+	}
+
+	for _, item := range req.Items {
+		reason := req.Reason
+		if req.Note != "" {
+			reason += ": " + req.Note
+		}
+		var lotID int
+		if item.LotID != nil {
+			lotID = *item.LotID
+		}
+		balance, err := applyStockChange(tx, stockChange{
+			outletID:      outletID,
+			productID:     item.ProductID,
+			quantity:      sign * item.Quantity,
+			movementType:  models.StockMovementAdjustment,
+			reason:        reason,
+			referenceType: "stock_adjustment",
+			referenceID:   id,
+			user:          req.AdjustedBy,
+			lotID:         lotID,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		_, err = tx.Exec("INSERT INTO stock_adjustment_items (adjustment_id, product_id, quantity, balance, lot_id) VALUES ($1, $2, $3, $4, $5)", id, item.ProductID, item.Quantity, balance, item.LotID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return repo.GetByID(id)
+}
+
+func (repo *StockAdjustmentRepository) GetByID(id int) (*models.StockAdjustment, error) {
+	var a models.StockAdjustment
+	err := repo.db.QueryRow("SELECT id, coalesce(outlet_id, 0), reason, coalesce(note, ''), coalesce(adjusted_by, ''), created_at FROM stock_adjustments WHERE id = $1", id).
+		Scan(&a.ID, &a.OutletID, &a.Reason, &a.Note, &a.AdjustedBy, &a.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, errors.New("stock adjustment not found")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := repo.db.Query(`SELECT i.product_id, p.name, i.lot_id, i.quantity, i.balance
+		FROM stock_adjustment_items i JOIN products p ON i.product_id = p.id
+		WHERE i.adjustment_id = $1 ORDER BY i.product_id`, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	a.Items = make([]models.StockAdjustmentItem, 0)
+	for rows.Next() {
+		var item models.StockAdjustmentItem
+		if err := rows.Scan(&item.ProductID, &item.ProductName, &item.LotID, &item.Quantity, &item.Balance); err != nil {
+			return nil, err
+		}
+		a.Items = append(a.Items, item)
+	}
+
+	return &a, rows.Err()
+}
+
+// WriteOffExpiredLots mengeluarkan stok semua lot yang sudah lewat tanggal kedaluwarsa sebagai penyesuaian
+// dengan alasan expired, satu dokumen per outlet. Lot diproses urut product id supaya urutan penguncian
+// produk konsisten dengan checkout. Mengembalikan penyesuaian yang dibuat.
+func (repo *StockAdjustmentRepository) WriteOffExpiredLots() ([]models.StockAdjustment, error) {
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.Query(`SELECT id, outlet_id, product_id, lot_number, to_char(expiry_date, 'YYYY-MM-DD')
+		FROM stock_lots WHERE quantity > 0 AND expiry_date < current_date ORDER BY product_id, expiry_date, id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	type expiredLot struct {
+		id, outletID, productID int
+		number, expiry          string
+	}
+	var lots []expiredLot
+	for rows.Next() {
+		var l expiredLot
+		if err := rows.Scan(&l.id, &l.outletID, &l.productID, &l.number, &l.expiry); err != nil {
+			return nil, err
+		}
+		lots = append(lots, l)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	rows.Close()
+
+	type itemKey struct{ adjustmentID, productID int }
+	adjustments := make(map[int]int)
+	var adjustmentIDs []int
+	var keys []itemKey
+	items := make(map[itemKey]*models.StockAdjustmentItem)
+
+	for _, l := range lots {
+		// kunci produk dulu, baru baca sisa lot yang mungkin sudah berubah sejak dipilih
+		if _, err := lockProductStock(tx, l.outletID, l.productID); err != nil {
+			return nil, err
+		}
+		var quantity int
+		if err := tx.QueryRow("SELECT quantity FROM stock_lots WHERE id = $1 FOR UPDATE", l.id).Scan(&quantity); err != nil {
+			return nil, err
+		}
+		if quantity == 0 {
+			continue
+		}
+
+		id, ok := adjustments[l.outletID]
+		if !ok {
+			err := tx.QueryRow("INSERT INTO stock_adjustments (outlet_id, reason, note, adjusted_by) VALUES ($1, $2, $3, $4) RETURNING id",
+				l.outletID, models.AdjustmentReasonExpired, "automatic write-off of expired lots", "system").Scan(&id)
+			if err != nil {
+				return nil, err
+			}
+			adjustments[l.outletID] = id
+			adjustmentIDs = append(adjustmentIDs, id)
+		}
+
+		reason := fmt.Sprintf("%s: lot %s expired on %s", models.AdjustmentReasonExpired, l.number, l.expiry)
+		if l.number == "" {
+			reason = fmt.Sprintf("%s: expired on %s", models.AdjustmentReasonExpired, l.expiry)
+		}
+		balance, err := applyStockChange(tx, stockChange{
+			outletID:      l.outletID,
+			productID:     l.productID,
+			quantity:      -quantity,
+			movementType:  models.StockMovementAdjustment,
+			reason:        reason,
+			referenceType: "stock_adjustment",
+			referenceID:   id,
+			user:          "system",
+			lotID:         l.id,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		// beberapa lot dari produk yang sama digabung menjadi satu item penyesuaian
+		key := itemKey{adjustmentID: id, productID: l.productID}
+		item, ok := items[key]
+		if !ok {
+			item = &models.StockAdjustmentItem{ProductID: l.productID}
+			items[key] = item
+			keys = append(keys, key)
+		}
+		item.Quantity += quantity
+		item.Balance = balance
+		if ok {
+			item.LotID = nil
+		} else {
+			lotID := l.id
+			item.LotID = &lotID
+		}
+	}
+
+	for _, key := range keys {
+		item := items[key]
+		_, err := tx.Exec("INSERT INTO stock_adjustment_items (adjustment_id, product_id, quantity, balance, lot_id) VALUES ($1, $2, $3, $4, $5)",
+			key.adjustmentID, item.ProductID, item.Quantity, item.Balance, item.LotID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	result := make([]models.StockAdjustment, 0, len(adjustmentIDs))
+	for _, id := range adjustmentIDs {
+		adjustment, err := repo.GetByID(id)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, *adjustment)
+	}
+	return result, nil
+}
@@ -0,0 +1,225 @@
+package repositories
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"kasir-api/models"
+)
+
+// stockLotKey menunjuk lot lewat nomor lot dan tanggal kedaluwarsa (YYYY-MM-DD), keduanya boleh kosong
+type stockLotKey struct {
+	number string
+	expiry string
+}
+
+// applyLotChange membukukan perubahan stok produk yang dilacak kedaluwarsanya ke lot-lot di outlet dan
+// mencatat pembagiannya di stock_movement_lots. Stok keluar diambil dari lot change.lotID atau FEFO.
+// Stok masuk ke lot change.lot; tanpa lot, stok yang kembali dari dokumen yang sama (refund, void, transfer)
+// masuk lagi ke lot asalnya dan sisanya ke lot tanpa tanggal kedaluwarsa.
+func applyLotChange(tx *sql.Tx, change stockChange, movementID int) error {
+	if change.quantity < 0 {
+		return takeFromLots(tx, change, movementID)
+	}
+	if change.lotID != 0 {
+		return errors.New("lot can only be chosen when reducing stock")
+	}
+
+	remaining := change.quantity
+	if change.lot == nil && change.referenceType != "" && change.referenceID != 0 {
+		returned, err := outstandingLots(tx, change)
+		if err != nil {
+			return err
+		}
+		for _, lot := range returned {
+			quantity := min(remaining, lot.quantity)
+			if err := addToLot(tx, change, lot.key, quantity, movementID); err != nil {
+				return err
+			}
+			if remaining -= quantity; remaining == 0 {
+				return nil
+			}
+		}
+	}
+
+	var key stockLotKey
+	if change.lot != nil {
+		key = *change.lot
+	}
+	return addToLot(tx, change, key, remaining, movementID)
+}
+
+// takeFromLots mengurangi lot untuk stok keluar, lot yang paling cepat kedaluwarsa diambil lebih dulu.
+// Lot yang sudah kedaluwarsa tidak ikut dijual atau dikirim; hanya penyesuaian stok yang boleh mengambilnya.
+func takeFromLots(tx *sql.Tx, change stockChange, movementID int) error {
+	remaining := -change.quantity
+	allowExpired := change.movementType == models.StockMovementAdjustment
+
+	if change.lotID != 0 {
+		result, err := tx.Exec("UPDATE stock_lots SET quantity = quantity - $1 WHERE id = $2 AND outlet_id = $3 AND product_id = $4 AND quantity >= $1",
+			remaining, change.lotID, change.outletID, change.productID)
+		if err != nil {
+			return err
+		}
+		rows, err := result.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if rows == 0 {
+			return fmt.Errorf("insufficient stock in lot id %d for product id %d", change.lotID, change.productID)
+		}
+		return recordLotMovement(tx, movementID, change.lotID, change.quantity)
+	}
+
+	rows, err := tx.Query(`SELECT id, quantity FROM stock_lots WHERE outlet_id = $1 AND product_id = $2 AND quantity > 0
+			AND ($3 OR expiry_date IS NULL OR expiry_date >= current_date)
+		ORDER BY expiry_date NULLS LAST, id FOR UPDATE`, change.outletID, change.productID, allowExpired)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	type lot struct{ id, quantity int }
+	var lots []lot
+	for rows.Next() {
+		var l lot
+		if err := rows.Scan(&l.id, &l.quantity); err != nil {
+			return err
+		}
+		lots = append(lots, l)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	rows.Close()
+
+	for _, l := range lots {
+		quantity := min(remaining, l.quantity)
+		if _, err := tx.Exec("UPDATE stock_lots SET quantity = quantity - $1 WHERE id = $2", quantity, l.id); err != nil {
+			return err
+		}
+		if err := recordLotMovement(tx, movementID, l.id, -quantity); err != nil {
+			return err
+		}
+		if remaining -= quantity; remaining == 0 {
+			return nil
+		}
+	}
+
+	if !allowExpired {
+		return fmt.Errorf("insufficient stock for product id %d, the remaining lots are expired", change.productID)
+	}
+	return fmt.Errorf("stock lots for product id %d do not cover the outlet stock", change.productID)
+}
+
+type outstandingLot struct {
+	key      stockLotKey
+	quantity int
+}
+
+// outstandingLots mengembalikan lot yang stoknya sudah keluar lewat dokumen change (di outlet mana pun)
+// dan belum kembali, lot yang paling lama kedaluwarsa lebih dulu
+func outstandingLots(tx *sql.Tx, change stockChange) ([]outstandingLot, error) {
+	rows, err := tx.Query(`SELECT l.lot_number, coalesce(to_char(l.expiry_date, 'YYYY-MM-DD'), ''), -sum(ml.quantity)
+		FROM stock_movement_lots ml
+		JOIN stock_movements m ON ml.stock_movement_id = m.id
+		JOIN stock_lots l ON ml.lot_id = l.id
+		WHERE m.reference_type = $1 AND m.reference_id = $2 AND m.product_id = $3
+		GROUP BY l.lot_number, l.expiry_date
+		HAVING sum(ml.quantity) < 0
+		ORDER BY l.expiry_date DESC NULLS FIRST, l.lot_number`, change.referenceType, change.referenceID, change.productID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var lots []outstandingLot
+	for rows.Next() {
+		var l outstandingLot
+		if err := rows.Scan(&l.key.number, &l.key.expiry, &l.quantity); err != nil {
+			return nil, err
+		}
+		lots = append(lots, l)
+	}
+	return lots, rows.Err()
+}
+
+func addToLot(tx *sql.Tx, change stockChange, key stockLotKey, quantity, movementID int) error {
+	var lotID int
+	err := tx.QueryRow(`INSERT INTO stock_lots (outlet_id, product_id, lot_number, expiry_date, quantity) VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (outlet_id, product_id, lot_number, (coalesce(expiry_date, 'infinity'::date)))
+		DO UPDATE SET quantity = stock_lots.quantity + EXCLUDED.quantity RETURNING id`,
+		change.outletID, change.productID, key.number, nullString(key.expiry), quantity).Scan(&lotID)
+	if err != nil {
+		return err
+	}
+	return recordLotMovement(tx, movementID, lotID, quantity)
+}
+
+func recordLotMovement(tx *sql.Tx, movementID, lotID, quantity int) error {
+	_, err := tx.Exec(`INSERT INTO stock_movement_lots (stock_movement_id, lot_id, quantity) VALUES ($1, $2, $3)
+		ON CONFLICT (stock_movement_id, lot_id) DO UPDATE SET quantity = stock_movement_lots.quantity + EXCLUDED.quantity`,
+		movementID, lotID, quantity)
+	return err
+}
+
+// setTrackExpiry mengaktifkan atau mematikan pelacakan lot produk. Stok outlet yang sudah ada menjadi
+// lot tanpa tanggal kedaluwarsa. Saat dimatikan, lot produk ditutup (quantity 0) tanpa dihapus supaya
+// riwayat stock_movement_lots di buku stok tetap utuh.
+func setTrackExpiry(tx *sql.Tx, productID int, track bool) error {
+	if _, err := tx.Exec("UPDATE products SET track_expiry = $1 WHERE id = $2", track, productID); err != nil {
+		return err
+	}
+	if !track {
+		_, err := tx.Exec("UPDATE stock_lots SET quantity = 0 WHERE product_id = $1 AND quantity <> 0", productID)
+		return err
+	}
+	_, err := tx.Exec(`INSERT INTO stock_lots (outlet_id, product_id, quantity)
+		SELECT outlet_id, product_id, stock FROM outlet_stocks WHERE product_id = $1 AND stock > 0
+		ON CONFLICT (outlet_id, product_id, lot_number, (coalesce(expiry_date, 'infinity'::date)))
+		DO UPDATE SET quantity = stock_lots.quantity + EXCLUDED.quantity`, productID)
+	return err
+}
+
+// stockLotColumns mengambil lot beserta nama produk, sisa hari sampai kedaluwarsa dan nilai stoknya
+const stockLotColumns = `l.id, l.product_id, coalesce(p.name, ''), coalesce(c.name, ''), l.outlet_id, l.lot_number,
+	coalesce(to_char(l.expiry_date, 'YYYY-MM-DD'), ''), l.expiry_date - current_date, l.quantity, l.quantity * p.cost_price, l.created_at
+	FROM stock_lots l JOIN products p ON l.product_id = p.id LEFT JOIN categories c ON p.category_id = c.id`
+
+func queryStockLots(q queryer, query string, args ...interface{}) ([]models.StockLot, error) {
+	rows, err := q.Query("SELECT "+stockLotColumns+" "+query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	lots := make([]models.StockLot, 0)
+	for rows.Next() {
+		var l models.StockLot
+		err := rows.Scan(&l.ID, &l.ProductID, &l.ProductName, &l.Category, &l.OutletID, &l.LotNumber,
+			&l.ExpiryDate, &l.DaysLeft, &l.Quantity, &l.Value, &l.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		lots = append(lots, l)
+	}
+	return lots, rows.Err()
+}
+
+// GetLots mengembalikan lot produk yang masih berisi dalam urutan FEFO. outletID 0 berarti semua outlet.
+func (repo *ProductRepository) GetLots(productID, outletID int) ([]models.StockLot, error) {
+	var trackExpiry bool
+	err := repo.db.QueryRow("SELECT track_expiry FROM products WHERE id = $1", productID).Scan(&trackExpiry)
+	if err == sql.ErrNoRows {
+		return nil, errors.New("product not found")
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !trackExpiry {
+		return nil, errors.New("product does not track expiry")
+	}
+
+	return queryStockLots(repo.db, `WHERE l.product_id = $1 AND ($2::bigint = 0 OR l.outlet_id = $2) AND l.quantity > 0
+		ORDER BY l.outlet_id, l.expiry_date NULLS LAST, l.id`, productID, outletID)
+}
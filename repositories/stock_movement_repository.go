@@ -0,0 +1,192 @@
+package repositories
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"kasir-api/models"
+	"strings"
+)
+
+// stockChange adalah satu perubahan stok produk di satu outlet yang akan dicatat di buku stok
+type stockChange struct {
+	outletID      int
+	productID     int
+	quantity      int
+	movementType  string
+	reason        string
+	referenceType string
+	referenceID   int
+	user          string
+	lot           *stockLotKey // lot tujuan stok masuk
+	lotID         int          // lot asal stok keluar, 0 berarti FEFO
+}
+
+// applyStockChange mengubah stok produk di outlet dan mencatat pergerakannya di stock_movements.
+// Semua perubahan stok harus lewat fungsi ini supaya buku stok, outlet_stocks dan products.stock
+// (total semua outlet) selalu cocok. Stok outlet tidak boleh menjadi negatif. Mengembalikan saldo
+// stok outlet setelah perubahan. Peringatan stok menipis outlet direset begitu stok outlet kembali di atas reorder point.
+// Untuk produk yang dilacak kedaluwarsanya, perubahan juga dibukukan ke lot (lihat applyLotChange).
+func applyStockChange(tx *sql.Tx, change stockChange) (int, error) {
+	if change.outletID == 0 {
+		return 0, errors.New("outlet is required for stock change")
+	}
+
+	// baris produk dikunci lebih dulu, sama seperti urutan penguncian saat checkout
+	var trackExpiry bool
+	var reorderPoint sql.NullInt64
+	err := tx.QueryRow("UPDATE products SET stock = coalesce(stock, 0) + $1 WHERE id = $2 RETURNING track_expiry, reorder_point",
+		change.quantity, change.productID).Scan(&trackExpiry, &reorderPoint)
+	if err == sql.ErrNoRows {
+		return 0, fmt.Errorf("product id %d not found", change.productID)
+	}
+	if err != nil {
+		return 0, err
+	}
+	if change.lotID != 0 && !trackExpiry {
+		return 0, fmt.Errorf("product id %d does not track expiry", change.productID)
+	}
+
+	// stok produk induk adalah jumlah stok variannya, dicek setelah baris induk terkunci
+	// supaya tidak balapan dengan pembuatan varian pertama. Produk komposisi memakai stok bahannya.
+	var hasVariants, isComposite bool
+	err = tx.QueryRow("SELECT EXISTS (SELECT 1 FROM products WHERE parent_id = $1), EXISTS (SELECT 1 FROM product_components WHERE product_id = $1)",
+		change.productID).Scan(&hasVariants, &isComposite)
+	if err != nil {
+		return 0, err
+	}
+	if hasVariants {
+		return 0, fmt.Errorf("product id %d has variants, stock is kept per variant", change.productID)
+	}
+	if isComposite {
+		return 0, fmt.Errorf("product id %d is made from components, stock is kept per component", change.productID)
+	}
+
+	var balance int
+	if change.quantity >= 0 {
+		err = tx.QueryRow(`INSERT INTO outlet_stocks (outlet_id, product_id, stock) VALUES ($1, $2, $3)
+			ON CONFLICT (outlet_id, product_id) DO UPDATE SET stock = outlet_stocks.stock + EXCLUDED.stock,
+				low_stock_alerted_at = CASE WHEN outlet_stocks.stock + EXCLUDED.stock > $4 THEN NULL ELSE outlet_stocks.low_stock_alerted_at END
+			RETURNING stock`,
+			change.outletID, change.productID, change.quantity, reorderPoint).Scan(&balance)
+	} else {
+		err = tx.QueryRow("UPDATE outlet_stocks SET stock = stock + $1 WHERE outlet_id = $2 AND product_id = $3 AND stock + $1 >= 0 RETURNING stock",
+			change.quantity, change.outletID, change.productID).Scan(&balance)
+		if err == sql.ErrNoRows {
+			return 0, fmt.Errorf("insufficient stock for product id %d", change.productID)
+		}
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	var referenceID sql.NullInt64
+	if change.referenceID != 0 {
+		referenceID = sql.NullInt64{Int64: int64(change.referenceID), Valid: true}
+	}
+	var movementID int
+	err = tx.QueryRow(`INSERT INTO stock_movements (product_id, outlet_id, type, quantity, balance, reason, reference_type, reference_id, "user") VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9) RETURNING id`,
+		change.productID, change.outletID, change.movementType, change.quantity, balance, nullString(change.reason), nullString(change.referenceType), referenceID, nullString(change.user)).Scan(&movementID)
+	if err != nil {
+		return 0, err
+	}
+
+	if trackExpiry && change.quantity != 0 {
+		if err := applyLotChange(tx, change, movementID); err != nil {
+			return 0, err
+		}
+	}
+
+	return balance, nil
+}
+
+// lockProductStock mengunci baris produk dan mengembalikan stoknya di outlet
+func lockProductStock(tx *sql.Tx, outletID, productID int) (int, error) {
+	var stock int
+	err := tx.QueryRow(`SELECT coalesce(os.stock, 0) FROM products p
+		LEFT JOIN outlet_stocks os ON os.product_id = p.id AND os.outlet_id = $1
+		WHERE p.id = $2 FOR UPDATE OF p`, outletID, productID).Scan(&stock)
+	if err == sql.ErrNoRows {
+		return 0, fmt.Errorf("product id %d not found", productID)
+	}
+	return stock, err
+}
+
+func nullString(s string) sql.NullString {
+	return sql.NullString{String: s, Valid: s != ""}
+}
+
+// GetStockMovements mengembalikan buku stok satu produk, terbaru di atas
+func (repo *ProductRepository) GetStockMovements(productID int, filter models.StockMovementFilter) (*models.StockMovementList, error) {
+	var exists bool
+	err := repo.db.QueryRow("SELECT EXISTS (SELECT 1 FROM products WHERE id = $1)", productID).Scan(&exists)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, errors.New("product not found")
+	}
+
+	conditions := []string{"product_id = $1"}
+	args := []interface{}{productID}
+	addCondition := func(condition string, value interface{}) {
+		args = append(args, value)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+
+	if filter.StartDate != "" {
+		addCondition("date(created_at) >= $%d", filter.StartDate)
+	}
+	if filter.EndDate != "" {
+		addCondition("date(created_at) <= $%d", filter.EndDate)
+	}
+	if filter.Type != "" {
+		addCondition("type = $%d", filter.Type)
+	}
+	if filter.OutletID != 0 {
+		addCondition("outlet_id = $%d", filter.OutletID)
+	}
+	where := " WHERE " + strings.Join(conditions, " AND ")
+
+	var total int
+	err = repo.db.QueryRow("SELECT count(*) FROM stock_movements"+where, args...).Scan(&total)
+	if err != nil {
+		return nil, err
+	}
+
+	query := fmt.Sprintf(`SELECT id, product_id, coalesce(outlet_id, 0), type, quantity, balance, coalesce(reason, ''), coalesce(reference_type, ''), reference_id, coalesce("user", ''), created_at
+		FROM stock_movements%s ORDER BY created_at DESC, id DESC LIMIT $%d OFFSET $%d`, where, len(args)+1, len(args)+2)
+	args = append(args, filter.Limit, (filter.Page-1)*filter.Limit)
+
+	rows, err := repo.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	movements := make([]models.StockMovement, 0)
+	for rows.Next() {
+		var (
+			m           models.StockMovement
+			referenceID sql.NullInt64
+		)
+		if err := rows.Scan(&m.ID, &m.ProductID, &m.OutletID, &m.Type, &m.Quantity, &m.Balance, &m.Reason, &m.ReferenceType, &referenceID, &m.User, &m.CreatedAt); err != nil {
+			return nil, err
+		}
+		if referenceID.Valid {
+			id := int(referenceID.Int64)
+			m.ReferenceID = &id
+		}
+		movements = append(movements, m)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return &models.StockMovementList{
+		Data:  movements,
+		Page:  filter.Page,
+		Limit: filter.Limit,
+		Total: total,
+	}, nil
+}
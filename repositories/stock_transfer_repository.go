@@ -0,0 +1,306 @@
+package repositories
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"kasir-api/models"
+	"strings"
+)
+
+type StockTransferRepository struct {
+	db *sql.DB
+}
+
+func NewStockTransferRepository(db *sql.DB) *StockTransferRepository {
+	return &StockTransferRepository{db: db}
+}
+
+const stockTransferColumns = `st.id, st.from_outlet_id, fo.name, st.to_outlet_id, tt.name, st.status, coalesce(st.note, ''),
+	coalesce(st.created_by, ''), coalesce(st.sent_by, ''), coalesce(st.received_by, ''), st.created_at, st.sent_at, st.received_at`
+
+const stockTransferFrom = " FROM stock_transfers st JOIN outlets fo ON st.from_outlet_id = fo.id JOIN outlets tt ON st.to_outlet_id = tt.id"
+
+func scanStockTransfer(scan func(dest ...interface{}) error) (models.StockTransfer, error) {
+	var (
+		t                  models.StockTransfer
+		sentAt, receivedAt sql.NullTime
+	)
+	err := scan(&t.ID, &t.FromOutletID, &t.FromOutletName, &t.ToOutletID, &t.ToOutletName, &t.Status, &t.Note,
+		&t.CreatedBy, &t.SentBy, &t.ReceivedBy, &t.CreatedAt, &sentAt, &receivedAt)
+	if sentAt.Valid {
+		t.SentAt = &sentAt.Time
+	}
+	if receivedAt.Valid {
+		t.ReceivedAt = &receivedAt.Time
+	}
+	return t, err
+}
+
+// GetAll mengembalikan transfer tanpa item. Filter OutletID mencocokkan outlet asal maupun tujuan.
+func (repo *StockTransferRepository) GetAll(filter models.StockTransferFilter) ([]models.StockTransfer, error) {
+	var (
+		conditions []string
+		args       []interface{}
+	)
+	if filter.Status != "" {
+		args = append(args, filter.Status)
+		conditions = append(conditions, fmt.Sprintf("st.status = $%d", len(args)))
+	}
+	if filter.OutletID != 0 {
+		args = append(args, filter.OutletID)
+		conditions = append(conditions, fmt.Sprintf("(st.from_outlet_id = $%[1]d OR st.to_outlet_id = $%[1]d)", len(args)))
+	}
+
+	query := "SELECT " + stockTransferColumns + stockTransferFrom
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	query += " ORDER BY st.id DESC"
+
+	rows, err := repo.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	transfers := make([]models.StockTransfer, 0)
+	for rows.Next() {
+		t, err := scanStockTransfer(rows.Scan)
+		if err != nil {
+			return nil, err
+		}
+		t.Items = make([]models.StockTransferItem, 0)
+		transfers = append(transfers, t)
+	}
+
+	return transfers, rows.Err()
+}
+
+func (repo *StockTransferRepository) GetByID(id int) (*models.StockTransfer, error) {
+	t, err := scanStockTransfer(repo.db.QueryRow("SELECT "+stockTransferColumns+stockTransferFrom+" WHERE st.id = $1", id).Scan)
+	if err == sql.ErrNoRows {
+		return nil, errors.New("stock transfer not found")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := repo.db.Query(`SELECT i.product_id, p.name, i.quantity
+		FROM stock_transfer_items i JOIN products p ON i.product_id = p.id
+		WHERE i.transfer_id = $1 ORDER BY i.product_id`, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	t.Items = make([]models.StockTransferItem, 0)
+	for rows.Next() {
+		var item models.StockTransferItem
+		if err := rows.Scan(&item.ProductID, &item.ProductName, &item.Quantity); err != nil {
+			return nil, err
+		}
+		t.Items = append(t.Items, item)
+	}
+
+	return &t, rows.Err()
+}
+
+// Create membuat transfer draft. FromOutletID 0 berarti outlet default.
+func (repo *StockTransferRepository) Create(input *models.StockTransferInput) (*models.StockTransfer, error) {
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	fromOutletID, err := resolveOutlet(tx, input.FromOutletID)
+	if err != nil {
+		return nil, err
+	}
+	toOutletID, err := resolveOutlet(tx, input.ToOutletID)
+	if err != nil {
+		return nil, err
+	}
+	if fromOutletID == toOutletID {
+		return nil, errors.New("from_outlet_id and to_outlet_id must be different")
+	}
+
+	var id int
+	err = tx.QueryRow("INSERT INTO stock_transfers (from_outlet_id, to_outlet_id, note, created_by) VALUES ($1, $2, $3, $4) RETURNING id",
+		fromOutletID, toOutletID, nullString(input.Note), nullString(input.CreatedBy)).Scan(&id)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, item := range input.Items {
+		var exists bool
+		if err := tx.QueryRow("SELECT EXISTS (SELECT 1 FROM products WHERE id = $1)", item.ProductID).Scan(&exists); err != nil {
+			return nil, err
+		}
+		if !exists {
+			return nil, fmt.Errorf("product id %d not found", item.ProductID)
+		}
+
+		_, err := tx.Exec("INSERT INTO stock_transfer_items (transfer_id, product_id, quantity) VALUES ($1, $2, $3)", id, item.ProductID, item.Quantity)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return repo.GetByID(id)
+}
+
+// Send mengeluarkan stok semua item dari outlet asal, transfer menjadi in_transit
+func (repo *StockTransferRepository) Send(id int, action *models.StockTransferAction) (*models.StockTransfer, error) {
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	t, err := lockStockTransfer(tx, id, models.StockTransferStatusDraft)
+	if err != nil {
+		return nil, err
+	}
+
+	reason := fmt.Sprintf("transfer to outlet #%d", t.ToOutletID)
+	if err := moveTransferStock(tx, t, t.FromOutletID, -1, reason, action.User); err != nil {
+		return nil, err
+	}
+
+	_, err = tx.Exec("UPDATE stock_transfers SET status = $1, sent_by = $2, sent_at = now() WHERE id = $3", models.StockTransferStatusInTransit, nullString(action.User), id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return repo.GetByID(id)
+}
+
+// Receive memasukkan stok semua item ke outlet tujuan, transfer menjadi received
+func (repo *StockTransferRepository) Receive(id int, action *models.StockTransferAction) (*models.StockTransfer, error) {
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	t, err := lockStockTransfer(tx, id, models.StockTransferStatusInTransit)
+	if err != nil {
+		return nil, err
+	}
+
+	reason := fmt.Sprintf("transfer from outlet #%d", t.FromOutletID)
+	if err := moveTransferStock(tx, t, t.ToOutletID, 1, reason, action.User); err != nil {
+		return nil, err
+	}
+
+	_, err = tx.Exec("UPDATE stock_transfers SET status = $1, received_by = $2, received_at = now() WHERE id = $3", models.StockTransferStatusReceived, nullString(action.User), id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return repo.GetByID(id)
+}
+
+// Cancel membatalkan transfer draft, atau transfer in_transit dengan mengembalikan stok ke outlet asal
+func (repo *StockTransferRepository) Cancel(id int, action *models.StockTransferAction) (*models.StockTransfer, error) {
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	t, err := lockStockTransfer(tx, id, models.StockTransferStatusDraft, models.StockTransferStatusInTransit)
+	if err != nil {
+		return nil, err
+	}
+
+	if t.Status == models.StockTransferStatusInTransit {
+		if err := moveTransferStock(tx, t, t.FromOutletID, 1, "transfer cancelled", action.User); err != nil {
+			return nil, err
+		}
+	}
+
+	if _, err := tx.Exec("UPDATE stock_transfers SET status = $1 WHERE id = $2", models.StockTransferStatusCancelled, id); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return repo.GetByID(id)
+}
+
+// lockStockTransfer mengunci transfer, memastikan statusnya salah satu dari allowed dan mengambil itemnya
+func lockStockTransfer(tx *sql.Tx, id int, allowed ...string) (*models.StockTransfer, error) {
+	var t models.StockTransfer
+	err := tx.QueryRow("SELECT id, from_outlet_id, to_outlet_id, status FROM stock_transfers WHERE id = $1 FOR UPDATE", id).
+		Scan(&t.ID, &t.FromOutletID, &t.ToOutletID, &t.Status)
+	if err == sql.ErrNoRows {
+		return nil, errors.New("stock transfer not found")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	ok := false
+	for _, s := range allowed {
+		if t.Status == s {
+			ok = true
+		}
+	}
+	if !ok {
+		return nil, fmt.Errorf("stock transfer is %s", t.Status)
+	}
+
+	rows, err := tx.Query("SELECT product_id, quantity FROM stock_transfer_items WHERE transfer_id = $1 ORDER BY product_id", id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var item models.StockTransferItem
+		if err := rows.Scan(&item.ProductID, &item.Quantity); err != nil {
+			return nil, err
+		}
+		t.Items = append(t.Items, item)
+	}
+
+	return &t, rows.Err()
+}
+
+// moveTransferStock membukukan semua item transfer di satu outlet, sign -1 untuk stok keluar dan 1 untuk stok masuk.
+// Item sudah urut berdasarkan product id sehingga urutan penguncian produk konsisten dengan checkout.
+func moveTransferStock(tx *sql.Tx, t *models.StockTransfer, outletID, sign int, reason, user string) error {
+	for _, item := range t.Items {
+		_, err := applyStockChange(tx, stockChange{
+			outletID:      outletID,
+			productID:     item.ProductID,
+			quantity:      sign * item.Quantity,
+			movementType:  models.StockMovementTransfer,
+			reason:        reason,
+			referenceType: "stock_transfer",
+			referenceID:   t.ID,
+			user:          user,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,282 @@
+package repositories
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"kasir-api/models"
+)
+
+type StocktakeRepository struct {
+	db *sql.DB
+}
+
+func NewStocktakeRepository(db *sql.DB) *StocktakeRepository {
+	return &StocktakeRepository{db: db}
+}
+
+// GetAll mengembalikan sesi stock opname, outletID 0 berarti semua outlet
+func (repo *StocktakeRepository) GetAll(status string, outletID int) ([]models.Stocktake, error) {
+	rows, err := repo.db.Query(`SELECT id FROM stocktakes WHERE ($1 = '' OR status = $1) AND ($2::bigint = 0 OR outlet_id = $2)
+		ORDER BY id DESC`, status, outletID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ids := make([]int, 0)
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	stocktakes := make([]models.Stocktake, 0, len(ids))
+	for _, id := range ids {
+		s, err := repo.GetByID(id)
+		if err != nil {
+			return nil, err
+		}
+		stocktakes = append(stocktakes, *s)
+	}
+
+	return stocktakes, nil
+}
+
+// GetByID mengembalikan sesi beserta hitungan dan selisihnya terhadap stok sistem saat produk dihitung
+func (repo *StocktakeRepository) GetByID(id int) (*models.Stocktake, error) {
+	var (
+		s        models.Stocktake
+		postedAt sql.NullTime
+	)
+	err := repo.db.QueryRow("SELECT id, coalesce(outlet_id, 0), coalesce(name, ''), status, coalesce(opened_by, ''), coalesce(posted_by, ''), created_at, posted_at FROM stocktakes WHERE id = $1", id).
+		Scan(&s.ID, &s.OutletID, &s.Name, &s.Status, &s.OpenedBy, &s.PostedBy, &s.CreatedAt, &postedAt)
+	if err == sql.ErrNoRows {
+		return nil, errors.New("stocktake not found")
+	}
+	if err != nil {
+		return nil, err
+	}
+	if postedAt.Valid {
+		s.PostedAt = &postedAt.Time
+	}
+
+	// hitungan lama tanpa snapshot memakai stok outlet saat ini
+	rows, err := repo.db.Query(`SELECT sc.product_id, p.name, sc.counted_quantity, coalesce(sc.system_stock, os.stock, 0), coalesce(sc.counted_by, ''), sc.updated_at
+		FROM stocktake_counts sc JOIN products p ON sc.product_id = p.id
+		LEFT JOIN outlet_stocks os ON os.product_id = sc.product_id AND os.outlet_id = $2
+		WHERE sc.stocktake_id = $1 ORDER BY p.name, sc.product_id`, id, s.OutletID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	s.Counts = make([]models.StocktakeCount, 0)
+	for rows.Next() {
+		var c models.StocktakeCount
+		if err := rows.Scan(&c.ProductID, &c.ProductName, &c.CountedQuantity, &c.SystemStock, &c.CountedBy, &c.UpdatedAt); err != nil {
+			return nil, err
+		}
+		c.Variance = c.CountedQuantity - c.SystemStock
+		s.TotalVariance += c.Variance
+		s.Counts = append(s.Counts, c)
+	}
+
+	return &s, rows.Err()
+}
+
+func (repo *StocktakeRepository) Create(input *models.StocktakeInput) (*models.Stocktake, error) {
+	outletID, err := resolveOutlet(repo.db, input.OutletID)
+	if err != nil {
+		return nil, err
+	}
+
+	var id int
+	err = repo.db.QueryRow("INSERT INTO stocktakes (outlet_id, name, opened_by) VALUES ($1, $2, $3) RETURNING id", outletID, nullString(input.Name), nullString(input.OpenedBy)).Scan(&id)
+	if err != nil {
+		return nil, err
+	}
+	return repo.GetByID(id)
+}
+
+// SubmitCounts menyimpan hitungan dari satu perangkat. Sesi dikunci FOR SHARE supaya beberapa perangkat
+// bisa mengirim bersamaan tetapi tidak bisa bersamaan dengan posting. Stok outlet saat hitungan dikirim
+// disimpan sebagai system_stock; mode add tetap memakai snapshot dari hitungan pertama produk itu.
+func (repo *StocktakeRepository) SubmitCounts(id int, req *models.StocktakeCountRequest) (*models.Stocktake, error) {
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	outletID, err := lockOpenStocktake(tx, id, "FOR SHARE")
+	if err != nil {
+		return nil, err
+	}
+
+	insert := `INSERT INTO stocktake_counts (stocktake_id, product_id, counted_quantity, counted_by, system_stock)
+		VALUES ($1, $2, $3, $4, coalesce((SELECT stock FROM outlet_stocks WHERE outlet_id = $5 AND product_id = $2), 0))`
+	query := insert + `
+		ON CONFLICT (stocktake_id, product_id) DO UPDATE SET counted_quantity = EXCLUDED.counted_quantity, counted_by = EXCLUDED.counted_by,
+			system_stock = EXCLUDED.system_stock, updated_at = now()
+		RETURNING counted_quantity`
+	if req.Mode == models.StocktakeCountModeAdd {
+		query = insert + `
+			ON CONFLICT (stocktake_id, product_id) DO UPDATE SET counted_quantity = stocktake_counts.counted_quantity + EXCLUDED.counted_quantity, counted_by = EXCLUDED.counted_by,
+				system_stock = coalesce(stocktake_counts.system_stock, EXCLUDED.system_stock), updated_at = now()
+			RETURNING counted_quantity`
+	}
+
+	for _, item := range req.Items {
+		var exists bool
+		if err := tx.QueryRow("SELECT EXISTS (SELECT 1 FROM products WHERE id = $1)", item.ProductID).Scan(&exists); err != nil {
+			return nil, err
+		}
+		if !exists {
+			return nil, fmt.Errorf("product id %d not found", item.ProductID)
+		}
+
+		var counted int
+		if err := tx.QueryRow(query, id, item.ProductID, item.Quantity, nullString(req.CountedBy), outletID).Scan(&counted); err != nil {
+			return nil, err
+		}
+		if counted < 0 {
+			return nil, fmt.Errorf("counted quantity for product id %d cannot be negative", item.ProductID)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return repo.GetByID(id)
+}
+
+// Post membukukan selisih semua hitungan sebagai penyesuaian stok secara atomik. Selisih dihitung terhadap
+// snapshot stok saat produk dihitung, sehingga penjualan setelah penghitungan tidak dianggap susut.
+// Produk yang tidak dihitung tidak diubah.
+func (repo *StocktakeRepository) Post(id int, req *models.StocktakePostRequest) (*models.Stocktake, error) {
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	outletID, err := lockOpenStocktake(tx, id, "FOR UPDATE")
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := tx.Query("SELECT product_id, counted_quantity, system_stock FROM stocktake_counts WHERE stocktake_id = $1 ORDER BY product_id", id)
+	if err != nil {
+		return nil, err
+	}
+	counts := make(map[int]int)
+	snapshots := make(map[int]sql.NullInt64)
+	productIDs := make([]int, 0)
+	for rows.Next() {
+		var productID, counted int
+		var snapshot sql.NullInt64
+		if err := rows.Scan(&productID, &counted, &snapshot); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		counts[productID] = counted
+		snapshots[productID] = snapshot
+		productIDs = append(productIDs, productID)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(productIDs) == 0 {
+		return nil, errors.New("stocktake has no counts")
+	}
+
+	// produk dikunci berurutan berdasarkan id supaya tidak deadlock dengan checkout
+	for _, productID := range productIDs {
+		stock, err := lockProductStock(tx, outletID, productID)
+		if err != nil {
+			return nil, err
+		}
+
+		// hitungan lama tanpa snapshot dibandingkan dengan stok saat posting
+		expected := stock
+		if snapshot := snapshots[productID]; snapshot.Valid {
+			expected = int(snapshot.Int64)
+		} else if _, err := tx.Exec("UPDATE stocktake_counts SET system_stock = $1 WHERE stocktake_id = $2 AND product_id = $3", stock, id, productID); err != nil {
+			return nil, err
+		}
+
+		variance := counts[productID] - expected
+		if variance == 0 {
+			continue
+		}
+		_, err = applyStockChange(tx, stockChange{
+			outletID:      outletID,
+			productID:     productID,
+			quantity:      variance,
+			movementType:  models.StockMovementAdjustment,
+			reason:        "stocktake",
+			referenceType: "stocktake",
+			referenceID:   id,
+			user:          req.PostedBy,
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	_, err = tx.Exec("UPDATE stocktakes SET status = $1, posted_by = $2, posted_at = now() WHERE id = $3", models.StocktakeStatusPosted, nullString(req.PostedBy), id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return repo.GetByID(id)
+}
+
+func (repo *StocktakeRepository) Cancel(id int) error {
+	result, err := repo.db.Exec("UPDATE stocktakes SET status = $1 WHERE id = $2 AND status = $3", models.StocktakeStatusCancelled, id, models.StocktakeStatusOpen)
+	if err != nil {
+		return err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return errors.New("stocktake not found or not open")
+	}
+
+	return nil
+}
+
+// lockOpenStocktake mengunci sesi (FOR SHARE atau FOR UPDATE), memastikan statusnya masih open
+// dan mengembalikan outlet sesi
+func lockOpenStocktake(tx *sql.Tx, id int, lock string) (int, error) {
+	var (
+		status   string
+		outletID int
+	)
+	err := tx.QueryRow("SELECT status, coalesce(outlet_id, 0) FROM stocktakes WHERE id = $1 "+lock, id).Scan(&status, &outletID)
+	if err == sql.ErrNoRows {
+		return 0, errors.New("stocktake not found")
+	}
+	if err != nil {
+		return 0, err
+	}
+	if status != models.StocktakeStatusOpen {
+		return 0, fmt.Errorf("stocktake is already %s", status)
+	}
+	return resolveOutlet(tx, outletID)
+}
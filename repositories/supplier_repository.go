@@ -0,0 +1,114 @@
+package repositories
+
+import (
+	"database/sql"
+	"errors"
+	"kasir-api/models"
+)
+
+type SupplierRepository struct {
+	db *sql.DB
+}
+
+func NewSupplierRepository(db *sql.DB) *SupplierRepository {
+	return &SupplierRepository{db: db}
+}
+
+const supplierColumns = "id, name, coalesce(contact, ''), coalesce(phone, ''), coalesce(email, ''), coalesce(address, ''), created_at"
+
+func (repo *SupplierRepository) GetAll(name string) ([]models.Supplier, error) {
+	query := "SELECT " + supplierColumns + " FROM suppliers"
+
+	var args []interface{}
+	if name != "" {
+		query += " WHERE name ILIKE $1"
+		args = append(args, "%"+name+"%")
+	}
+	query += " ORDER BY id"
+
+	rows, err := repo.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	suppliers := make([]models.Supplier, 0)
+	for rows.Next() {
+		var s models.Supplier
+		if err := rows.Scan(&s.ID, &s.Name, &s.Contact, &s.Phone, &s.Email, &s.Address, &s.CreatedAt); err != nil {
+			return nil, err
+		}
+		suppliers = append(suppliers, s)
+	}
+
+	return suppliers, rows.Err()
+}
+
+func (repo *SupplierRepository) GetByID(id int) (*models.Supplier, error) {
+	var s models.Supplier
+	err := repo.db.QueryRow("SELECT "+supplierColumns+" FROM suppliers WHERE id = $1", id).
+		Scan(&s.ID, &s.Name, &s.Contact, &s.Phone, &s.Email, &s.Address, &s.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, errors.New("supplier not found")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &s, nil
+}
+
+func (repo *SupplierRepository) Create(supplier *models.Supplier) (*models.Supplier, error) {
+	var id int
+	err := repo.db.QueryRow("INSERT INTO suppliers (name, contact, phone, email, address) VALUES ($1, $2, $3, $4, $5) RETURNING id",
+		supplier.Name, nullString(supplier.Contact), nullString(supplier.Phone), nullString(supplier.Email), nullString(supplier.Address)).Scan(&id)
+	if err != nil {
+		return nil, err
+	}
+	return repo.GetByID(id)
+}
+
+func (repo *SupplierRepository) Update(id int, supplier *models.Supplier) (*models.Supplier, error) {
+	result, err := repo.db.Exec("UPDATE suppliers SET name = $1, contact = $2, phone = $3, email = $4, address = $5 WHERE id = $6",
+		supplier.Name, nullString(supplier.Contact), nullString(supplier.Phone), nullString(supplier.Email), nullString(supplier.Address), id)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return nil, err
+	}
+	if rows == 0 {
+		return nil, errors.New("supplier not found")
+	}
+
+	return repo.GetByID(id)
+}
+
+// Delete menghapus supplier yang belum pernah dipakai di purchase order
+func (repo *SupplierRepository) Delete(id int) error {
+	var used bool
+	err := repo.db.QueryRow("SELECT EXISTS (SELECT 1 FROM purchase_orders WHERE supplier_id = $1)", id).Scan(&used)
+	if err != nil {
+		return err
+	}
+	if used {
+		return errors.New("supplier has purchase orders and cannot be deleted")
+	}
+
+	result, err := repo.db.Exec("DELETE FROM suppliers WHERE id = $1", id)
+	if err != nil {
+		return err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return errors.New("supplier not found")
+	}
+
+	return nil
+}
@@ -2,38 +2,207 @@ package repositories
 
 import (
 	"database/sql"
+	"errors"
 	"fmt"
 	"kasir-api/models"
+	"slices"
 	"strings"
+	"time"
+
+	"github.com/lib/pq"
 )
 
 type TransactionRepository struct {
-	db *sql.DB
+	db        *sql.DB
+	taxConfig models.TaxConfig
+	location  *time.Location
 }
 
-func NewTransactionRepository(db *sql.DB) *TransactionRepository {
-	return &TransactionRepository{db: db}
+// location adalah zona waktu toko, dipakai untuk jam promo
+func NewTransactionRepository(db *sql.DB, taxConfig models.TaxConfig, location *time.Location) *TransactionRepository {
+	return &TransactionRepository{db: db, taxConfig: taxConfig, location: location}
 }
 
-func (repo *TransactionRepository) CreateTransaction(items []models.CheckoutItem) (*models.Transaction, error) {
+// ErrIdempotencyKeyConflict dikembalikan jika Idempotency-Key sudah dipakai untuk request dengan body berbeda
+var ErrIdempotencyKeyConflict = errors.New("idempotency key already used with a different request body")
+
+func (repo *TransactionRepository) CreateTransaction(req models.CheckoutRequest) (*models.Transaction, error) {
 	tx, err := repo.db.Begin()
 	if err != nil {
 		return nil, err
 	}
 	defer tx.Rollback()
 
+	transaction, err := repo.createTransaction(tx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return transaction, nil
+}
+
+// CreateTransactionIdempotent menjalankan checkout satu kali per idempotency key. Key diklaim di dalam
+// DB transaction yang sama dengan checkout, sehingga retry yang datang bersamaan akan menunggu request
+// pertama selesai lalu mendapatkan transaksi yang sama. Jika checkout gagal, key ikut di-rollback
+// dan boleh dicoba lagi. Nilai bool bernilai true jika transaksi berasal dari request sebelumnya.
+func (repo *TransactionRepository) CreateTransactionIdempotent(key, requestHash string, ttl time.Duration, req models.CheckoutRequest) (*models.Transaction, bool, error) {
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return nil, false, err
+	}
+	defer tx.Rollback()
+
+	// bersihkan key yang sudah kedaluwarsa supaya bisa dipakai lagi
+	if _, err := tx.Exec("DELETE FROM idempotency_keys WHERE expires_at < now()"); err != nil {
+		return nil, false, err
+	}
+
+	result, err := tx.Exec("INSERT INTO idempotency_keys (key, request_hash, expires_at) VALUES ($1, $2, now() + $3 * interval '1 second') ON CONFLICT (key) DO NOTHING",
+		key, requestHash, int(ttl.Seconds()))
+	if err != nil {
+		return nil, false, err
+	}
+	claimed, err := result.RowsAffected()
+	if err != nil {
+		return nil, false, err
+	}
+
+	if claimed == 0 {
+		// key sudah dipakai request sebelumnya yang sudah commit
+		var storedHash string
+		var transactionID int
+		err := tx.QueryRow("SELECT request_hash, transaction_id FROM idempotency_keys WHERE key = $1", key).Scan(&storedHash, &transactionID)
+		if err != nil {
+			return nil, false, err
+		}
+		if storedHash != requestHash {
+			return nil, false, ErrIdempotencyKeyConflict
+		}
+		tx.Rollback()
+
+		transaction, err := repo.GetByID(transactionID)
+		return transaction, true, err
+	}
+
+	transaction, err := repo.createTransaction(tx, req)
+	if err != nil {
+		return nil, false, err
+	}
+
+	if _, err := tx.Exec("UPDATE idempotency_keys SET transaction_id = $1 WHERE key = $2", transaction.ID, key); err != nil {
+		return nil, false, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, false, err
+	}
+
+	return transaction, false, nil
+}
+
+// checkoutProduct adalah data produk yang dibutuhkan saat checkout
+type checkoutProduct struct {
+	parentID    *int
+	hasVariants bool
+	name        string
+	price       int
+	costPrice   int
+	stock       int
+	categoryID  int
+	category    string
+	taxRate     *float64
+	taxExempt   bool
+}
+
+// saleUnit adalah satuan jual satu item checkout
+type saleUnit struct {
+	factor int
+	price  *int // nil berarti harga satuan dasar produk
+}
+
+func (u saleUnit) unitPrice(p checkoutProduct) int {
+	if u.price != nil {
+		return *u.price
+	}
+	return p.price
+}
+
+// createTransaction menjalankan seluruh proses checkout di dalam DB transaction tx
+func (repo *TransactionRepository) createTransaction(tx *sql.Tx, req models.CheckoutRequest) (*models.Transaction, error) {
+	// checkout dari cart: kunci cart supaya tidak di-checkout dua kali, lalu item, outlet dan customer
+	// diambil dari cart setelah terkunci supaya perubahan item di saat yang sama tidak terlewat
+	if req.CartID != 0 {
+		if err := loadCartCheckout(tx, &req); err != nil {
+			return nil, err
+		}
+	}
+	items := req.Items
+
 	// validasi item yang dicheckout tidak kosong
 	if len(items) == 0 {
 		return nil, fmt.Errorf("no items provided")
 	}
 
-	// validasi quantity item dan hitung jumlah item yang di-checkout
+	// stok diambil dari outlet request, outlet default jika tidak disebut
+	outletID, err := resolveOutlet(tx, req.OutletID)
+	if err != nil {
+		return nil, err
+	}
+
+	// item hasil scan barcode diubah menjadi product id
+	if err := resolveItemBarcodes(tx, items); err != nil {
+		return nil, err
+	}
+
+	// validasi quantity item dan hitung jumlah item yang di-checkout dalam satuan dasar
+	units := make([]saleUnit, len(items))
 	qtyMap := make(map[int]int)
-	for _, item := range items {
+	for i, item := range items {
 		if item.Quantity <= 0 {
 			return nil, fmt.Errorf("quantity for product id %d must be greater than 0", item.ProductID)
 		}
-		qtyMap[item.ProductID] += item.Quantity
+		units[i] = saleUnit{factor: 1}
+		if item.Unit != "" {
+			factor, price, err := lookupUnit(tx, item.ProductID, item.Unit)
+			if err != nil {
+				return nil, err
+			}
+			units[i] = saleUnit{factor: factor, price: &price}
+		}
+		qtyMap[item.ProductID] += item.Quantity * units[i].factor
+	}
+
+	// produk komposisi tidak menyimpan stok sendiri, yang dikurangi adalah stok bahan-bahannya.
+	// stockQty adalah kebutuhan stok per produk dalam satuan dasar.
+	soldIDs := make([]int, 0, len(qtyMap))
+	for id := range qtyMap {
+		soldIDs = append(soldIDs, id)
+	}
+	recipes, err := loadRecipes(tx, soldIDs)
+	if err != nil {
+		return nil, err
+	}
+	stockQty := make(map[int]int)
+	for id, qty := range qtyMap {
+		components, ok := recipes[id]
+		if !ok {
+			stockQty[id] += qty
+			continue
+		}
+		for _, c := range components {
+			stockQty[c.productID] += qty * c.quantity
+		}
+	}
+	lookupIDs := make(map[int]bool)
+	for id := range qtyMap {
+		lookupIDs[id] = true
+	}
+	for id := range stockQty {
+		lookupIDs[id] = true
 	}
 
 	// siapkan string query ke dalam placeholders dan args-nya
@@ -42,7 +211,7 @@ func (repo *TransactionRepository) CreateTransaction(items []models.CheckoutItem
 		args         []interface{}
 		idx          = 1
 	)
-	for id := range qtyMap {
+	for id := range lookupIDs {
 		// buat placeholder sesuai format PostgreSQL: $1, $2, dst
 		placeholders = append(placeholders, fmt.Sprintf("$%d", idx))
 		args = append(args, id)
@@ -52,7 +221,12 @@ func (repo *TransactionRepository) CreateTransaction(items []models.CheckoutItem
 	// query produk sekaligus berdasarkan ProductID yang dibutuhkan
 	// misal ... WHERE id IN ($1, $2, $3)
 	// lalu args diisi dengan variable ProductID, misal []interface{1, 3, 4}
-	query := fmt.Sprintf("SELECT id, name, price, stock FROM products WHERE id IN (%s)", strings.Join(placeholders, ","))
+	// baris produk dikunci sampai checkout selesai supaya cek stok dan reservasi konsisten
+	args = append(args, outletID)
+	query := fmt.Sprintf(`SELECT p.id, p.parent_id, EXISTS (SELECT 1 FROM products v WHERE v.parent_id = p.id), p.name, p.price, p.cost_price,
+			coalesce(os.stock, 0), p.category_id, coalesce(c.name, ''), c.tax_rate, coalesce(c.tax_exempt, false)
+		FROM products p LEFT JOIN categories c ON p.category_id = c.id LEFT JOIN outlet_stocks os ON os.product_id = p.id AND os.outlet_id = $%d
+		WHERE p.id IN (%s) FOR UPDATE OF p`, idx, strings.Join(placeholders, ","))
 	rows, err := tx.Query(query, args...)
 	if err != nil {
 		return nil, err
@@ -60,91 +234,885 @@ func (repo *TransactionRepository) CreateTransaction(items []models.CheckoutItem
 	defer rows.Close()
 
 	// siapkan map "products" yang melakukan mapping hasil query (struct hasil scan). key = id
-	products := map[int]struct {
-		name  string
-		price int
-		stock int
-	}{}
-	var id, price, stock int
-	var name string
+	products := map[int]checkoutProduct{}
 	for rows.Next() {
-		if err := rows.Scan(&id, &name, &price, &stock); err != nil {
+		var id int
+		var p checkoutProduct
+		if err := rows.Scan(&id, &p.parentID, &p.hasVariants, &p.name, &p.price, &p.costPrice, &p.stock, &p.categoryID, &p.category, &p.taxRate, &p.taxExempt); err != nil {
 			return nil, err
 		}
-		products[id] = struct {
-			name  string
-			price int
-			stock int
-		}{name, price, stock}
+		products[id] = p
 	}
+	rows.Close()
 
-	// cek produk ada dan stok cukup
-	for id, qty := range qtyMap {
+	// cek produk ada
+	for id := range qtyMap {
 		p, ok := products[id]
 		if !ok {
 			return nil, fmt.Errorf("product id %d not found", id)
 		}
-		if p.stock < qty {
+		// produk induk hanya pengelompokan, yang dijual adalah variannya
+		if p.hasVariants {
+			return nil, fmt.Errorf("product id %d has variants, choose a variant", id)
+		}
+	}
+
+	// stok yang sedang direservasi cart lain tidak boleh terjual
+	productIDs := make([]int, 0, len(stockQty))
+	for id := range stockQty {
+		productIDs = append(productIDs, id)
+	}
+	reserved, err := reservedStock(tx, outletID, productIDs, req.CartID)
+	if err != nil {
+		return nil, err
+	}
+
+	// cek stok produk dan bahan cukup
+	for id, qty := range stockQty {
+		if products[id].stock-reserved[id] < qty {
 			return nil, fmt.Errorf("insufficient stock for product id %d", id)
 		}
 	}
 
-	// inisialisasi subtotal -> jumlah total transaksi keseluruhan
-	totalAmount := 0
+	// evaluasi promo otomatis terhadap setiap baris item, jam promo dicek di zona waktu toko
+	now := time.Now().In(repo.location)
+	promotions, err := loadActivePromotions(tx, now)
+	if err != nil {
+		return nil, err
+	}
+	promoLines := make([]promoLine, len(items))
+	for i, item := range items {
+		p := products[item.ProductID]
+		promoLines[i] = promoLine{productID: item.ProductID, parentID: p.parentID, categoryID: p.categoryID, unitPrice: units[i].unitPrice(p), quantity: item.Quantity}
+	}
+	appliedPromotions := evaluatePromotions(promotions, promoLines, now)
+
+	// inisialisasi gross -> total harga x quantity sebelum diskon
+	grossAmount, promotionDiscountAmount, lineDiscountAmount := 0, 0, 0
 	// inisialisasi transactionDetails -> nanti kita insert ke db
 	details := make([]models.TransactionDetail, 0)
 
-	// siapkan detail transaksi
-	for _, item := range items {
+	// siapkan detail transaksi beserta diskon per baris. Diskon manual dihitung dari harga setelah promo.
+	for i, item := range items {
 		p := products[item.ProductID]
-		subtotal := item.Quantity * p.price
-		totalAmount += subtotal
+		price := units[i].unitPrice(p)
+		gross := item.Quantity * price
+
+		// harga pokok produk komposisi adalah jumlah harga pokok bahannya
+		costPrice := p.costPrice
+		var components []models.TransactionDetailComponent
+		if recipe, ok := recipes[item.ProductID]; ok {
+			costPrice = 0
+			for _, c := range recipe {
+				component := products[c.productID]
+				costPrice += component.costPrice * c.quantity
+				components = append(components, models.TransactionDetailComponent{
+					ProductID:   c.productID,
+					ProductName: component.name,
+					Quantity:    c.quantity * units[i].factor,
+					UnitCost:    component.costPrice,
+				})
+			}
+		}
+		promoDiscount := promoLines[i].discount
+		discount, err := discountAmount(gross-promoDiscount, item.Discount)
+		if err != nil {
+			return nil, fmt.Errorf("product id %d: %w", item.ProductID, err)
+		}
+
+		grossAmount += gross
+		promotionDiscountAmount += promoDiscount
+		lineDiscountAmount += discount
 		details = append(details, models.TransactionDetail{
-			ProductID:   item.ProductID,
-			ProductName: p.name,
-			Quantity:    item.Quantity,
-			Subtotal:    subtotal,
+			ProductID:         item.ProductID,
+			ProductName:       p.name,
+			CategoryName:      p.category,
+			UnitPrice:         price,
+			UnitCost:          costPrice * units[i].factor,
+			Quantity:          item.Quantity,
+			Unit:              item.Unit,
+			UnitFactor:        units[i].factor,
+			PromotionDiscount: promoDiscount,
+			DiscountAmount:    discount,
+			Subtotal:          gross - promoDiscount - discount,
+			Components:        components,
 		})
 	}
 
+	// diskon transaksi dihitung dari subtotal setelah diskon baris, lalu dibagi ke setiap baris
+	// secara proporsional supaya nominal refund per baris tetap akurat
+	subtotalAmount := grossAmount - promotionDiscountAmount - lineDiscountAmount
+	transactionDiscount, err := discountAmount(subtotalAmount, req.Discount)
+	if err != nil {
+		return nil, fmt.Errorf("transaction discount: %w", err)
+	}
+	subtotalAmount -= transactionDiscount
+
+	// voucher dikunci dan divalidasi di dalam DB transaction yang sama, potongannya dihitung
+	// dari subtotal setelah semua diskon lain
+	var voucher *redeemableVoucher
+	voucherDiscount := 0
+	if strings.TrimSpace(req.VoucherCode) != "" {
+		voucher, voucherDiscount, err = redeemVoucher(tx, req.VoucherCode, req.Customer, subtotalAmount)
+		if err != nil {
+			return nil, err
+		}
+		subtotalAmount -= voucherDiscount
+	}
+
+	weights := make([]int, len(details))
+	for i := range details {
+		weights[i] = details[i].Subtotal
+	}
+	for i, allocated := range allocateProportionally(transactionDiscount+voucherDiscount, weights) {
+		details[i].AllocatedDiscount = allocated
+	}
+
+	// hitung pajak per baris dan service charge sesuai outlet transaksi
+	taxConfig, err := outletTaxConfig(tx, repo.taxConfig, outletID)
+	if err != nil {
+		return nil, err
+	}
+	taxLines := make([]taxLine, len(details))
+	for i, d := range details {
+		p := products[d.ProductID]
+		taxLines[i] = taxLine{net: d.Subtotal - d.AllocatedDiscount, rate: repo.taxConfig.RateFor(p.taxRate, p.taxExempt)}
+	}
+	taxes := calculateTaxes(taxConfig, taxLines)
+	taxableAmount, taxAmount, serviceChargeAmount, totalAmount := 0, 0, 0, 0
+	for i, t := range taxes {
+		details[i].TaxRate = taxLines[i].rate
+		details[i].TaxableAmount = t.taxable
+		details[i].TaxAmount = t.tax
+		details[i].ServiceChargeAmount = t.serviceCharge
+		details[i].LineTotal = t.total
+		taxableAmount += t.taxable
+		taxAmount += t.tax
+		serviceChargeAmount += t.serviceCharge
+		totalAmount += t.total
+	}
+
+	// validasi pembayaran dan hitung kembalian
+	payments, paidAmount, changeAmount, err := preparePayments(totalAmount, req.Payments)
+	if err != nil {
+		return nil, err
+	}
+
 	// insert transaction
 	var transactionID int
-	err = tx.QueryRow("INSERT INTO transactions (total_amount) VALUES ($1) RETURNING id", totalAmount).Scan(&transactionID)
+	var createdAt time.Time
+	var voucherCode, customer interface{}
+	if voucher != nil {
+		voucherCode = voucher.code
+	}
+	if req.Customer != "" {
+		customer = req.Customer
+	}
+	err = tx.QueryRow(`INSERT INTO transactions (outlet_id, gross_amount, promotion_discount_amount, line_discount_amount, discount_amount, voucher_code, voucher_discount_amount, customer, subtotal_amount, tax_mode, taxable_amount, tax_amount, service_charge_amount, total_amount, paid_amount, change_amount)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16) RETURNING id, created_at`,
+		outletID, grossAmount, promotionDiscountAmount, lineDiscountAmount, transactionDiscount, voucherCode, voucherDiscount, customer, subtotalAmount, repo.taxConfig.Mode, taxableAmount, taxAmount, serviceChargeAmount, totalAmount, paidAmount, changeAmount).Scan(&transactionID, &createdAt)
 	if err != nil {
 		return nil, err
 	}
 
-	// update stok produk setelah transaksi
-	for id, qty := range qtyMap {
-		res, err := tx.Exec("UPDATE products SET stock = stock - $1 WHERE id = $2 AND stock >= $1", qty, id)
+	// update stok produk dan bahan setelah transaksi
+	for id, qty := range stockQty {
+		_, err := applyStockChange(tx, stockChange{
+			outletID:      outletID,
+			productID:     id,
+			quantity:      -qty,
+			movementType:  models.StockMovementSale,
+			referenceType: "transaction",
+			referenceID:   transactionID,
+			user:          req.Cashier,
+		})
 		if err != nil {
 			return nil, err
 		}
-		ra, err := res.RowsAffected()
+	}
+
+	// insert transaction details beserta snapshot nama, kategori dan harga produk saat checkout
+	for i := range details {
+		details[i].TransactionID = transactionID
+		d := details[i]
+		err := tx.QueryRow(`INSERT INTO transaction_details (transaction_id, product_id, product_name, category_name, unit_price, unit_cost, quantity, unit, unit_factor, promotion_discount, discount_amount, subtotal, allocated_discount, tax_rate, taxable_amount, tax_amount, service_charge_amount, line_total)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18) RETURNING id`,
+			transactionID, d.ProductID, d.ProductName, d.CategoryName, d.UnitPrice, d.UnitCost, d.Quantity, nullString(d.Unit), d.UnitFactor, d.PromotionDiscount, d.DiscountAmount, d.Subtotal, d.AllocatedDiscount,
+			d.TaxRate, d.TaxableAmount, d.TaxAmount, d.ServiceChargeAmount, d.LineTotal).Scan(&details[i].ID)
 		if err != nil {
 			return nil, err
 		}
-		if ra == 0 {
-			return nil, fmt.Errorf("insufficient stock for product id %d", id)
+		for _, c := range d.Components {
+			_, err := tx.Exec("INSERT INTO transaction_detail_components (transaction_detail_id, product_id, product_name, quantity, unit_cost) VALUES ($1, $2, $3, $4, $5)",
+				details[i].ID, c.ProductID, c.ProductName, c.Quantity, c.UnitCost)
+			if err != nil {
+				return nil, err
+			}
 		}
 	}
 
-	// insert transaction details
-	for i := range details {
-		details[i].TransactionID = transactionID
-		_, err := tx.Exec("INSERT INTO transaction_details (transaction_id, product_id, quantity, subtotal) VALUES ($1, $2, $3, $4)", transactionID, details[i].ProductID, details[i].Quantity, details[i].Subtotal)
+	// catat pemakaian voucher
+	if voucher != nil {
+		_, err := tx.Exec("INSERT INTO voucher_redemptions (voucher_id, transaction_id, customer, discount_amount) VALUES ($1, $2, $3, $4)", voucher.id, transactionID, customer, voucherDiscount)
 		if err != nil {
 			return nil, err
 		}
 	}
 
-	if err := tx.Commit(); err != nil {
-		return nil, err
+	// catat promo yang diterapkan
+	for _, promo := range appliedPromotions {
+		_, err := tx.Exec("INSERT INTO transaction_promotions (transaction_id, promotion_id, promotion_name, discount_amount) VALUES ($1, $2, $3, $4)", transactionID, promo.PromotionID, promo.Name, promo.DiscountAmount)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// insert pembayaran
+	for _, payment := range payments {
+		_, err := tx.Exec("INSERT INTO transaction_payments (transaction_id, method, amount, change_amount) VALUES ($1, $2, $3, $4)", transactionID, payment.Method, payment.Amount, payment.ChangeAmount)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// tutup cart yang di-checkout, reservasi stoknya otomatis lepas
+	if req.CartID != 0 {
+		_, err := tx.Exec("UPDATE carts SET status = $1, transaction_id = $2, updated_at = now() WHERE id = $3", models.CartStatusCheckedOut, transactionID, req.CartID)
+		if err != nil {
+			return nil, err
+		}
 	}
 
 	return &models.Transaction{
-		ID:          transactionID,
-		TotalAmount: totalAmount,
-		Details:     details,
+		ID:                      transactionID,
+		GrossAmount:             grossAmount,
+		PromotionDiscountAmount: promotionDiscountAmount,
+		LineDiscountAmount:      lineDiscountAmount,
+		DiscountAmount:          transactionDiscount,
+		VoucherCode:             voucherCodeString(voucher),
+		VoucherDiscountAmount:   voucherDiscount,
+		Customer:                req.Customer,
+		SubtotalAmount:          subtotalAmount,
+		TaxMode:                 repo.taxConfig.Mode,
+		TaxableAmount:           taxableAmount,
+		TaxAmount:               taxAmount,
+		ServiceChargeAmount:     serviceChargeAmount,
+		TotalAmount:             totalAmount,
+		Status:                  models.TransactionStatusCompleted,
+		PaidAmount:              paidAmount,
+		ChangeAmount:            changeAmount,
+		CreatedAt:               createdAt,
+		Details:                 details,
+		Payments:                payments,
+		Promotions:              appliedPromotions,
 	}, nil
 }
+
+func voucherCodeString(v *redeemableVoucher) string {
+	if v == nil {
+		return ""
+	}
+	return v.code
+}
+
+// preparePayments memvalidasi pembayaran terhadap total transaksi dan menghitung kembalian.
+// Tanpa pembayaran, transaksi dianggap dibayar tunai pas. Kembalian hanya bisa berasal dari cash,
+// sehingga total pembayaran non-tunai tidak boleh melebihi total transaksi.
+func preparePayments(totalAmount int, payments []models.Payment) ([]models.Payment, int, int, error) {
+	if len(payments) == 0 {
+		return []models.Payment{{Method: models.PaymentMethodCash, Amount: totalAmount}}, totalAmount, 0, nil
+	}
+
+	result := make([]models.Payment, 0, len(payments))
+	paid, nonCash, lastCash := 0, 0, -1
+	for _, payment := range payments {
+		method := strings.ToLower(strings.TrimSpace(payment.Method))
+		if !slices.Contains(models.PaymentMethods, method) {
+			return nil, 0, 0, fmt.Errorf("invalid payment method %q", payment.Method)
+		}
+		if payment.Amount <= 0 {
+			return nil, 0, 0, fmt.Errorf("payment amount for %s must be greater than 0", method)
+		}
+
+		paid += payment.Amount
+		if method == models.PaymentMethodCash {
+			lastCash = len(result)
+		} else {
+			nonCash += payment.Amount
+		}
+		result = append(result, models.Payment{Method: method, Amount: payment.Amount})
+	}
+
+	if nonCash > totalAmount {
+		return nil, 0, 0, fmt.Errorf("non-cash payments (%d) exceed total amount (%d)", nonCash, totalAmount)
+	}
+	if paid < totalAmount {
+		return nil, 0, 0, fmt.Errorf("insufficient payment: paid %d of %d", paid, totalAmount)
+	}
+
+	change := paid - totalAmount
+	if change > 0 {
+		// kembalian dicatat pada pembayaran cash terakhir
+		result[lastCash].ChangeAmount = change
+	}
+
+	return result, paid, change, nil
+}
+
+func (repo *TransactionRepository) GetAll(filter models.TransactionFilter) (*models.TransactionList, error) {
+	// susun kondisi WHERE sesuai filter yang diisi
+	var (
+		conditions []string
+		args       []interface{}
+	)
+	addCondition := func(condition string, value interface{}) {
+		args = append(args, value)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+
+	if filter.StartDate != "" {
+		addCondition("date(t.created_at) >= $%d", filter.StartDate)
+	}
+	if filter.EndDate != "" {
+		addCondition("date(t.created_at) <= $%d", filter.EndDate)
+	}
+	if filter.MinAmount != nil {
+		addCondition("t.total_amount >= $%d", *filter.MinAmount)
+	}
+	if filter.MaxAmount != nil {
+		addCondition("t.total_amount <= $%d", *filter.MaxAmount)
+	}
+	if filter.ProductID != 0 {
+		addCondition("EXISTS (SELECT 1 FROM transaction_details td WHERE td.transaction_id = t.id AND td.product_id = $%d)", filter.ProductID)
+	}
+	if filter.Status != "" {
+		addCondition("t.status = $%d", filter.Status)
+	}
+	if filter.OutletID != 0 {
+		addCondition("t.outlet_id = $%d", filter.OutletID)
+	}
+
+	where := ""
+	if len(conditions) > 0 {
+		where = " WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	var total int
+	err := repo.db.QueryRow("SELECT count(*) FROM transactions t"+where, args...).Scan(&total)
+	if err != nil {
+		return nil, err
+	}
+
+	query := fmt.Sprintf("SELECT t.id, coalesce(t.outlet_id, 0), t.gross_amount, t.promotion_discount_amount, t.line_discount_amount, t.discount_amount, coalesce(t.voucher_code, ''), t.voucher_discount_amount, coalesce(t.customer, ''), t.subtotal_amount, t.tax_mode, t.taxable_amount, t.tax_amount, t.service_charge_amount, t.total_amount, t.status, t.refunded_amount, t.paid_amount, t.change_amount, t.created_at FROM transactions t%s ORDER BY t.created_at DESC, t.id DESC LIMIT $%d OFFSET $%d", where, len(args)+1, len(args)+2)
+	args = append(args, filter.Limit, (filter.Page-1)*filter.Limit)
+
+	rows, err := repo.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	transactions := make([]models.Transaction, 0)
+	index := make(map[int]int)
+	ids := make([]int, 0)
+	for rows.Next() {
+		var t models.Transaction
+		if err := rows.Scan(&t.ID, &t.OutletID, &t.GrossAmount, &t.PromotionDiscountAmount, &t.LineDiscountAmount, &t.DiscountAmount, &t.VoucherCode, &t.VoucherDiscountAmount, &t.Customer, &t.SubtotalAmount, &t.TaxMode, &t.TaxableAmount, &t.TaxAmount, &t.ServiceChargeAmount, &t.TotalAmount, &t.Status, &t.RefundedAmount, &t.PaidAmount, &t.ChangeAmount, &t.CreatedAt); err != nil {
+			return nil, err
+		}
+		t.Details = make([]models.TransactionDetail, 0)
+		t.Payments = make([]models.Payment, 0)
+		index[t.ID] = len(transactions)
+		ids = append(ids, t.ID)
+		transactions = append(transactions, t)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// ambil detail untuk semua transaksi di halaman ini sekaligus
+	details, err := repo.getDetails(ids)
+	if err != nil {
+		return nil, err
+	}
+	for _, d := range details {
+		i := index[d.TransactionID]
+		transactions[i].Details = append(transactions[i].Details, d)
+	}
+
+	payments, err := repo.getPayments(ids)
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range payments {
+		i := index[p.transactionID]
+		transactions[i].Payments = append(transactions[i].Payments, p.Payment)
+	}
+
+	return &models.TransactionList{
+		Data:  transactions,
+		Page:  filter.Page,
+		Limit: filter.Limit,
+		Total: total,
+	}, nil
+}
+
+func (repo *TransactionRepository) GetByID(id int) (*models.Transaction, error) {
+	query := "SELECT id, coalesce(outlet_id, 0), gross_amount, promotion_discount_amount, line_discount_amount, discount_amount, coalesce(voucher_code, ''), voucher_discount_amount, coalesce(customer, ''), subtotal_amount, tax_mode, taxable_amount, tax_amount, service_charge_amount, total_amount, status, refunded_amount, paid_amount, change_amount, created_at FROM transactions WHERE id = $1"
+
+	var t models.Transaction
+	err := repo.db.QueryRow(query, id).Scan(&t.ID, &t.OutletID, &t.GrossAmount, &t.PromotionDiscountAmount, &t.LineDiscountAmount, &t.DiscountAmount, &t.VoucherCode, &t.VoucherDiscountAmount, &t.Customer, &t.SubtotalAmount, &t.TaxMode, &t.TaxableAmount, &t.TaxAmount, &t.ServiceChargeAmount, &t.TotalAmount, &t.Status, &t.RefundedAmount, &t.PaidAmount, &t.ChangeAmount, &t.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, errors.New("transaction not found")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	t.Details, err = repo.getDetails([]int{t.ID})
+	if err != nil {
+		return nil, err
+	}
+
+	payments, err := repo.getPayments([]int{t.ID})
+	if err != nil {
+		return nil, err
+	}
+	t.Payments = make([]models.Payment, 0, len(payments))
+	for _, p := range payments {
+		t.Payments = append(t.Payments, p.Payment)
+	}
+
+	t.Promotions, err = repo.getPromotions(t.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	t.Refunds, err = repo.getRefunds(t.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &t, nil
+}
+
+// getDetails mengambil detail transaksi beserta nama produk untuk daftar transaction id
+func (repo *TransactionRepository) getDetails(transactionIDs []int) ([]models.TransactionDetail, error) {
+	details := make([]models.TransactionDetail, 0)
+	if len(transactionIDs) == 0 {
+		return details, nil
+	}
+
+	query := `
+		SELECT td.id, td.transaction_id, coalesce(td.product_id, 0), coalesce(td.product_name, p.name, ''), coalesce(td.category_name, ''),
+		       coalesce(td.unit_price, td.subtotal / nullif(td.quantity, 0), 0), td.unit_cost, td.quantity, coalesce(td.unit, ''), td.unit_factor, td.promotion_discount, td.discount_amount, td.subtotal,
+		       td.allocated_discount, td.tax_rate, td.taxable_amount, td.tax_amount, td.service_charge_amount, td.line_total, td.refunded_quantity
+		FROM transaction_details td
+		LEFT JOIN products p ON td.product_id = p.id
+		WHERE td.transaction_id = ANY($1)
+		ORDER BY td.transaction_id, td.id`
+	rows, err := repo.db.Query(query, pq.Array(transactionIDs))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var d models.TransactionDetail
+		if err := rows.Scan(&d.ID, &d.TransactionID, &d.ProductID, &d.ProductName, &d.CategoryName, &d.UnitPrice, &d.UnitCost, &d.Quantity, &d.Unit, &d.UnitFactor, &d.PromotionDiscount, &d.DiscountAmount, &d.Subtotal, &d.AllocatedDiscount,
+			&d.TaxRate, &d.TaxableAmount, &d.TaxAmount, &d.ServiceChargeAmount, &d.LineTotal, &d.RefundedQuantity); err != nil {
+			return nil, err
+		}
+		details = append(details, d)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	rows.Close()
+
+	// bahan yang dipakai baris produk komposisi
+	detailIndex := make(map[int]int, len(details))
+	detailIDs := make([]int, 0, len(details))
+	for i, d := range details {
+		detailIndex[d.ID] = i
+		detailIDs = append(detailIDs, d.ID)
+	}
+	componentRows, err := repo.db.Query(`SELECT transaction_detail_id, coalesce(product_id, 0), coalesce(product_name, ''), quantity, unit_cost
+		FROM transaction_detail_components WHERE transaction_detail_id = ANY($1) ORDER BY id`, pq.Array(detailIDs))
+	if err != nil {
+		return nil, err
+	}
+	defer componentRows.Close()
+
+	for componentRows.Next() {
+		var detailID int
+		var c models.TransactionDetailComponent
+		if err := componentRows.Scan(&detailID, &c.ProductID, &c.ProductName, &c.Quantity, &c.UnitCost); err != nil {
+			return nil, err
+		}
+		d := &details[detailIndex[detailID]]
+		d.Components = append(d.Components, c)
+	}
+
+	return details, componentRows.Err()
+}
+
+type transactionPayment struct {
+	transactionID int
+	models.Payment
+}
+
+// getPayments mengambil pembayaran untuk daftar transaction id
+func (repo *TransactionRepository) getPayments(transactionIDs []int) ([]transactionPayment, error) {
+	payments := make([]transactionPayment, 0)
+	if len(transactionIDs) == 0 {
+		return payments, nil
+	}
+
+	rows, err := repo.db.Query("SELECT transaction_id, method, amount, change_amount FROM transaction_payments WHERE transaction_id = ANY($1) ORDER BY transaction_id, id", pq.Array(transactionIDs))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var p transactionPayment
+		if err := rows.Scan(&p.transactionID, &p.Method, &p.Amount, &p.ChangeAmount); err != nil {
+			return nil, err
+		}
+		payments = append(payments, p)
+	}
+
+	return payments, rows.Err()
+}
+
+// getPromotions mengambil promo yang diterapkan pada satu transaksi
+func (repo *TransactionRepository) getPromotions(transactionID int) ([]models.AppliedPromotion, error) {
+	rows, err := repo.db.Query("SELECT coalesce(promotion_id, 0), promotion_name, discount_amount FROM transaction_promotions WHERE transaction_id = $1 ORDER BY id", transactionID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	promotions := make([]models.AppliedPromotion, 0)
+	for rows.Next() {
+		var p models.AppliedPromotion
+		if err := rows.Scan(&p.PromotionID, &p.Name, &p.DiscountAmount); err != nil {
+			return nil, err
+		}
+		promotions = append(promotions, p)
+	}
+
+	return promotions, rows.Err()
+}
+
+// getRefunds mengambil riwayat void/refund beserta item-nya untuk satu transaksi
+func (repo *TransactionRepository) getRefunds(transactionID int) ([]models.TransactionRefund, error) {
+	rows, err := repo.db.Query(`
+		SELECT r.id, r.transaction_id, r.type, r.reason, r.refunded_by, r.amount, r.created_at,
+		       ri.transaction_detail_id, ri.quantity, ri.amount
+		FROM transaction_refunds r
+		JOIN transaction_refund_items ri ON ri.refund_id = r.id
+		WHERE r.transaction_id = $1
+		ORDER BY r.id, ri.id`, transactionID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	refunds := make([]models.TransactionRefund, 0)
+	for rows.Next() {
+		var r models.TransactionRefund
+		var item models.TransactionRefundItem
+		err := rows.Scan(&r.ID, &r.TransactionID, &r.Type, &r.Reason, &r.RefundedBy, &r.Amount, &r.CreatedAt,
+			&item.TransactionDetailID, &item.Quantity, &item.Amount)
+		if err != nil {
+			return nil, err
+		}
+		if n := len(refunds); n == 0 || refunds[n-1].ID != r.ID {
+			r.Items = make([]models.TransactionRefundItem, 0)
+			refunds = append(refunds, r)
+		}
+		last := &refunds[len(refunds)-1]
+		last.Items = append(last.Items, item)
+	}
+
+	return refunds, rows.Err()
+}
+
+// Void membatalkan seluruh sisa transaksi dan mengembalikan stok semua item
+func (repo *TransactionRepository) Void(id int, req models.VoidRequest) (*models.Transaction, error) {
+	if strings.TrimSpace(req.Reason) == "" {
+		return nil, errors.New("reason is required")
+	}
+	if strings.TrimSpace(req.VoidedBy) == "" {
+		return nil, errors.New("voided_by is required")
+	}
+
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	lines, err := lockTransactionForRefund(tx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	// void = refund semua quantity yang belum di-refund
+	quantities := make(map[int]int)
+	for _, line := range lines {
+		if remaining := line.quantity - line.refundedQuantity; remaining > 0 {
+			quantities[line.id] = remaining
+		}
+	}
+
+	if _, err := applyRefund(tx, id, lines, quantities, "void", req.Reason, req.VoidedBy); err != nil {
+		return nil, err
+	}
+
+	_, err = tx.Exec("UPDATE transactions SET status = $1, void_reason = $2, voided_by = $3, voided_at = CURRENT_TIMESTAMP WHERE id = $4",
+		models.TransactionStatusVoided, req.Reason, req.VoidedBy, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := releaseVoucherRedemptions(tx, id); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return repo.GetByID(id)
+}
+
+// Refund mengembalikan sebagian item transaksi (per baris transaction_details) dan stoknya
+func (repo *TransactionRepository) Refund(id int, req models.RefundRequest) (*models.Transaction, error) {
+	if strings.TrimSpace(req.Reason) == "" {
+		return nil, errors.New("reason is required")
+	}
+	if strings.TrimSpace(req.RefundedBy) == "" {
+		return nil, errors.New("refunded_by is required")
+	}
+	if len(req.Items) == 0 {
+		return nil, errors.New("no items provided")
+	}
+
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	lines, err := lockTransactionForRefund(tx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	quantities := make(map[int]int)
+	for _, item := range req.Items {
+		if item.Quantity <= 0 {
+			return nil, fmt.Errorf("quantity for transaction detail id %d must be greater than 0", item.TransactionDetailID)
+		}
+		quantities[item.TransactionDetailID] += item.Quantity
+	}
+
+	fullyRefunded, err := applyRefund(tx, id, lines, quantities, "refund", req.Reason, req.RefundedBy)
+	if err != nil {
+		return nil, err
+	}
+
+	status := models.TransactionStatusPartiallyRefunded
+	if fullyRefunded {
+		status = models.TransactionStatusRefunded
+		if err := releaseVoucherRedemptions(tx, id); err != nil {
+			return nil, err
+		}
+	}
+	if _, err := tx.Exec("UPDATE transactions SET status = $1 WHERE id = $2", status, id); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return repo.GetByID(id)
+}
+
+// releaseVoucherRedemptions mengembalikan kuota voucher yang dipakai transaksi, dipanggil saat transaksi
+// di-void atau di-refund seluruhnya sehingga tidak lagi menghabiskan kuota voucher
+func releaseVoucherRedemptions(tx *sql.Tx, transactionID int) error {
+	_, err := tx.Exec("UPDATE vouchers v SET used_count = v.used_count - 1 FROM voucher_redemptions r WHERE r.voucher_id = v.id AND r.transaction_id = $1", transactionID)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec("DELETE FROM voucher_redemptions WHERE transaction_id = $1", transactionID)
+	return err
+}
+
+type refundLine struct {
+	id               int
+	productID        sql.NullInt64
+	quantity         int
+	unitFactor       int
+	amount           int // nominal yang dibayar pelanggan untuk baris ini
+	refundedQuantity int
+}
+
+// lockTransactionForRefund mengunci baris transaksi agar void/refund tidak berjalan bersamaan,
+// lalu mengembalikan baris detailnya
+func lockTransactionForRefund(tx *sql.Tx, id int) (map[int]refundLine, error) {
+	var status string
+	err := tx.QueryRow("SELECT status FROM transactions WHERE id = $1 FOR UPDATE", id).Scan(&status)
+	if err == sql.ErrNoRows {
+		return nil, errors.New("transaction not found")
+	}
+	if err != nil {
+		return nil, err
+	}
+	if status == models.TransactionStatusVoided || status == models.TransactionStatusRefunded {
+		return nil, fmt.Errorf("transaction is already %s", status)
+	}
+
+	rows, err := tx.Query("SELECT id, product_id, quantity, unit_factor, line_total, refunded_quantity FROM transaction_details WHERE transaction_id = $1", id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	lines := make(map[int]refundLine)
+	for rows.Next() {
+		var l refundLine
+		if err := rows.Scan(&l.id, &l.productID, &l.quantity, &l.unitFactor, &l.amount, &l.refundedQuantity); err != nil {
+			return nil, err
+		}
+		lines[l.id] = l
+	}
+
+	return lines, rows.Err()
+}
+
+// applyRefund mencatat refund, menambah refunded_quantity, mengembalikan stok produk ke outlet transaksi
+// dan mengembalikan true jika setelahnya semua baris sudah ter-refund penuh
+func applyRefund(tx *sql.Tx, transactionID int, lines map[int]refundLine, quantities map[int]int, refundType, reason, refundedBy string) (bool, error) {
+	if len(quantities) == 0 {
+		return false, errors.New("nothing left to refund")
+	}
+
+	var outletID int
+	if err := tx.QueryRow("SELECT coalesce(outlet_id, 0) FROM transactions WHERE id = $1", transactionID).Scan(&outletID); err != nil {
+		return false, err
+	}
+	outletID, err := resolveOutlet(tx, outletID)
+	if err != nil {
+		return false, err
+	}
+
+	var refundID int
+	err = tx.QueryRow("INSERT INTO transaction_refunds (transaction_id, type, reason, refunded_by, amount) VALUES ($1, $2, $3, $4, 0) RETURNING id",
+		transactionID, refundType, reason, refundedBy).Scan(&refundID)
+	if err != nil {
+		return false, err
+	}
+
+	totalAmount := 0
+	for detailID, qty := range quantities {
+		line, ok := lines[detailID]
+		if !ok {
+			return false, fmt.Errorf("transaction detail id %d not found in transaction %d", detailID, transactionID)
+		}
+		if line.refundedQuantity+qty > line.quantity {
+			return false, fmt.Errorf("refund quantity for transaction detail id %d exceeds remaining quantity %d", detailID, line.quantity-line.refundedQuantity)
+		}
+
+		// hitung nominal secara kumulatif supaya pembulatan tidak bocor saat baris di-refund bertahap
+		amount := line.amount*(line.refundedQuantity+qty)/line.quantity - line.amount*line.refundedQuantity/line.quantity
+		totalAmount += amount
+
+		_, err := tx.Exec("INSERT INTO transaction_refund_items (refund_id, transaction_detail_id, quantity, amount) VALUES ($1, $2, $3, $4)", refundID, detailID, qty, amount)
+		if err != nil {
+			return false, err
+		}
+		if _, err := tx.Exec("UPDATE transaction_details SET refunded_quantity = refunded_quantity + $1 WHERE id = $2", qty, detailID); err != nil {
+			return false, err
+		}
+
+		// kembalikan stok dalam satuan dasar, kecuali produknya sudah tidak ada
+		returns, err := refundStockReturns(tx, line, qty)
+		if err != nil {
+			return false, err
+		}
+		movementType := models.StockMovementRefund
+		if refundType == "void" {
+			movementType = models.StockMovementVoid
+		}
+		for _, r := range returns {
+			_, err := applyStockChange(tx, stockChange{
+				outletID:      outletID,
+				productID:     r.productID,
+				quantity:      r.quantity,
+				movementType:  movementType,
+				reason:        reason,
+				referenceType: "transaction",
+				referenceID:   transactionID,
+				user:          refundedBy,
+			})
+			if err != nil {
+				return false, err
+			}
+		}
+
+		line.refundedQuantity += qty
+		lines[detailID] = line
+	}
+
+	if _, err := tx.Exec("UPDATE transaction_refunds SET amount = $1 WHERE id = $2", totalAmount, refundID); err != nil {
+		return false, err
+	}
+	if _, err := tx.Exec("UPDATE transactions SET refunded_amount = refunded_amount + $1 WHERE id = $2", totalAmount, transactionID); err != nil {
+		return false, err
+	}
+
+	for _, line := range lines {
+		if line.refundedQuantity < line.quantity {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// refundStockReturns menghitung stok yang kembali saat qty baris di-refund dalam satuan dasar. Baris produk
+// komposisi mengembalikan bahan sesuai snapshot saat checkout; produk atau bahan yang sudah dihapus dilewati.
+func refundStockReturns(tx *sql.Tx, line refundLine, qty int) ([]recipeComponent, error) {
+	rows, err := tx.Query("SELECT product_id, quantity FROM transaction_detail_components WHERE transaction_detail_id = $1 ORDER BY product_id", line.id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var returns []recipeComponent
+	composite := false
+	for rows.Next() {
+		var productID sql.NullInt64
+		var quantity int
+		if err := rows.Scan(&productID, &quantity); err != nil {
+			return nil, err
+		}
+		composite = true
+		if productID.Valid {
+			returns = append(returns, recipeComponent{productID: int(productID.Int64), quantity: quantity * qty})
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if !composite && line.productID.Valid {
+		returns = append(returns, recipeComponent{productID: int(line.productID.Int64), quantity: qty * line.unitFactor})
+	}
+	return returns, nil
+}
@@ -0,0 +1,306 @@
+package repositories
+
+import (
+	"crypto/rand"
+	"database/sql"
+	"errors"
+	"fmt"
+	"kasir-api/models"
+	"math"
+	"strings"
+	"time"
+)
+
+type VoucherRepository struct {
+	db *sql.DB
+}
+
+func NewVoucherRepository(db *sql.DB) *VoucherRepository {
+	return &VoucherRepository{db: db}
+}
+
+const voucherBatchColumns = `b.id, b.name, b.type, b.value, b.max_discount, b.min_spend, b.expires_at, b.usage_limit, b.per_customer_limit, b.active, b.created_at`
+
+func scanVoucherBatch(row interface{ Scan(...interface{}) error }, b *models.VoucherBatch, extra ...interface{}) error {
+	dest := []interface{}{&b.ID, &b.Name, &b.Type, &b.Value, &b.MaxDiscount, &b.MinSpend, &b.ExpiresAt, &b.UsageLimit, &b.PerCustomerLimit, &b.Active, &b.CreatedAt}
+	return row.Scan(append(dest, extra...)...)
+}
+
+func (repo *VoucherRepository) GetAll() ([]models.VoucherBatch, error) {
+	rows, err := repo.db.Query(`
+		SELECT ` + voucherBatchColumns + `, count(v.id), coalesce(sum(v.used_count), 0)
+		FROM voucher_batches b
+		LEFT JOIN vouchers v ON v.batch_id = b.id
+		GROUP BY b.id
+		ORDER BY b.id DESC`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	batches := make([]models.VoucherBatch, 0)
+	for rows.Next() {
+		var b models.VoucherBatch
+		if err := scanVoucherBatch(rows, &b, &b.TotalCodes, &b.TotalRedeemed); err != nil {
+			return nil, err
+		}
+		batches = append(batches, b)
+	}
+
+	return batches, rows.Err()
+}
+
+func (repo *VoucherRepository) GetByID(id int) (*models.VoucherBatch, error) {
+	var b models.VoucherBatch
+	err := scanVoucherBatch(repo.db.QueryRow("SELECT "+voucherBatchColumns+" FROM voucher_batches b WHERE b.id = $1", id), &b)
+	if err == sql.ErrNoRows {
+		return nil, errors.New("voucher batch not found")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := repo.db.Query("SELECT id, batch_id, code, used_count FROM vouchers WHERE batch_id = $1 ORDER BY id", id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	b.Vouchers = make([]models.Voucher, 0)
+	for rows.Next() {
+		var v models.Voucher
+		if err := rows.Scan(&v.ID, &v.BatchID, &v.Code, &v.UsedCount); err != nil {
+			return nil, err
+		}
+		b.TotalCodes++
+		b.TotalRedeemed += v.UsedCount
+		b.Vouchers = append(b.Vouchers, v)
+	}
+
+	return &b, rows.Err()
+}
+
+// Create menyimpan batch dan membuat kode vouchernya dalam satu DB transaction
+func (repo *VoucherRepository) Create(input *models.VoucherBatchInput) (*models.VoucherBatch, error) {
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	b := input.VoucherBatch
+	err = tx.QueryRow(`INSERT INTO voucher_batches (name, type, value, max_discount, min_spend, expires_at, usage_limit, per_customer_limit, active)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9) RETURNING id`,
+		b.Name, b.Type, b.Value, b.MaxDiscount, b.MinSpend, b.ExpiresAt, b.UsageLimit, b.PerCustomerLimit, b.Active).Scan(&b.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	insert := func(code string) (bool, error) {
+		result, err := tx.Exec("INSERT INTO vouchers (batch_id, code) VALUES ($1, $2) ON CONFLICT (code) DO NOTHING", b.ID, code)
+		if err != nil {
+			return false, err
+		}
+		n, err := result.RowsAffected()
+		return n == 1, err
+	}
+
+	for _, code := range input.Codes {
+		ok, err := insert(code)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			return nil, fmt.Errorf("voucher code %s already exists", code)
+		}
+	}
+
+	for created := 0; created < input.Quantity; {
+		code, err := generateVoucherCode(input.Prefix)
+		if err != nil {
+			return nil, err
+		}
+		// kode acak yang bentrok cukup dibuat ulang
+		ok, err := insert(code)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			created++
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return repo.GetByID(b.ID)
+}
+
+// Update mengubah aturan batch. Kode voucher yang sudah dibuat tidak berubah.
+func (repo *VoucherRepository) Update(b *models.VoucherBatch) (*models.VoucherBatch, error) {
+	result, err := repo.db.Exec(`UPDATE voucher_batches SET name = $1, type = $2, value = $3, max_discount = $4, min_spend = $5, expires_at = $6,
+		usage_limit = $7, per_customer_limit = $8, active = $9 WHERE id = $10`,
+		b.Name, b.Type, b.Value, b.MaxDiscount, b.MinSpend, b.ExpiresAt, b.UsageLimit, b.PerCustomerLimit, b.Active, b.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return nil, err
+	}
+	if rows == 0 {
+		return nil, errors.New("voucher batch not found")
+	}
+
+	return repo.GetByID(b.ID)
+}
+
+// GetStatus mengecek apakah kode voucher masih bisa dipakai, tanpa memperhitungkan minimum belanja
+func (repo *VoucherRepository) GetStatus(code, customer string) (*models.VoucherStatus, error) {
+	v, err := findVoucher(repo.db, code, false)
+	if err != nil {
+		return nil, err
+	}
+
+	status := &models.VoucherStatus{Code: v.code, UsedCount: v.usedCount, Batch: v.batch, Valid: true}
+	customerUses, err := countCustomerRedemptions(repo.db, v.batch.ID, customer)
+	if err != nil {
+		return nil, err
+	}
+	if err := v.check(time.Now(), customer, customerUses); err != nil {
+		status.Valid = false
+		status.Reason = err.Error()
+	}
+
+	return status, nil
+}
+
+// redeemableVoucher adalah voucher beserta aturan batch-nya
+type redeemableVoucher struct {
+	id        int
+	code      string
+	usedCount int
+	batch     models.VoucherBatch
+}
+
+// findVoucher mencari voucher berdasarkan kode. Dengan lock=true baris voucher dan batch-nya dikunci
+// sampai DB transaction selesai, sehingga dua kasir tidak bisa memakai kode yang sama bersamaan.
+func findVoucher(q queryer, code string, lock bool) (*redeemableVoucher, error) {
+	query := "SELECT v.id, v.code, v.used_count, " + voucherBatchColumns + " FROM vouchers v JOIN voucher_batches b ON v.batch_id = b.id WHERE v.code = $1"
+	if lock {
+		query += " FOR UPDATE"
+	}
+
+	var v redeemableVoucher
+	b := &v.batch
+	err := q.QueryRow(query, normalizeVoucherCode(code)).Scan(&v.id, &v.code, &v.usedCount,
+		&b.ID, &b.Name, &b.Type, &b.Value, &b.MaxDiscount, &b.MinSpend, &b.ExpiresAt, &b.UsageLimit, &b.PerCustomerLimit, &b.Active, &b.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("voucher %s not found", code)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &v, nil
+}
+
+func countCustomerRedemptions(q queryer, batchID int, customer string) (int, error) {
+	if customer == "" {
+		return 0, nil
+	}
+
+	var count int
+	err := q.QueryRow(`SELECT count(*) FROM voucher_redemptions r JOIN vouchers v ON r.voucher_id = v.id
+		WHERE v.batch_id = $1 AND r.customer = $2`, batchID, customer).Scan(&count)
+	return count, err
+}
+
+// check memvalidasi status, masa berlaku dan batas pemakaian voucher
+func (v *redeemableVoucher) check(now time.Time, customer string, customerUses int) error {
+	if !v.batch.Active {
+		return errors.New("voucher is not active")
+	}
+	if v.batch.ExpiresAt != nil && now.After(*v.batch.ExpiresAt) {
+		return errors.New("voucher has expired")
+	}
+	if v.batch.UsageLimit > 0 && v.usedCount >= v.batch.UsageLimit {
+		return errors.New("voucher usage limit reached")
+	}
+	if v.batch.PerCustomerLimit > 0 {
+		if customer == "" {
+			return errors.New("customer is required for this voucher")
+		}
+		if customerUses >= v.batch.PerCustomerLimit {
+			return errors.New("voucher usage limit for this customer reached")
+		}
+	}
+	return nil
+}
+
+// discount menghitung potongan voucher terhadap amount (subtotal setelah diskon lain)
+func (v *redeemableVoucher) discount(amount int) (int, error) {
+	if amount < v.batch.MinSpend {
+		return 0, fmt.Errorf("voucher requires minimum spend of %d", v.batch.MinSpend)
+	}
+
+	var result int
+	if v.batch.Type == models.DiscountTypePercent {
+		result = int(math.Round(float64(amount) * v.batch.Value / 100))
+		if v.batch.MaxDiscount > 0 {
+			result = min(result, v.batch.MaxDiscount)
+		}
+	} else {
+		result = int(v.batch.Value)
+	}
+
+	return min(result, amount), nil
+}
+
+// redeemVoucher mengunci dan memvalidasi voucher di dalam DB transaction checkout,
+// lalu mengembalikan voucher beserta potongannya terhadap amount
+func redeemVoucher(tx *sql.Tx, code, customer string, amount int) (*redeemableVoucher, int, error) {
+	v, err := findVoucher(tx, code, true)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	customerUses, err := countCustomerRedemptions(tx, v.batch.ID, customer)
+	if err != nil {
+		return nil, 0, err
+	}
+	if err := v.check(time.Now(), customer, customerUses); err != nil {
+		return nil, 0, err
+	}
+
+	discount, err := v.discount(amount)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if _, err := tx.Exec("UPDATE vouchers SET used_count = used_count + 1 WHERE id = $1", v.id); err != nil {
+		return nil, 0, err
+	}
+
+	return v, discount, nil
+}
+
+func normalizeVoucherCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+// karakter kode voucher tanpa huruf/angka yang mirip (0/O, 1/I/L)
+const voucherAlphabet = "ABCDEFGHJKMNPQRSTUVWXYZ23456789"
+
+func generateVoucherCode(prefix string) (string, error) {
+	buf := make([]byte, 8)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	for i, b := range buf {
+		buf[i] = voucherAlphabet[int(b)%len(voucherAlphabet)]
+	}
+	return normalizeVoucherCode(prefix) + string(buf), nil
+}
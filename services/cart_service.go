@@ -0,0 +1,67 @@
+package services
+
+import (
+	"fmt"
+	"kasir-api/models"
+	"kasir-api/repositories"
+	"strings"
+)
+
+type CartService struct {
+	repo               *repositories.CartRepository
+	transactionService *TransactionService
+}
+
+func NewCartService(repo *repositories.CartRepository, transactionService *TransactionService) *CartService {
+	return &CartService{repo: repo, transactionService: transactionService}
+}
+
+func (s *CartService) GetAll(status string, outletID int) ([]models.Cart, error) {
+	return s.repo.GetAll(status, outletID)
+}
+
+func (s *CartService) GetByID(id int) (*models.Cart, error) {
+	return s.repo.GetByID(id)
+}
+
+// Create membuat cart. Cart tidak menyimpan diskon baris; diskon transaksi diberikan saat checkout cart.
+func (s *CartService) Create(input *models.CartInput) (*models.Cart, error) {
+	for _, item := range input.Items {
+		if item.Discount != nil {
+			return nil, fmt.Errorf("cart items cannot have a line discount (product id %d)", item.ProductID)
+		}
+	}
+	return s.repo.Create(input)
+}
+
+func (s *CartService) AddItem(cartID int, input *models.CartItemInput) (*models.Cart, error) {
+	input.Unit = strings.TrimSpace(input.Unit)
+	return s.repo.AddItem(cartID, input)
+}
+
+func (s *CartService) SetItem(cartID int, input *models.CartItemInput) (*models.Cart, error) {
+	input.Unit = strings.TrimSpace(input.Unit)
+	return s.repo.SetItem(cartID, input)
+}
+
+func (s *CartService) RemoveItem(cartID, productID int, unit string) (*models.Cart, error) {
+	return s.repo.RemoveItem(cartID, productID, strings.TrimSpace(unit))
+}
+
+func (s *CartService) Cancel(id int) error {
+	return s.repo.Cancel(id)
+}
+
+// Checkout mengubah isi cart menjadi transaksi lewat alur checkout biasa. req berisi diskon, voucher
+// dan pembayaran; item dan outlet selalu diambil dari cart di dalam transaksi checkout setelah cart dikunci.
+// Jika idempotencyKey diisi, checkout idempotent dipakai.
+func (s *CartService) Checkout(id int, req models.CheckoutRequest, idempotencyKey string) (*models.Transaction, bool, error) {
+	req.Items = nil
+	req.CartID = id
+
+	if idempotencyKey != "" {
+		return s.transactionService.CheckoutIdempotent(idempotencyKey, req)
+	}
+	transaction, err := s.transactionService.Checkout(req)
+	return transaction, false, err
+}
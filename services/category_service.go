@@ -1,6 +1,7 @@
 package services
 
 import (
+	"errors"
 	"kasir-api/models"
 	"kasir-api/repositories"
 )
@@ -13,11 +14,20 @@ func NewCategoryService(repo *repositories.CategoryRepository) *CategoryService
 	return &CategoryService{repo: repo}
 }
 
-func (s *CategoryService) GetAll() ([]models.Category, error) {
-	return s.repo.GetAll()
+func (s *CategoryService) GetAll(includeArchived bool) ([]models.Category, error) {
+	return s.repo.GetAll(includeArchived)
+}
+
+// GetTree mengembalikan hierarki kategori mulai dari kategori akar
+func (s *CategoryService) GetTree(includeArchived bool) ([]models.Category, error) {
+	return s.repo.GetTree(includeArchived)
 }
 
 func (s *CategoryService) Create(data *models.Category) error {
+	data.ArchivedAt = nil
+	if err := validateTaxRate(data.TaxRate); err != nil {
+		return err
+	}
 	return s.repo.Create(data)
 }
 
@@ -26,9 +36,31 @@ func (s *CategoryService) GetByID(id int) (*models.Category, error) {
 }
 
 func (s *CategoryService) Update(category *models.Category) error {
+	if err := validateTaxRate(category.TaxRate); err != nil {
+		return err
+	}
 	return s.repo.Update(category)
 }
 
-func (s *CategoryService) Delete(id int) error {
-	return s.repo.Delete(id)
+// Delete menghapus kategori, produknya dipindah ke reassignTo jika diisi (0 berarti tidak dipindah)
+func (s *CategoryService) Delete(id, reassignTo int) (int, error) {
+	if reassignTo == id {
+		return 0, errors.New("reassign_to must be a different category")
+	}
+	return s.repo.Delete(id, reassignTo)
+}
+
+func (s *CategoryService) Archive(id int) (*models.Category, error) {
+	return s.repo.Archive(id)
+}
+
+func (s *CategoryService) Unarchive(id int) (*models.Category, error) {
+	return s.repo.Unarchive(id)
+}
+
+func validateTaxRate(rate *float64) error {
+	if rate != nil && (*rate < 0 || *rate > 100) {
+		return errors.New("tax_rate must be between 0 and 100")
+	}
+	return nil
 }
@@ -0,0 +1,57 @@
+package services
+
+import (
+	"kasir-api/repositories"
+	"log"
+	"time"
+)
+
+// ExpiredLotWriter mengeluarkan stok lot yang sudah kedaluwarsa secara berkala di background. Setiap run
+// membuat penyesuaian stok dengan alasan expired, lalu produknya dicek ulang untuk peringatan stok menipis.
+type ExpiredLotWriter struct {
+	repo     *repositories.StockAdjustmentRepository
+	interval time.Duration
+	notifier *LowStockNotifier
+}
+
+func NewExpiredLotWriter(repo *repositories.StockAdjustmentRepository, interval time.Duration, notifier *LowStockNotifier) *ExpiredLotWriter {
+	return &ExpiredLotWriter{repo: repo, interval: interval, notifier: notifier}
+}
+
+// Start menjalankan penghapusan di goroutine terpisah, interval 0 berarti tidak dijalankan
+func (w *ExpiredLotWriter) Start() {
+	if w.interval <= 0 {
+		return
+	}
+	go w.run()
+}
+
+func (w *ExpiredLotWriter) run() {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	// jalankan sekali saat start
+	w.writeOff()
+	for range ticker.C {
+		w.writeOff()
+	}
+}
+
+func (w *ExpiredLotWriter) writeOff() {
+	adjustments, err := w.repo.WriteOffExpiredLots()
+	if err != nil {
+		log.Println("failed to write off expired lots:", err)
+		return
+	}
+
+	for _, a := range adjustments {
+		productIDs := make([]int, 0, len(a.Items))
+		quantity := 0
+		for _, item := range a.Items {
+			productIDs = append(productIDs, item.ProductID)
+			quantity += item.Quantity
+		}
+		log.Printf("[EXPIRED] outlet %d: %d stok kedaluwarsa dari %d produk dihapus, penyesuaian #%d", a.OutletID, quantity, len(a.Items), a.ID)
+		w.notifier.Notify(productIDs)
+	}
+}
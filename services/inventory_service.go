@@ -0,0 +1,22 @@
+package services
+
+import (
+	"kasir-api/models"
+	"kasir-api/repositories"
+)
+
+type InventoryService struct {
+	repo *repositories.InventoryRepository
+}
+
+func NewInventoryService(repo *repositories.InventoryRepository) *InventoryService {
+	return &InventoryService{repo: repo}
+}
+
+func (s *InventoryService) GetLowStock(outletID int) ([]models.LowStockItem, error) {
+	return s.repo.GetLowStock(outletID)
+}
+
+func (s *InventoryService) GetExpiringLots(filter models.ExpiringLotFilter) ([]models.StockLot, error) {
+	return s.repo.GetExpiringLots(filter)
+}
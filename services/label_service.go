@@ -0,0 +1,107 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"kasir-api/barcode"
+	"kasir-api/format"
+	"kasir-api/label"
+	"kasir-api/models"
+	"kasir-api/repositories"
+)
+
+// format label
+const (
+	LabelFormatSVG = "svg"
+	LabelFormatPNG = "png"
+	LabelFormatPDF = "pdf"
+)
+
+// batas jumlah label dalam satu permintaan
+const (
+	maxLabelCopies = 100
+	maxLabels      = 500
+)
+
+type LabelService struct {
+	productRepo *repositories.ProductRepository
+}
+
+func NewLabelService(productRepo *repositories.ProductRepository) *LabelService {
+	return &LabelService{productRepo: productRepo}
+}
+
+// Render mengembalikan label produk dan content type-nya. Barcode label memakai barcode EAN pertama
+// produk, atau SKU sebagai Code-128 jika produk tidak punya barcode.
+func (s *LabelService) Render(req models.LabelRequest) ([]byte, string, error) {
+	if req.Format != LabelFormatSVG && req.Format != LabelFormatPNG && req.Format != LabelFormatPDF {
+		return nil, "", errors.New("format must be svg, png or pdf")
+	}
+	size := label.Size{Width: req.Width, Height: req.Height}
+	if err := size.Validate(); err != nil {
+		return nil, "", err
+	}
+	if req.Copies < 1 || req.Copies > maxLabelCopies {
+		return nil, "", fmt.Errorf("copies must be between 1 and %d", maxLabelCopies)
+	}
+	if len(req.ProductIDs) == 0 && req.CategoryID == 0 {
+		return nil, "", errors.New("product_ids or category_id is required")
+	}
+
+	// label tidak mencetak stok, jadi produk diambil dengan outlet default
+	products := make([]models.Product, 0)
+	if len(req.ProductIDs) > 0 {
+		selected, err := s.productRepo.GetByIDs(req.ProductIDs, 0)
+		if err != nil {
+			return nil, "", err
+		}
+		products = append(products, selected...)
+	}
+	if req.CategoryID != 0 {
+		inCategory, err := s.productRepo.GetByCategory(req.CategoryID, 0)
+		if err != nil {
+			return nil, "", err
+		}
+		products = append(products, inCategory...)
+	}
+	if len(products) == 0 {
+		return nil, "", errors.New("no products found for labels")
+	}
+	if len(products)*req.Copies > maxLabels {
+		return nil, "", fmt.Errorf("too many labels, maximum is %d per request", maxLabels)
+	}
+
+	labels := make([]label.Label, 0, len(products)*req.Copies)
+	for _, p := range products {
+		l := productLabel(p)
+		for i := 0; i < req.Copies; i++ {
+			labels = append(labels, l)
+		}
+	}
+
+	switch req.Format {
+	case LabelFormatPNG:
+		body, err := label.PNG(labels, size)
+		return body, "image/png", err
+	case LabelFormatPDF:
+		return label.PDF(labels, size), "application/pdf", nil
+	default:
+		return label.SVG(labels, size), "image/svg+xml", nil
+	}
+}
+
+func productLabel(p models.Product) label.Label {
+	l := label.Label{Name: p.Name, Price: format.Rupiah(p.Price)}
+	if len(p.Barcodes) > 0 {
+		// barcode yang tersimpan sudah tervalidasi, UPC-A disimpan sebagai EAN-13
+		kind, err := barcode.Type(p.Barcodes[0])
+		if err == nil {
+			l.Code, l.Symbology = p.Barcodes[0], kind
+			return l
+		}
+	}
+	if p.SKU != "" {
+		l.Code, l.Symbology = p.SKU, barcode.Code128
+	}
+	return l
+}
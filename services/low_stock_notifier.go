@@ -0,0 +1,130 @@
+package services
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"kasir-api/models"
+	"kasir-api/repositories"
+	"log"
+	"net/http"
+	"time"
+)
+
+// AlertSink adalah tujuan pengiriman peringatan stok menipis
+type AlertSink interface {
+	Send(alert models.LowStockAlert) error
+}
+
+// LogAlertSink menulis peringatan ke log aplikasi
+type LogAlertSink struct{}
+
+func (LogAlertSink) Send(alert models.LowStockAlert) error {
+	p := alert.Product
+	log.Printf("[LOW STOCK] %s (id %d) di outlet %d tersisa %d, reorder point %d, sedang dipesan %d", p.Name, p.ProductID, p.OutletID, p.Stock, p.ReorderPoint, p.OnOrder)
+	return nil
+}
+
+// WebhookAlertSink mengirim peringatan sebagai JSON lewat POST ke URL yang dikonfigurasi
+type WebhookAlertSink struct {
+	url    string
+	client *http.Client
+}
+
+func NewWebhookAlertSink(url string) *WebhookAlertSink {
+	return &WebhookAlertSink{url: url, client: &http.Client{Timeout: 10 * time.Second}}
+}
+
+func (s *WebhookAlertSink) Send(alert models.LowStockAlert) error {
+	body, err := json.Marshal(alert)
+	if err != nil {
+		return err
+	}
+
+	resp, err := s.client.Post(s.url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook responded with status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// LowStockNotifier mengecek stok menipis di background. Checkout memanggil Notify dengan produk yang
+// baru terjual, dan pengecekan penuh dijalankan berkala untuk perubahan stok lain (penyesuaian, stock opname).
+// Duplikasi dicegah di database: produk hanya diperingatkan sekali per outlet sampai stok outlet kembali di atas
+// reorder point. Peringatan yang gagal dikirim ke salah satu sink dilepas lagi dan dicoba pada pengecekan berikutnya.
+type LowStockNotifier struct {
+	repo     *repositories.InventoryRepository
+	sinks    []AlertSink
+	interval time.Duration
+	queue    chan []int
+}
+
+func NewLowStockNotifier(repo *repositories.InventoryRepository, interval time.Duration, sinks ...AlertSink) *LowStockNotifier {
+	return &LowStockNotifier{repo: repo, sinks: sinks, interval: interval, queue: make(chan []int, 100)}
+}
+
+// Start menjalankan pengecek di goroutine terpisah
+func (n *LowStockNotifier) Start() {
+	go n.run()
+}
+
+// Notify meminta pengecekan untuk produk tertentu tanpa menahan request. Jika antrean penuh,
+// permintaan dilewati karena pengecekan berkala akan menangkapnya.
+func (n *LowStockNotifier) Notify(productIDs []int) {
+	if n == nil || len(productIDs) == 0 {
+		return
+	}
+	select {
+	case n.queue <- productIDs:
+	default:
+	}
+}
+
+func (n *LowStockNotifier) run() {
+	var tick <-chan time.Time
+	if n.interval > 0 {
+		ticker := time.NewTicker(n.interval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
+	// cek semua produk sekali saat start
+	n.check(nil)
+	for {
+		select {
+		case ids := <-n.queue:
+			n.check(ids)
+		case <-tick:
+			n.check(nil)
+		}
+	}
+}
+
+func (n *LowStockNotifier) check(productIDs []int) {
+	items, err := n.repo.ClaimLowStockAlerts(productIDs)
+	if err != nil {
+		log.Println("failed to check low stock:", err)
+		return
+	}
+
+	for _, item := range items {
+		alert := models.LowStockAlert{Event: "low_stock", Product: item, OccurredAt: time.Now()}
+		failed := false
+		for _, sink := range n.sinks {
+			if err := sink.Send(alert); err != nil {
+				log.Printf("failed to send low stock alert for product id %d at outlet id %d: %v", item.ProductID, item.OutletID, err)
+				failed = true
+			}
+		}
+		if failed {
+			if err := n.repo.ReleaseLowStockAlert(item.ProductID, item.OutletID); err != nil {
+				log.Printf("failed to release low stock alert for product id %d at outlet id %d: %v", item.ProductID, item.OutletID, err)
+			}
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package services
+
+import (
+	"errors"
+	"kasir-api/models"
+	"kasir-api/repositories"
+	"strings"
+)
+
+type OutletService struct {
+	repo *repositories.OutletRepository
+}
+
+func NewOutletService(repo *repositories.OutletRepository) *OutletService {
+	return &OutletService{repo: repo}
+}
+
+func (s *OutletService) GetAll() ([]models.Outlet, error) {
+	return s.repo.GetAll()
+}
+
+func (s *OutletService) GetByID(id int) (*models.Outlet, error) {
+	return s.repo.GetByID(id)
+}
+
+func (s *OutletService) Create(input *models.OutletInput) (*models.Outlet, error) {
+	if err := validateOutletInput(input); err != nil {
+		return nil, err
+	}
+	return s.repo.Create(input)
+}
+
+func (s *OutletService) Update(id int, input *models.OutletInput) (*models.Outlet, error) {
+	if err := validateOutletInput(input); err != nil {
+		return nil, err
+	}
+	return s.repo.Update(id, input)
+}
+
+func validateOutletInput(input *models.OutletInput) error {
+	if strings.TrimSpace(input.Name) == "" {
+		return errors.New("name is required")
+	}
+	if input.ServiceChargeRate < 0 || input.ServiceChargeRate > 100 {
+		return errors.New("service_charge_rate must be between 0 and 100")
+	}
+	return nil
+}
+
+func (s *OutletService) GetStock(id int) ([]models.OutletStock, error) {
+	return s.repo.GetStock(id)
+}
+
+// FindByAPIKey dipakai middleware API key untuk mengenali API key outlet
+func (s *OutletService) FindByAPIKey(apiKey string) (int, bool, error) {
+	return s.repo.FindByAPIKey(apiKey)
+}
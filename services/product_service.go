@@ -1,8 +1,12 @@
 package services
 
 import (
+	"errors"
+	"fmt"
+	"kasir-api/barcode"
 	"kasir-api/models"
 	"kasir-api/repositories"
+	"strings"
 )
 
 type ProductService struct {
@@ -13,22 +17,143 @@ func NewProductService(repo *repositories.ProductRepository) *ProductService {
 	return &ProductService{repo: repo}
 }
 
-func (s *ProductService) GetAll(name string) ([]models.Product, error) {
-	return s.repo.GetAll(name)
+// categoryID 0 berarti semua kategori, selain itu termasuk produk di sub-kategorinya
+func (s *ProductService) GetAll(name string, categoryID, outletID int) ([]models.Product, error) {
+	return s.repo.GetAll(name, categoryID, outletID)
 }
 
 func (s *ProductService) Create(input *models.ProductInput) (*models.Product, error) {
+	if err := normalizeProductInput(input); err != nil {
+		return nil, err
+	}
+	if input.ParentID == nil && input.Options != nil {
+		return nil, errors.New("options require parent_id")
+	}
+	if input.ParentID != nil && len(input.Options) == 0 {
+		return nil, errors.New("options are required for a variant")
+	}
+	if len(input.Components) > 0 && input.Stock != nil && *input.Stock != 0 {
+		return nil, errors.New("a product with components has no stock of its own, stock must be 0")
+	}
 	return s.repo.Create(input)
 }
 
-func (s *ProductService) GetByID(id int) (*models.Product, error) {
-	return s.repo.GetByID(id)
+func (s *ProductService) GetByID(id, outletID int) (*models.Product, error) {
+	return s.repo.GetByID(id, outletID)
 }
 
 func (s *ProductService) Update(id int, input *models.ProductInput) (*models.Product, error) {
+	if err := normalizeProductInput(input); err != nil {
+		return nil, err
+	}
+	if input.Options != nil && len(input.Options) == 0 {
+		return nil, errors.New("options are required for a variant")
+	}
+	for _, c := range input.Components {
+		if c.ProductID == id {
+			return nil, errors.New("a product cannot be its own component")
+		}
+	}
 	return s.repo.Update(id, input)
 }
 
+// GetByBarcode mencari produk dari hasil scan, UPC-A dan EAN-13 dengan awalan 0 dianggap sama
+func (s *ProductService) GetByBarcode(code string, outletID int) (*models.Product, error) {
+	normalized, err := barcode.Normalize(code)
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.GetByBarcode(normalized, outletID)
+}
+
 func (s *ProductService) Delete(id int) error {
 	return s.repo.Delete(id)
 }
+
+func (s *ProductService) GetStockMovements(id int, filter models.StockMovementFilter) (*models.StockMovementList, error) {
+	return s.repo.GetStockMovements(id, filter)
+}
+
+func (s *ProductService) GetLots(id, outletID int) ([]models.StockLot, error) {
+	return s.repo.GetLots(id, outletID)
+}
+
+// normalizeProductInput merapikan SKU, opsi varian dan satuan serta memvalidasi barcode dan resep
+func normalizeProductInput(input *models.ProductInput) error {
+	if input.SKU != nil {
+		sku := strings.TrimSpace(*input.SKU)
+		input.SKU = &sku
+	}
+	input.Unit = strings.TrimSpace(input.Unit)
+
+	if input.Options != nil {
+		options := make(map[string]string, len(input.Options))
+		for name, value := range input.Options {
+			name, value = strings.TrimSpace(name), strings.TrimSpace(value)
+			if name == "" || value == "" {
+				return errors.New("variant option name and value must not be empty")
+			}
+			options[name] = value
+		}
+		input.Options = options
+	}
+
+	// barcode produk dan semua satuannya tidak boleh kembar
+	seen := make(map[string]bool)
+	if err := normalizeBarcodes(input.Barcodes, seen); err != nil {
+		return err
+	}
+
+	units := make(map[string]bool)
+	for i := range input.Units {
+		u := &input.Units[i]
+		u.Name = strings.TrimSpace(u.Name)
+		if u.Name == "" {
+			return errors.New("unit name must not be empty")
+		}
+		if units[u.Name] || u.Name == input.Unit {
+			return fmt.Errorf("duplicate unit %s", u.Name)
+		}
+		units[u.Name] = true
+		if u.Factor <= 1 {
+			return fmt.Errorf("factor for unit %s must be greater than 1", u.Name)
+		}
+		if u.Price < 0 {
+			return fmt.Errorf("price for unit %s must not be negative", u.Name)
+		}
+		if err := normalizeBarcodes(u.Barcodes, seen); err != nil {
+			return err
+		}
+	}
+
+	components := make(map[int]bool)
+	for _, c := range input.Components {
+		if c.ProductID <= 0 {
+			return errors.New("component product_id is required")
+		}
+		if components[c.ProductID] {
+			return fmt.Errorf("duplicate component product id %d", c.ProductID)
+		}
+		components[c.ProductID] = true
+		if c.Quantity <= 0 {
+			return fmt.Errorf("quantity for component product id %d must be greater than 0", c.ProductID)
+		}
+	}
+	return nil
+}
+
+// normalizeBarcodes memvalidasi barcode dan menyimpan UPC-A dalam bentuk EAN-13, barcode yang kembar ditolak
+func normalizeBarcodes(codes []string, seen map[string]bool) error {
+	for i, code := range codes {
+		normalized, err := barcode.Normalize(code)
+		if err != nil {
+			return err
+		}
+		if seen[normalized] {
+			return fmt.Errorf("duplicate barcode %s", code)
+		}
+		seen[normalized] = true
+		codes[i] = normalized
+	}
+	return nil
+}
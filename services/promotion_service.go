@@ -0,0 +1,111 @@
+package services
+
+import (
+	"errors"
+	"kasir-api/models"
+	"kasir-api/repositories"
+	"strings"
+	"time"
+)
+
+type PromotionService struct {
+	repo *repositories.PromotionRepository
+}
+
+func NewPromotionService(repo *repositories.PromotionRepository) *PromotionService {
+	return &PromotionService{repo: repo}
+}
+
+func (s *PromotionService) GetAll() ([]models.Promotion, error) {
+	return s.repo.GetAll()
+}
+
+func (s *PromotionService) GetByID(id int) (*models.Promotion, error) {
+	return s.repo.GetByID(id)
+}
+
+func (s *PromotionService) Create(promotion *models.Promotion) error {
+	if err := validatePromotion(promotion); err != nil {
+		return err
+	}
+	return s.repo.Create(promotion)
+}
+
+func (s *PromotionService) Update(promotion *models.Promotion) error {
+	if err := validatePromotion(promotion); err != nil {
+		return err
+	}
+	return s.repo.Update(promotion)
+}
+
+func (s *PromotionService) Delete(id int) error {
+	return s.repo.Delete(id)
+}
+
+func validatePromotion(p *models.Promotion) error {
+	p.Name = strings.TrimSpace(p.Name)
+	if p.Name == "" {
+		return errors.New("name is required")
+	}
+
+	switch p.Type {
+	case models.PromotionTypeBOGO:
+		if p.ProductID == nil {
+			return errors.New("product_id is required for bogo promotion")
+		}
+		if p.BuyQuantity <= 0 || p.GetQuantity <= 0 {
+			return errors.New("buy_quantity and get_quantity must be greater than 0")
+		}
+	case models.PromotionTypeBundle:
+		if len(p.BundleItems) == 0 {
+			return errors.New("bundle_items is required for bundle promotion")
+		}
+		seen := make(map[int]bool)
+		for _, item := range p.BundleItems {
+			if item.Quantity <= 0 {
+				return errors.New("bundle item quantity must be greater than 0")
+			}
+			if seen[item.ProductID] {
+				return errors.New("bundle items must not contain duplicate products")
+			}
+			seen[item.ProductID] = true
+		}
+		if p.BundlePrice <= 0 {
+			return errors.New("bundle_price must be greater than 0")
+		}
+	case models.PromotionTypeCategoryPercent:
+		if p.CategoryID == nil {
+			return errors.New("category_id is required for category_percent promotion")
+		}
+	case models.PromotionTypeHappyHour:
+		if p.StartTime == "" || p.EndTime == "" {
+			return errors.New("start_time and end_time are required for happy_hour promotion")
+		}
+	default:
+		return errors.New("type must be one of bogo, bundle, category_percent, happy_hour")
+	}
+
+	if p.Type == models.PromotionTypeCategoryPercent || p.Type == models.PromotionTypeHappyHour {
+		if p.Percent <= 0 || p.Percent > 100 {
+			return errors.New("percent must be greater than 0 and at most 100")
+		}
+	}
+
+	if (p.StartTime == "") != (p.EndTime == "") {
+		return errors.New("start_time and end_time must be set together")
+	}
+	for _, t := range []string{p.StartTime, p.EndTime} {
+		if t == "" {
+			continue
+		}
+		if _, err := time.Parse("15:04", t); err != nil {
+			return errors.New("start_time and end_time must use HH:MM format")
+		}
+	}
+
+	if p.StartDate != nil && p.EndDate != nil && p.EndDate.Before(*p.StartDate) {
+		return errors.New("end_date must not be before start_date")
+	}
+
+	return nil
+}
@@ -0,0 +1,98 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"kasir-api/models"
+	"kasir-api/repositories"
+	"strings"
+	"time"
+)
+
+type PurchaseOrderService struct {
+	repo         *repositories.PurchaseOrderRepository
+	supplierRepo *repositories.SupplierRepository
+}
+
+func NewPurchaseOrderService(repo *repositories.PurchaseOrderRepository, supplierRepo *repositories.SupplierRepository) *PurchaseOrderService {
+	return &PurchaseOrderService{repo: repo, supplierRepo: supplierRepo}
+}
+
+func (s *PurchaseOrderService) GetAll(filter models.PurchaseOrderFilter) ([]models.PurchaseOrder, error) {
+	return s.repo.GetAll(filter)
+}
+
+func (s *PurchaseOrderService) GetByID(id int) (*models.PurchaseOrder, error) {
+	return s.repo.GetByID(id)
+}
+
+func (s *PurchaseOrderService) Create(input *models.PurchaseOrderInput) (*models.PurchaseOrder, error) {
+	if err := s.validate(input); err != nil {
+		return nil, err
+	}
+	return s.repo.Create(input)
+}
+
+func (s *PurchaseOrderService) Update(id int, input *models.PurchaseOrderInput) (*models.PurchaseOrder, error) {
+	if err := s.validate(input); err != nil {
+		return nil, err
+	}
+	return s.repo.Update(id, input)
+}
+
+func (s *PurchaseOrderService) Delete(id int) error {
+	return s.repo.Delete(id)
+}
+
+func (s *PurchaseOrderService) Order(id int) (*models.PurchaseOrder, error) {
+	return s.repo.Order(id)
+}
+
+func (s *PurchaseOrderService) Receive(id int, req *models.GoodsReceiptRequest) (*models.PurchaseOrder, error) {
+	if len(req.Items) == 0 {
+		return nil, errors.New("items must not be empty")
+	}
+	for i := range req.Items {
+		item := &req.Items[i]
+		if item.PurchaseOrderLineID == 0 && item.ProductID == 0 {
+			return nil, errors.New("each item needs purchase_order_line_id or product_id")
+		}
+		if item.Quantity <= 0 {
+			return nil, errors.New("received quantity must be greater than 0")
+		}
+		if item.UnitCost != nil && *item.UnitCost < 0 {
+			return nil, errors.New("unit_cost cannot be negative")
+		}
+		item.LotNumber = strings.TrimSpace(item.LotNumber)
+		if item.ExpiryDate != "" {
+			if _, err := time.Parse("2006-01-02", item.ExpiryDate); err != nil {
+				return nil, errors.New("invalid expiry_date, expected YYYY-MM-DD")
+			}
+		}
+	}
+	return s.repo.Receive(id, req)
+}
+
+func (s *PurchaseOrderService) Close(id int) (*models.PurchaseOrder, error) {
+	return s.repo.Close(id)
+}
+
+func (s *PurchaseOrderService) validate(input *models.PurchaseOrderInput) error {
+	if _, err := s.supplierRepo.GetByID(input.SupplierID); err != nil {
+		return err
+	}
+	if len(input.Lines) == 0 {
+		return errors.New("lines must not be empty")
+	}
+	for i := range input.Lines {
+		line := &input.Lines[i]
+		line.Unit = strings.TrimSpace(line.Unit)
+		if line.Quantity <= 0 {
+			return fmt.Errorf("quantity for product id %d must be greater than 0", line.ProductID)
+		}
+		if line.UnitCost < 0 {
+			return fmt.Errorf("unit_cost for product id %d cannot be negative", line.ProductID)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,50 @@
+package services
+
+import (
+	"errors"
+	"kasir-api/receipt"
+	"kasir-api/repositories"
+)
+
+// format struk
+const (
+	ReceiptFormatText   = "text"
+	ReceiptFormatESCPOS = "escpos"
+	ReceiptFormatPDF    = "pdf"
+)
+
+type ReceiptService struct {
+	repo   *repositories.TransactionRepository
+	config receipt.Config
+}
+
+func NewReceiptService(repo *repositories.TransactionRepository, config receipt.Config) *ReceiptService {
+	return &ReceiptService{repo: repo, config: config}
+}
+
+// Render mengembalikan isi struk dan content type-nya
+func (s *ReceiptService) Render(transactionID int, format string, width int, size string) ([]byte, string, error) {
+	if width != receipt.Width58 && width != receipt.Width80 {
+		return nil, "", errors.New("width must be 58 or 80")
+	}
+	if size != receipt.SizeA4 && size != receipt.SizeA6 {
+		return nil, "", errors.New("size must be a4 or a6")
+	}
+	if format != ReceiptFormatText && format != ReceiptFormatESCPOS && format != ReceiptFormatPDF {
+		return nil, "", errors.New("format must be text, escpos or pdf")
+	}
+
+	transaction, err := s.repo.GetByID(transactionID)
+	if err != nil {
+		return nil, "", err
+	}
+
+	switch format {
+	case ReceiptFormatESCPOS:
+		return receipt.ESCPOS(transaction, s.config, width), "application/octet-stream", nil
+	case ReceiptFormatPDF:
+		return receipt.PDF(transaction, s.config, width, size), "application/pdf", nil
+	default:
+		return receipt.Text(transaction, s.config, width), "text/plain; charset=utf-8", nil
+	}
+}
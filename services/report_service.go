@@ -1,6 +1,7 @@
 package services
 
 import (
+	"errors"
 	"kasir-api/models"
 	"kasir-api/repositories"
 )
@@ -13,10 +14,30 @@ func NewReportService(repo *repositories.ReportRepository) *ReportService {
 	return &ReportService{repo: repo}
 }
 
-func (s *ReportService) GetReportToday() (*models.Report, error) {
-	return s.repo.GetReportToday()
+// outletID 0 berarti laporan konsolidasi semua outlet
+func (s *ReportService) GetReportToday(outletID int) (*models.Report, error) {
+	return s.repo.GetReportToday(outletID)
 }
 
-func (s *ReportService) GetReportByDate(startDate string, endDate string) (*models.Report, error) {
-	return s.repo.GetReportByDate(startDate, endDate)
+func (s *ReportService) GetReportByDate(startDate string, endDate string, outletID int) (*models.Report, error) {
+	return s.repo.GetReportByDate(startDate, endDate, outletID)
+}
+
+func (s *ReportService) GetTaxReport(startDate string, endDate string, outletID int) (*models.TaxReport, error) {
+	return s.repo.GetTaxReport(startDate, endDate, outletID)
+}
+
+// categoryID 0 berarti semua kategori, selain itu termasuk sub-kategorinya
+func (s *ReportService) GetProfitReport(startDate, endDate, groupBy string, outletID, categoryID int) (*models.ProfitReport, error) {
+	if groupBy == "" {
+		groupBy = models.ProfitGroupProduct
+	}
+	if groupBy != models.ProfitGroupProduct && groupBy != models.ProfitGroupCategory && groupBy != models.ProfitGroupDay {
+		return nil, errors.New("group_by must be product, category or day")
+	}
+	return s.repo.GetProfitReport(startDate, endDate, groupBy, outletID, categoryID)
+}
+
+func (s *ReportService) GetIngredientReport(startDate string, endDate string, outletID int) (*models.IngredientReport, error) {
+	return s.repo.GetIngredientReport(startDate, endDate, outletID)
 }
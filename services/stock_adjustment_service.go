@@ -0,0 +1,51 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"kasir-api/models"
+	"kasir-api/repositories"
+	"slices"
+	"sort"
+	"strings"
+)
+
+type StockAdjustmentService struct {
+	repo *repositories.StockAdjustmentRepository
+}
+
+func NewStockAdjustmentService(repo *repositories.StockAdjustmentRepository) *StockAdjustmentService {
+	return &StockAdjustmentService{repo: repo}
+}
+
+func (s *StockAdjustmentService) Create(req *models.StockAdjustmentRequest) (*models.StockAdjustment, error) {
+	if !slices.Contains(models.AdjustmentReasons, req.Reason) {
+		return nil, fmt.Errorf("reason must be one of %s", strings.Join(models.AdjustmentReasons, ", "))
+	}
+	if len(req.Items) == 0 {
+		return nil, errors.New("items must not be empty")
+	}
+
+	seen := make(map[int]bool)
+	for _, item := range req.Items {
+		if item.Quantity <= 0 {
+			return nil, fmt.Errorf("quantity for product id %d must be greater than 0", item.ProductID)
+		}
+		if seen[item.ProductID] {
+			return nil, fmt.Errorf("duplicate product id %d", item.ProductID)
+		}
+		if item.LotID != nil && req.Reason == models.AdjustmentReasonFound {
+			return nil, errors.New("lot_id can only be used when reducing stock")
+		}
+		seen[item.ProductID] = true
+	}
+
+	// urutkan berdasarkan product id supaya urutan penguncian produk konsisten
+	sort.Slice(req.Items, func(i, j int) bool { return req.Items[i].ProductID < req.Items[j].ProductID })
+
+	return s.repo.Create(req)
+}
+
+func (s *StockAdjustmentService) GetByID(id int) (*models.StockAdjustment, error) {
+	return s.repo.GetByID(id)
+}
@@ -0,0 +1,62 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"kasir-api/models"
+	"kasir-api/repositories"
+	"sort"
+)
+
+type StockTransferService struct {
+	repo *repositories.StockTransferRepository
+}
+
+func NewStockTransferService(repo *repositories.StockTransferRepository) *StockTransferService {
+	return &StockTransferService{repo: repo}
+}
+
+func (s *StockTransferService) GetAll(filter models.StockTransferFilter) ([]models.StockTransfer, error) {
+	return s.repo.GetAll(filter)
+}
+
+func (s *StockTransferService) GetByID(id int) (*models.StockTransfer, error) {
+	return s.repo.GetByID(id)
+}
+
+func (s *StockTransferService) Create(input *models.StockTransferInput) (*models.StockTransfer, error) {
+	if input.ToOutletID == 0 {
+		return nil, errors.New("to_outlet_id is required")
+	}
+	if len(input.Items) == 0 {
+		return nil, errors.New("items must not be empty")
+	}
+
+	seen := make(map[int]bool)
+	for _, item := range input.Items {
+		if item.Quantity <= 0 {
+			return nil, fmt.Errorf("quantity for product id %d must be greater than 0", item.ProductID)
+		}
+		if seen[item.ProductID] {
+			return nil, fmt.Errorf("duplicate product id %d", item.ProductID)
+		}
+		seen[item.ProductID] = true
+	}
+
+	// urutkan berdasarkan product id supaya urutan penguncian produk konsisten
+	sort.Slice(input.Items, func(i, j int) bool { return input.Items[i].ProductID < input.Items[j].ProductID })
+
+	return s.repo.Create(input)
+}
+
+func (s *StockTransferService) Send(id int, action *models.StockTransferAction) (*models.StockTransfer, error) {
+	return s.repo.Send(id, action)
+}
+
+func (s *StockTransferService) Receive(id int, action *models.StockTransferAction) (*models.StockTransfer, error) {
+	return s.repo.Receive(id, action)
+}
+
+func (s *StockTransferService) Cancel(id int, action *models.StockTransferAction) (*models.StockTransfer, error) {
+	return s.repo.Cancel(id, action)
+}
@@ -0,0 +1,55 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"kasir-api/models"
+	"kasir-api/repositories"
+)
+
+type StocktakeService struct {
+	repo *repositories.StocktakeRepository
+}
+
+func NewStocktakeService(repo *repositories.StocktakeRepository) *StocktakeService {
+	return &StocktakeService{repo: repo}
+}
+
+func (s *StocktakeService) GetAll(status string, outletID int) ([]models.Stocktake, error) {
+	return s.repo.GetAll(status, outletID)
+}
+
+func (s *StocktakeService) GetByID(id int) (*models.Stocktake, error) {
+	return s.repo.GetByID(id)
+}
+
+func (s *StocktakeService) Create(input *models.StocktakeInput) (*models.Stocktake, error) {
+	return s.repo.Create(input)
+}
+
+func (s *StocktakeService) SubmitCounts(id int, req *models.StocktakeCountRequest) (*models.Stocktake, error) {
+	if req.Mode == "" {
+		req.Mode = models.StocktakeCountModeSet
+	}
+	if req.Mode != models.StocktakeCountModeSet && req.Mode != models.StocktakeCountModeAdd {
+		return nil, errors.New("mode must be set or add")
+	}
+	if len(req.Items) == 0 {
+		return nil, errors.New("items must not be empty")
+	}
+	for _, item := range req.Items {
+		if req.Mode == models.StocktakeCountModeSet && item.Quantity < 0 {
+			return nil, fmt.Errorf("counted quantity for product id %d cannot be negative", item.ProductID)
+		}
+	}
+
+	return s.repo.SubmitCounts(id, req)
+}
+
+func (s *StocktakeService) Post(id int, req *models.StocktakePostRequest) (*models.Stocktake, error) {
+	return s.repo.Post(id, req)
+}
+
+func (s *StocktakeService) Cancel(id int) error {
+	return s.repo.Cancel(id)
+}
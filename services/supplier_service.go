@@ -0,0 +1,42 @@
+package services
+
+import (
+	"errors"
+	"kasir-api/models"
+	"kasir-api/repositories"
+	"strings"
+)
+
+type SupplierService struct {
+	repo *repositories.SupplierRepository
+}
+
+func NewSupplierService(repo *repositories.SupplierRepository) *SupplierService {
+	return &SupplierService{repo: repo}
+}
+
+func (s *SupplierService) GetAll(name string) ([]models.Supplier, error) {
+	return s.repo.GetAll(name)
+}
+
+func (s *SupplierService) GetByID(id int) (*models.Supplier, error) {
+	return s.repo.GetByID(id)
+}
+
+func (s *SupplierService) Create(supplier *models.Supplier) (*models.Supplier, error) {
+	if strings.TrimSpace(supplier.Name) == "" {
+		return nil, errors.New("name is required")
+	}
+	return s.repo.Create(supplier)
+}
+
+func (s *SupplierService) Update(id int, supplier *models.Supplier) (*models.Supplier, error) {
+	if strings.TrimSpace(supplier.Name) == "" {
+		return nil, errors.New("name is required")
+	}
+	return s.repo.Update(id, supplier)
+}
+
+func (s *SupplierService) Delete(id int) error {
+	return s.repo.Delete(id)
+}
@@ -1,18 +1,80 @@
 package services
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
 	"kasir-api/models"
 	"kasir-api/repositories"
+	"time"
 )
 
 type TransactionService struct {
-	repo *repositories.TransactionRepository
+	repo             *repositories.TransactionRepository
+	idempotencyTTL   time.Duration
+	lowStockNotifier *LowStockNotifier
 }
 
-func NewTransactionService(repo *repositories.TransactionRepository) *TransactionService {
-	return &TransactionService{repo: repo}
+func NewTransactionService(repo *repositories.TransactionRepository, idempotencyTTL time.Duration, lowStockNotifier *LowStockNotifier) *TransactionService {
+	return &TransactionService{repo: repo, idempotencyTTL: idempotencyTTL, lowStockNotifier: lowStockNotifier}
 }
 
-func (s *TransactionService) Checkout(items []models.CheckoutItem) (*models.Transaction, error) {
-	return s.repo.CreateTransaction(items)
+func (s *TransactionService) Checkout(req models.CheckoutRequest) (*models.Transaction, error) {
+	transaction, err := s.repo.CreateTransaction(req)
+	if err != nil {
+		return nil, err
+	}
+	s.notifyStockSold(transaction)
+	return transaction, nil
+}
+
+// CheckoutIdempotent menjalankan checkout dengan Idempotency-Key. Body request dibandingkan lewat hash
+// dari request yang sudah di-decode, jadi beda spasi atau urutan field tidak dianggap body berbeda.
+func (s *TransactionService) CheckoutIdempotent(key string, req models.CheckoutRequest) (*models.Transaction, bool, error) {
+	body, err := json.Marshal(struct {
+		models.CheckoutRequest
+		CartID   int `json:"cart_id"`
+		OutletID int `json:"outlet_id"`
+	}{req, req.CartID, req.OutletID})
+	if err != nil {
+		return nil, false, err
+	}
+	sum := sha256.Sum256(body)
+
+	transaction, replayed, err := s.repo.CreateTransactionIdempotent(key, hex.EncodeToString(sum[:]), s.idempotencyTTL, req)
+	if err != nil {
+		return nil, false, err
+	}
+	if !replayed {
+		s.notifyStockSold(transaction)
+	}
+	return transaction, replayed, nil
+}
+
+// notifyStockSold meminta pengecekan stok menipis untuk produk dan bahan yang baru terjual
+func (s *TransactionService) notifyStockSold(transaction *models.Transaction) {
+	productIDs := make([]int, 0, len(transaction.Details))
+	for _, d := range transaction.Details {
+		productIDs = append(productIDs, d.ProductID)
+		for _, c := range d.Components {
+			productIDs = append(productIDs, c.ProductID)
+		}
+	}
+	s.lowStockNotifier.Notify(productIDs)
+}
+
+func (s *TransactionService) GetAll(filter models.TransactionFilter) (*models.TransactionList, error) {
+	return s.repo.GetAll(filter)
+}
+
+func (s *TransactionService) GetByID(id int) (*models.Transaction, error) {
+	return s.repo.GetByID(id)
+}
+
+func (s *TransactionService) Void(id int, req models.VoidRequest) (*models.Transaction, error) {
+	return s.repo.Void(id, req)
+}
+
+func (s *TransactionService) Refund(id int, req models.RefundRequest) (*models.Transaction, error) {
+	return s.repo.Refund(id, req)
 }
@@ -0,0 +1,94 @@
+package services
+
+import (
+	"errors"
+	"kasir-api/models"
+	"kasir-api/repositories"
+	"math"
+	"regexp"
+	"strings"
+)
+
+type VoucherService struct {
+	repo *repositories.VoucherRepository
+}
+
+func NewVoucherService(repo *repositories.VoucherRepository) *VoucherService {
+	return &VoucherService{repo: repo}
+}
+
+// batas jumlah kode yang dibuat dalam satu request
+const maxVoucherBatchSize = 10000
+
+var voucherCodePattern = regexp.MustCompile(`^[A-Z0-9-]{3,32}$`)
+
+func (s *VoucherService) GetAll() ([]models.VoucherBatch, error) {
+	return s.repo.GetAll()
+}
+
+func (s *VoucherService) GetByID(id int) (*models.VoucherBatch, error) {
+	return s.repo.GetByID(id)
+}
+
+func (s *VoucherService) Create(input *models.VoucherBatchInput) (*models.VoucherBatch, error) {
+	if err := validateVoucherBatch(&input.VoucherBatch); err != nil {
+		return nil, err
+	}
+
+	input.Prefix = strings.ToUpper(strings.TrimSpace(input.Prefix))
+	if input.Prefix != "" && !voucherCodePattern.MatchString(input.Prefix) {
+		return nil, errors.New("prefix may only contain letters, digits and dashes")
+	}
+	for i, code := range input.Codes {
+		code = strings.ToUpper(strings.TrimSpace(code))
+		if !voucherCodePattern.MatchString(code) {
+			return nil, errors.New("codes must be 3-32 letters, digits or dashes")
+		}
+		input.Codes[i] = code
+	}
+	if input.Quantity < 0 || input.Quantity+len(input.Codes) > maxVoucherBatchSize {
+		return nil, errors.New("quantity must be between 0 and 10000")
+	}
+	if input.Quantity+len(input.Codes) == 0 {
+		return nil, errors.New("quantity or codes is required")
+	}
+
+	return s.repo.Create(input)
+}
+
+func (s *VoucherService) Update(batch *models.VoucherBatch) (*models.VoucherBatch, error) {
+	if err := validateVoucherBatch(batch); err != nil {
+		return nil, err
+	}
+	return s.repo.Update(batch)
+}
+
+func (s *VoucherService) GetStatus(code, customer string) (*models.VoucherStatus, error) {
+	return s.repo.GetStatus(code, customer)
+}
+
+func validateVoucherBatch(b *models.VoucherBatch) error {
+	b.Name = strings.TrimSpace(b.Name)
+	if b.Name == "" {
+		return errors.New("name is required")
+	}
+
+	switch b.Type {
+	case models.DiscountTypePercent:
+		if b.Value <= 0 || b.Value > 100 {
+			return errors.New("percentage voucher value must be greater than 0 and at most 100")
+		}
+	case models.DiscountTypeFixed:
+		if b.Value <= 0 || b.Value != math.Trunc(b.Value) {
+			return errors.New("fixed voucher value must be a whole rupiah amount greater than 0")
+		}
+	default:
+		return errors.New("type must be percent or fixed")
+	}
+
+	if b.MaxDiscount < 0 || b.MinSpend < 0 || b.UsageLimit < 0 || b.PerCustomerLimit < 0 {
+		return errors.New("max_discount, min_spend, usage_limit and per_customer_limit must not be negative")
+	}
+
+	return nil
+}